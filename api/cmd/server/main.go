@@ -3,19 +3,57 @@ package main
 import (
 	"api/internal/api"
 	"api/internal/initializers"
+	"api/internal/jobs"
+	"api/internal/notifications"
+	"api/internal/pinning"
+	"api/internal/webhooks"
+	"context"
 	"github.com/gin-gonic/gin"
 	"log"
 )
 
 func init() {
 	initializers.LoadENV()
+	initializers.InitClientOrigins()
 	initializers.InitRedis()
 	initializers.InitDB()
+	initializers.InitChain()
+	initializers.InitAnchor()
+	initializers.InitMail()
+	initializers.InitKMS()
+	initializers.InitPush()
+	initializers.InitReceipts()
+	initializers.InitStatusLists()
+	initializers.InitIPFS()
+	initializers.InitPinning()
+	initializers.InitTSA()
 }
 
 func main() {
+	ctx := context.Background()
+
+	// Background workers
+	go jobs.OutboxRelay(initializers.DB).Run(ctx)
+	go webhooks.NewWorker(initializers.DB).Run(ctx)
+	go jobs.ExpireRequests(ctx, initializers.DB)
+	go jobs.RemindExpiringRequests(ctx, initializers.DB)
+	go jobs.VerifyAuditLog(ctx, initializers.DB)
+	if initializers.AuditAnchorer != nil {
+		go jobs.AnchorAuditLog(ctx, initializers.DB, initializers.AuditAnchorer)
+	}
+	if initializers.IPFS != nil {
+		go jobs.IndexTranscriptMetadata(ctx, initializers.DB, initializers.IPFS)
+	}
+	if len(initializers.PinningServices) > 0 {
+		monitor := pinning.NewMonitor(initializers.DB, initializers.PinningServices, initializers.PinningOrigins, notifications.PinLost)
+		go jobs.MonitorPins(ctx, monitor)
+	}
+	if initializers.NFTCMS != nil {
+		go jobs.WatchRevocations(ctx, initializers.DB, initializers.NFTCMS)
+	}
 
 	r := gin.Default()
+	initializers.ConfigureProxies(r)
 
 	api.SetupRoutes(r)
 
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"api/internal/audit"
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"api/internal/models"
+	"api/internal/repository"
+	"errors"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+// GetAccessLog lists the audit entries about the wallet's transcripts and sessions, newest first.
+// Each entry carries its hash and the previous hash so the owner can check the chain.
+func GetAccessLog(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	var input repository.AccessLogInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		log.Error("Binding error: ", err)
+		panic(customErrors.ErrInsufficientData)
+	}
+
+	if err := input.Validate(); err != nil {
+		log.Error("Invalid access log filters: ", err)
+		panic(err)
+	}
+
+	entries, err := audit.ListForSubject(initializers.DB, walletAddress, input.Action, input.BeforeID, input.Limit)
+	if err != nil {
+		log.Error("Failed to get access log: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	entryList := make([]gin.H, 0, len(entries))
+	for _, entry := range entries {
+		entryList = append(entryList, auditEntryResponse(entry))
+	}
+
+	var nextCursor string
+	if len(entries) == input.Limit {
+		nextCursor = strconv.FormatUint(entries[len(entries)-1].ID, 10)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Access log retrieved successfully",
+		"entries":     entryList,
+		"next_cursor": nextCursor,
+	})
+}
+
+// GetAccessLogProof returns an access log entry with its Merkle inclusion proof and the
+// transaction that anchored the root on chain. proof and anchor are null until the entry's batch
+// has been anchored.
+func GetAccessLogProof(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	entryID, err := strconv.ParseUint(c.Param("entry_id"), 10, 64)
+	if err != nil {
+		panic(customErrors.ErrAuditEntryNotFound)
+	}
+
+	entry, proof, err := audit.GetEntryProof(initializers.DB, entryID, walletAddress)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		panic(customErrors.ErrAuditEntryNotFound)
+	}
+	if err != nil {
+		log.Error("Failed to get audit proof: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	response := gin.H{"message": "Audit entry retrieved successfully", "entry": auditEntryResponse(entry), "proof": nil, "anchor": nil}
+	if proof != nil {
+		response["proof"] = gin.H{
+			"leaf":  proof.Leaf,
+			"nodes": proof.Proof,
+			"root":  proof.Anchor.Root,
+		}
+		response["anchor"] = gin.H{
+			"status":         proof.Anchor.Status,
+			"first_entry_id": proof.Anchor.FirstEntryID,
+			"last_entry_id":  proof.Anchor.LastEntryID,
+			"chain_id":       proof.Anchor.ChainID,
+			"contract":       proof.Anchor.Contract,
+			"tx_hash":        proof.Anchor.TxHash,
+			"block_number":   proof.Anchor.BlockNumber,
+			"anchored_at":    proof.Anchor.AnchoredAt,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func auditEntryResponse(entry models.AuditEntry) gin.H {
+	return gin.H{
+		"id":             entry.ID,
+		"action":         entry.Action,
+		"actor_wallet":   entry.ActorWallet,
+		"subject_wallet": entry.SubjectWallet,
+		"resource":       entry.Resource,
+		"outcome":        entry.Outcome,
+		"client_ip":      entry.ClientIP,
+		"user_agent":     entry.UserAgent,
+		"metadata":       entry.Metadata,
+		"created_at":     entry.CreatedAt,
+		"prev_hash":      entry.PrevHash,
+		"hash":           entry.Hash,
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"api/internal/audit"
+	"api/internal/customErrors"
+	"api/internal/envelope"
+	"api/internal/initializers"
+	"api/internal/ipfs"
+	"api/internal/ipfsuri"
+	"api/internal/models"
+	"api/internal/repository"
+	"api/pkg/utils"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"github.com/ipfs/go-cid"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// inlineContentTypes are the sniffed types shown in the browser. Anything else, HTML and SVG
+// above all, is served as a sandboxed download so it cannot run script on the API origin.
+var inlineContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/gif":       true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+	"text/plain":      true,
+}
+
+// GetContent streams a transcript file from IPFS to a wallet allowed to open it. Every block is
+// verified against its CID, so a misbehaving node cannot serve other bytes. Content behind a CID
+// never changes, so responses may be cached by the client for as long as it likes. Files below
+// a directory CID are named with the path query parameter.
+func GetContent(c *gin.Context) {
+	if initializers.IPFS == nil {
+		panic(customErrors.ErrIPFSDisabled)
+	}
+
+	walletAddress := c.GetHeader("Wallet-Address")
+	uri := contentURI(c)
+
+	metadata := map[string]string{"cid": c.Param("cid")}
+	if uri.Path != "" {
+		metadata["path"] = uri.Path
+	}
+	if byteRange := c.GetHeader("Range"); byteRange != "" {
+		metadata["range"] = byteRange
+	}
+	authorizeContent(c, walletAddress, uri, models.AuditAccessCheck, metadata)
+	id := resolveContent(c, uri)
+
+	etag := `"` + id.String() + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	file, err := initializers.IPFS.Open(c.Request.Context(), id)
+	if err != nil {
+		panic(contentError(err))
+	}
+
+	// Sniff the type from the first block, http.ServeContent would fail with a bare 500
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		panic(contentError(err))
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		panic(contentError(err))
+	}
+	contentType := http.DetectContentType(head[:n])
+	c.Header("Content-Type", contentType)
+	if mediaType, _, _ := strings.Cut(contentType, ";"); !inlineContentTypes[mediaType] {
+		c.Header("Content-Disposition", "attachment")
+		c.Header("Content-Security-Policy", "sandbox")
+	}
+
+	// Handles Range, If-Range and HEAD
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, file)
+}
+
+// ReleaseContentKey returns the data key of an encrypted document to a wallet allowed to read
+// it. By default the key is ECIES encrypted to the public key recovered from the wallet's
+// signature over the key release payload, so it is never exposed in transit or in logs.
+func ReleaseContentKey(c *gin.Context) {
+	if initializers.KMS == nil {
+		panic(customErrors.ErrEncryptionDisabled)
+	}
+
+	walletAddress := c.GetHeader("Wallet-Address")
+	rawCID := c.Param("cid")
+	uri := contentURI(c)
+
+	var input repository.KeyReleaseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Error("Binding error: ", err)
+		panic(customErrors.ErrInsufficientData)
+	}
+	if err := input.Validate(); err != nil {
+		panic(err)
+	}
+
+	// Recover the recipient key before releasing anything
+	var recipientKey *ecdsa.PublicKey
+	if input.Mode == repository.ReleaseECIES {
+		publicKey, err := utils.RecoverPublicKey(utils.KeyReleasePayload(rawCID), input.Signature)
+		if err != nil || crypto.PubkeyToAddress(*publicKey) != common.HexToAddress(walletAddress) {
+			panic(customErrors.ErrInvalidSignature)
+		}
+		recipientKey = publicKey
+	}
+
+	metadata := map[string]string{"cid": rawCID, "mode": string(input.Mode)}
+	if uri.Path != "" {
+		metadata["path"] = uri.Path
+	}
+	authorizeContent(c, walletAddress, uri, models.AuditKeyRelease, metadata)
+	// Keys are stored under the CIDv1 of the document itself, not of a directory above it
+	canonical := ipfsuri.URI{CID: resolveContent(c, uri)}.Canonical().CID.String()
+
+	documentKey, dataKey, err := envelope.ReleaseKey(c.Request.Context(), initializers.DB, initializers.KMS, canonical)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		panic(customErrors.ErrDocumentKeyNotFound)
+	}
+	if err != nil {
+		log.Error("Failed to unwrap document key: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	key := dataKey
+	if recipientKey != nil {
+		key, err = envelope.WrapForRecipient(dataKey, recipientKey)
+		if err != nil {
+			log.Error("Failed to wrap document key: ", err)
+			panic(customErrors.ErrInternalServer)
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Document key released successfully",
+		"cid":       rawCID,
+		"algorithm": documentKey.Algorithm,
+		"mode":      input.Mode,
+		"key":       base64.StdEncoding.EncodeToString(key),
+	})
+}
+
+// contentURI returns the content named in the URL: the root CID and, for transcripts stored as
+// ipfs://<cid>/<path>, the path query parameter.
+func contentURI(c *gin.Context) ipfsuri.URI {
+	raw := c.Param("cid")
+	if _, err := cid.Decode(raw); err != nil {
+		panic(customErrors.ErrInvalidCID)
+	}
+	if subpath := c.Query("path"); subpath != "" {
+		raw += "/" + subpath
+	}
+	uri, err := ipfsuri.Parse(raw)
+	if err != nil {
+		panic(customErrors.ErrInvalidCID)
+	}
+	return uri
+}
+
+// resolveContent returns the CID of the file the URI points at.
+func resolveContent(c *gin.Context, uri ipfsuri.URI) cid.Cid {
+	if uri.Path == "" {
+		return uri.CID
+	}
+	if initializers.IPFS == nil {
+		panic(customErrors.ErrIPFSDisabled)
+	}
+	id, err := initializers.IPFS.Resolve(c.Request.Context(), uri.CID, uri.Path)
+	if errors.Is(err, ipfs.ErrNoSuchLink) || errors.Is(err, ipfs.ErrNotADirectory) {
+		panic(customErrors.ErrContentNotFound)
+	} else if err != nil {
+		panic(contentError(err))
+	}
+	return id
+}
+
+// authorizeContent records the access decision for the content at the URI and aborts unless the
+// wallet may read it.
+func authorizeContent(c *gin.Context, walletAddress string, uri ipfsuri.URI, action models.AuditAction, metadata map[string]string) {
+	transcript, hasAccess, err := utils.CheckAccess(initializers.DB, walletAddress, uri)
+	if err != nil {
+		log.Error("Failed to check access: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	if transcript.TranscriptID == "" {
+		panic(customErrors.ErrContentNotFound)
+	}
+
+	outcome := models.AuditDenied
+	if hasAccess {
+		outcome = models.AuditAllowed
+	}
+	if err := audit.Record(initializers.DB, utils.AuditClient(c), action, walletAddress,
+		transcript.OwnerWallet, transcript.TranscriptID, outcome, metadata); err != nil {
+		log.Error("Failed to record access check: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	if !hasAccess {
+		panic(customErrors.ErrAccessDenied)
+	}
+}
+
+func contentError(err error) *customErrors.ApiError {
+	switch {
+	case errors.Is(err, ipfs.ErrNotFound), errors.Is(err, ipfs.ErrNotAFile):
+		return customErrors.ErrContentNotFound
+	case errors.Is(err, ipfs.ErrHashMismatch):
+		log.Error("IPFS returned corrupted content: ", err)
+		return customErrors.ErrContentIntegrity
+	}
+	log.Error("Failed to fetch content from IPFS: ", err)
+	return customErrors.ErrIPFSUnavailable
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"api/internal/chain"
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"api/internal/models"
+	"api/internal/repository"
+	"api/internal/statuslist"
+	"api/internal/vc"
+	"api/pkg/constants"
+	"api/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetCredentialTypedData returns the credential as a W3C Verifiable Credential together with the
+// EIP-712 typed data its issuer signs with eth_signTypedData_v4 to attach a proof. Only the
+// wallet that issued the NFT may sign it.
+func GetCredentialTypedData(c *gin.Context) {
+	tokenID := tokenIDParam(c)
+	credential, issuer := unsignedCredential(c, tokenID)
+	if !strings.EqualFold(c.GetHeader("Wallet-Address"), issuer.Hex()) {
+		panic(customErrors.ErrAccessDenied)
+	}
+
+	typedData, err := vc.TypedData(credential, initializers.ChainID)
+	if err != nil {
+		log.Error("Failed to build typed data: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.JSON(http.StatusOK, gin.H{"credential": credential, "typed_data": typedData})
+}
+
+// AttachCredentialProof takes the issuer's EIP-712 signature over the typed data and stores the
+// signed Verifiable Credential. The credential is built again from the chain, so the signature
+// only verifies when nothing changed since the typed data was fetched. The issuer must still be
+// an institution.
+func AttachCredentialProof(c *gin.Context) {
+	tokenID := tokenIDParam(c)
+	var input repository.CredentialProofInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Error("Binding error: ", err)
+		panic(customErrors.ErrInsufficientData)
+	}
+
+	credential, issuer := unsignedCredential(c, tokenID)
+	if !strings.EqualFold(c.GetHeader("Wallet-Address"), issuer.Hex()) {
+		panic(customErrors.ErrAccessDenied)
+	}
+	institution, err := initializers.NFTCMS.HasRole(c.Request.Context(), chain.InstitutionRole, issuer)
+	if err != nil {
+		log.Error("Failed to read issuer role: ", err)
+		panic(customErrors.ErrVerificationFailed)
+	}
+	if !institution {
+		panic(customErrors.ErrAccessDenied)
+	}
+
+	now := time.Now()
+	signed, err := vc.Attach(credential, initializers.ChainID, issuer, input.Signature, now)
+	if errors.Is(err, vc.ErrWrongSigner) {
+		panic(customErrors.ErrInvalidSignature)
+	} else if err != nil {
+		log.Error("Failed to attach credential proof: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	document, err := json.Marshal(signed)
+	if err != nil {
+		log.Error("Failed to encode verifiable credential: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	record := models.VerifiableCredential{
+		TokenID:  tokenID.String(),
+		Issuer:   issuer.Hex(),
+		Document: string(document),
+		SignedAt: now,
+	}
+	if err := initializers.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"issuer", "document", "signed_at"}),
+	}).Create(&record).Error; err != nil {
+		log.Error("Failed to save verifiable credential: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.Data(http.StatusCreated, "application/vc+ld+json", document)
+}
+
+// GetVerifiableCredential returns the signed Verifiable Credential to its issuer, the wallet
+// holding the credential and the recipients it was shared with. Revocation is not part of the
+// signed document; verifiers check the status list its credentialStatus points at.
+func GetVerifiableCredential(c *gin.Context) {
+	tokenID := tokenIDParam(c)
+	walletAddress := c.GetHeader("Wallet-Address")
+
+	var record models.VerifiableCredential
+	err := initializers.DB.Take(&record, "token_id = ?", tokenID.String()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		panic(customErrors.ErrCredentialNotSigned)
+	} else if err != nil {
+		log.Error("Failed to fetch verifiable credential: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	allowed := strings.EqualFold(walletAddress, record.Issuer)
+	if !allowed {
+		audience, err := utils.CredentialAudience(initializers.DB, record.TokenID)
+		if err != nil {
+			log.Error("Failed to fetch credential audience: ", err)
+			panic(customErrors.ErrInternalServer)
+		}
+		for _, wallet := range audience {
+			allowed = allowed || strings.EqualFold(walletAddress, wallet)
+		}
+	}
+	if !allowed {
+		panic(customErrors.ErrAccessDenied)
+	}
+	c.Data(http.StatusOK, "application/vc+ld+json", []byte(record.Document))
+}
+
+// GetStatusList serves a Bitstring Status List credential of revocations, as signed the last time
+// a credential in it was revoked. It is a JWS whose kid names its key in the receipt key set at
+// /v1/receipts/keys, which is linked from the Link header.
+func GetStatusList(c *gin.Context) {
+	listID, err := strconv.ParseUint(c.Param("list_id"), 10, 32)
+	if err != nil {
+		panic(customErrors.ErrStatusListNotFound)
+	}
+	list, err := initializers.StatusLists.Get(c.Request.Context(), uint(listID))
+	if errors.Is(err, statuslist.ErrNotPublished) {
+		panic(customErrors.ErrStatusListNotFound)
+	} else if err != nil {
+		log.Error("Failed to fetch status list: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", constants.StatusListCacheTTL))
+	c.Header("Last-Modified", list.PublishedAt.UTC().Format(http.TimeFormat))
+	c.Header("Link", fmt.Sprintf(`<%s>; rel="jwks"`, initializers.StatusLists.KeysURL()))
+	c.Data(http.StatusOK, "application/vc+jwt", []byte(list.Document))
+}
+
+// unsignedCredential builds the Verifiable Credential of the token from the chain and its
+// indexed metadata, and returns it with the wallet that issued the NFT.
+func unsignedCredential(c *gin.Context, tokenID *big.Int) (vc.Credential, common.Address) {
+	if initializers.NFTCMS == nil || initializers.ChainID == nil {
+		panic(customErrors.ErrChainDisabled)
+	}
+	ctx := c.Request.Context()
+	onChain, err := initializers.NFTCMS.Credential(ctx, tokenID)
+	if err != nil {
+		log.Error("Failed to read credential: ", err)
+		panic(customErrors.ErrVerificationFailed)
+	}
+	if onChain.TokenID == nil || onChain.TokenID.Sign() == 0 {
+		panic(customErrors.ErrCredentialNotFound)
+	}
+	owner, err := initializers.NFTCMS.OwnerOf(ctx, tokenID)
+	if err != nil {
+		log.Error("Failed to read credential owner: ", err)
+		panic(customErrors.ErrVerificationFailed)
+	}
+
+	var metadata models.TranscriptMetadata
+	err = initializers.DB.Take(&metadata, "transcript_id = ?", tokenID.String()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		panic(customErrors.ErrCredentialMetadata)
+	} else if err != nil {
+		log.Error("Failed to fetch credential metadata: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	entry, err := initializers.StatusLists.Assign(ctx, tokenID.String(), onChain.Status == chain.CredentialRevoked)
+	if err != nil {
+		log.Error("Failed to assign status list entry: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	credential, err := vc.Build(vc.Source{
+		ChainID:     initializers.ChainID,
+		Contract:    initializers.NFTCMS.Address,
+		TokenID:     tokenID,
+		Issuer:      onChain.Signer,
+		Owner:       owner,
+		Metadata:    metadata,
+		StatusList:  initializers.StatusLists.URL(entry.ListID),
+		StatusIndex: entry.StatusIndex,
+	})
+	if errors.Is(err, vc.ErrMetadataInvalid) {
+		panic(customErrors.ErrCredentialMetadata)
+	} else if err != nil {
+		log.Error("Failed to build verifiable credential: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	return credential, onChain.Signer
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"api/internal/models"
+	"api/internal/repository"
+	"api/pkg/utils"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// Actions a wallet can take on a request in its current state
+const (
+	ActionAccept          = "accept"
+	ActionReject          = "reject"
+	ActionViewTranscripts = "view_transcripts"
+)
+
+// GetInbox lists requests sent to the caller as the student.
+func GetInbox(c *gin.Context) {
+	listMailbox(c, repository.StudentRole)
+}
+
+// GetOutbox lists requests made by the caller as the recipient.
+func GetOutbox(c *gin.Context) {
+	listMailbox(c, repository.RecipientRole)
+}
+
+func listMailbox(c *gin.Context, role repository.RoleEnum) {
+	walletAddress := c.GetHeader("Wallet-Address")
+
+	var input repository.ListRequestsInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		log.Error("Binding error: ", err)
+		panic(customErrors.ErrInsufficientData)
+	}
+	input.Role = role
+
+	if err := input.Validate(); err != nil {
+		log.Error("Invalid list filters: ", err)
+		panic(err)
+	}
+
+	requests, total, nextCursor, err := utils.ListRequests(initializers.DB, walletAddress, input)
+	if err != nil {
+		var apiErr *customErrors.ApiError
+		if errors.As(err, &apiErr) {
+			panic(apiErr)
+		}
+		log.Error("Failed to get requests: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	requestIDs := make([]string, 0, len(requests))
+	for _, request := range requests {
+		requestIDs = append(requestIDs, request.ID)
+	}
+	views, err := utils.GetRequestViews(initializers.DB, walletAddress, requestIDs)
+	if err != nil {
+		log.Error("Failed to get request views: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	unreadCount, err := utils.CountUnreadRequests(initializers.DB, walletAddress, input)
+	if err != nil {
+		log.Error("Failed to count unread requests: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	now := time.Now()
+	requestList := make([]gin.H, 0, len(requests))
+	for _, request := range requests {
+		counterparty := request.RecipientWallet
+		if role == repository.RecipientRole {
+			counterparty = request.StudentWallet
+		}
+
+		lastViewed, viewed := views[request.ID]
+		unread := !viewed || request.UpdatedAt.After(lastViewed)
+
+		entry := requestSummary(request)
+		entry["counterparty"] = counterpartyInfo(counterparty)
+		entry["unread"] = unread
+		entry["expired"] = request.Status == models.Expired || (request.Status == models.Pending && now.After(request.ExpiryTimestamp))
+		entry["actions"] = availableActions(request, role, now)
+		requestList = append(requestList, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Requests retrieved successfully",
+		"requests":     requestList,
+		"total":        total,
+		"unread_count": unreadCount,
+		"next_cursor":  nextCursor,
+	})
+}
+
+// counterpartyInfo returns display information for the other wallet on a request.
+func counterpartyInfo(wallet string) gin.H {
+	info := gin.H{"wallet": wallet, "display_name": wallet}
+	if common.IsHexAddress(wallet) {
+		checksum := common.HexToAddress(wallet).Hex()
+		info["wallet"] = checksum
+		info["display_name"] = checksum[:6] + "…" + checksum[len(checksum)-4:]
+	}
+	return info
+}
+
+// availableActions lists what the wallet in the given role may do with the request right now.
+func availableActions(request models.Request, role repository.RoleEnum, now time.Time) []string {
+	actions := []string{}
+	switch request.Status {
+	case models.Pending:
+		if role == repository.StudentRole && now.Before(request.ExpiryTimestamp) {
+			actions = append(actions, ActionAccept, ActionReject)
+		}
+	case models.Approved:
+		if role == repository.RecipientRole {
+			actions = append(actions, ActionViewTranscripts)
+		}
+	}
+	return actions
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"api/internal/models"
+	"api/internal/repository"
+	"api/pkg/utils"
+	"errors"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Discussion thread handlers, available to the student and the recipient of a request
+
+func SendMessage(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	request := participantRequest(c, walletAddress)
+
+	var input repository.SendMessageInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Error("Binding error: ", err)
+		panic(customErrors.ErrInsufficientData)
+	}
+
+	// The message must be signed by the sender's wallet
+	valid, err := utils.VerifySignature(utils.MessageSigningPayload(request.ID, input.Body), input.Signature, walletAddress)
+	if err != nil {
+		var apiErr *customErrors.ApiError
+		if errors.As(err, &apiErr) {
+			panic(apiErr)
+		}
+		panic(customErrors.ErrInvalidMsgSignature)
+	}
+	if !valid {
+		panic(customErrors.ErrInvalidMsgSignature)
+	}
+
+	message, err := utils.CreateMessage(initializers.DB, request, walletAddress, input.Body, input.Signature)
+	if err != nil {
+		log.Error("Failed to store message: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Message sent successfully", "request_message": messageResponse(message)})
+}
+
+func GetMessages(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	request := participantRequest(c, walletAddress)
+
+	// Opening the thread acknowledges everything the other party sent
+	if _, err := utils.MarkMessagesRead(initializers.DB, request, walletAddress); err != nil {
+		log.Error("Failed to mark messages as read: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	messages, err := utils.ListMessages(initializers.DB, request.ID)
+	if err != nil {
+		log.Error("Failed to get messages: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	messageList := make([]gin.H, 0, len(messages))
+	for _, message := range messages {
+		messageList = append(messageList, messageResponse(message))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Messages retrieved successfully", "messages": messageList})
+}
+
+func GetRequestHistory(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	request := participantRequest(c, walletAddress)
+
+	events, err := utils.ListRequestEvents(initializers.DB, request.ID)
+	if err != nil {
+		log.Error("Failed to get request history: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	history := make([]gin.H, 0, len(events))
+	for _, event := range events {
+		history = append(history, gin.H{
+			"event_id":     event.ID,
+			"type":         event.Type,
+			"actor_wallet": event.ActorWallet,
+			"data":         event.Data,
+			"created_at":   event.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Request history retrieved successfully", "history": history})
+}
+
+// participantRequest loads the request named in the URL, which the wallet must be a party to.
+func participantRequest(c *gin.Context, walletAddress string) models.Request {
+	request, err := utils.GetRequestForParticipant(initializers.DB, c.Param("request_id"), walletAddress)
+	if err != nil {
+		log.Error("Request not found or DB error: ", err)
+		panic(customErrors.ErrRequestNotFound)
+	}
+	return request
+}
+
+func messageResponse(message models.RequestMessage) gin.H {
+	return gin.H{
+		"message_id":    message.ID,
+		"request_id":    message.RequestID,
+		"sender_wallet": message.SenderWallet,
+		"body":          message.Body,
+		"signature":     message.Signature,
+		"created_at":    message.CreatedAt,
+		"read_at":       message.ReadAt,
+	}
+}
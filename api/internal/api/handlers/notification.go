@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"api/internal/models"
+	"api/internal/notifications"
+	"api/internal/repository"
+	"errors"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// Email notification handlers
+
+func GetEmail(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	record, err := notifications.GetEmail(initializers.DB, walletAddress)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		panic(customErrors.ErrEmailNotFound)
+	} else if err != nil {
+		log.Error("Failed to get email: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email retrieved successfully", "email": emailResponse(record)})
+}
+
+func SetEmail(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	var input repository.EmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Error("Binding error: ", err)
+		panic(customErrors.ErrInsufficientData)
+	}
+
+	record, err := notifications.SetEmail(initializers.DB, walletAddress, input.Email)
+	if err != nil {
+		log.Error("Failed to set email: ", err)
+		panic(customErrors.ErrFailedToSendEmail)
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent", "email": emailResponse(record)})
+}
+
+func DeleteEmail(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	if err := notifications.DeleteEmail(initializers.DB, walletAddress); err != nil {
+		log.Error("Failed to delete email: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email removed successfully"})
+}
+
+func UpdateNotificationPreferences(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	var input repository.NotificationPreferencesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Error("Binding error: ", err)
+		panic(customErrors.ErrInsufficientData)
+	}
+	if err := input.Validate(); err != nil {
+		panic(err)
+	}
+
+	record, err := notifications.UpdatePreferences(initializers.DB, walletAddress, input.Preferences)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		panic(customErrors.ErrEmailNotFound)
+	} else if err != nil {
+		log.Error("Failed to update notification preferences: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification preferences updated successfully", "email": emailResponse(record)})
+}
+
+// VerifyEmail is opened from the link in the verification email, so it needs no session.
+func VerifyEmail(c *gin.Context) {
+	record, err := notifications.VerifyEmail(initializers.DB, c.Query("token"))
+	if errors.Is(err, notifications.ErrInvalidVerificationToken) {
+		panic(customErrors.ErrInvalidVerifyToken)
+	} else if err != nil {
+		log.Error("Failed to verify email: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully", "email": emailResponse(record)})
+}
+
+func emailResponse(record models.WalletEmail) gin.H {
+	preferences := make(map[string]bool, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		enabled, ok := record.Preferences[notificationType]
+		preferences[notificationType] = !ok || enabled
+	}
+	return gin.H{
+		"email":       record.Email,
+		"verified":    record.VerifiedAt != nil,
+		"verified_at": record.VerifiedAt,
+		"preferences": preferences,
+	}
+}
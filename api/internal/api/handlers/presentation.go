@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"api/internal/models"
+	"api/internal/repository"
+	"api/internal/vc"
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/http"
+	"time"
+)
+
+// GetPresentationTypedData returns the Verifiable Presentation of an approved request and the
+// EIP-712 typed data the student signs to attach a proof. It bundles the credentials shared with
+// the request, bound to the recipient and the request ID.
+func GetPresentationTypedData(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	request := participantRequest(c, walletAddress)
+	if request.StudentWallet != walletAddress {
+		panic(customErrors.ErrAccessDenied)
+	}
+
+	presentation, typedData, err := presentationTypedData(request)
+	if err != nil {
+		panic(err)
+	}
+	c.JSON(http.StatusOK, gin.H{"presentation": presentation, "typed_data": typedData})
+}
+
+// SignPresentation takes the student's EIP-712 signature over the typed data and stores the
+// signed presentation for the recipient. Signing again replaces it.
+func SignPresentation(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	var input repository.PresentationProofInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Error("Binding error: ", err)
+		panic(customErrors.ErrInsufficientData)
+	}
+	request := participantRequest(c, walletAddress)
+	if request.StudentWallet != walletAddress {
+		panic(customErrors.ErrAccessDenied)
+	}
+
+	source, apiErr := presentationSource(request)
+	if apiErr != nil {
+		panic(apiErr)
+	}
+	now := time.Now()
+	presentation, err := vc.AttachPresentationProof(source, input.Signature, now)
+	if errors.Is(err, vc.ErrWrongSigner) {
+		panic(customErrors.ErrInvalidSignature)
+	} else if err != nil {
+		log.Error("Failed to attach presentation proof: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	document, err := json.Marshal(presentation)
+	if err != nil {
+		log.Error("Failed to encode presentation: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	record := models.Presentation{
+		RequestID: request.ID,
+		Holder:    request.StudentWallet,
+		Document:  string(document),
+		SignedAt:  now,
+	}
+	if err := initializers.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "request_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"holder", "document", "signed_at"}),
+	}).Create(&record).Error; err != nil {
+		log.Error("Failed to save presentation: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.Data(http.StatusCreated, "application/vp+ld+json", document)
+}
+
+// GetPresentation returns the signed presentation of the request to either party.
+func GetPresentation(c *gin.Context) {
+	request := participantRequest(c, c.GetHeader("Wallet-Address"))
+
+	var record models.Presentation
+	err := initializers.DB.Take(&record, "request_id = ?", request.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		panic(customErrors.ErrPresentationNotFound)
+	} else if err != nil {
+		log.Error("Failed to fetch presentation: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.Header("Content-Disposition", `attachment; filename="presentation-`+request.ID+`.json"`)
+	c.Data(http.StatusOK, "application/vp+ld+json", []byte(record.Document))
+}
+
+// presentationTypedData builds the unsigned presentation of the request and its typed data.
+func presentationTypedData(request models.Request) (vc.Presentation, apitypes.TypedData, *customErrors.ApiError) {
+	source, apiErr := presentationSource(request)
+	if apiErr != nil {
+		return vc.Presentation{}, apitypes.TypedData{}, apiErr
+	}
+	presentation, typedData, err := vc.BuildPresentation(source)
+	if err != nil {
+		log.Error("Failed to build presentation: ", err)
+		return vc.Presentation{}, apitypes.TypedData{}, customErrors.ErrInternalServer
+	}
+	return presentation, typedData, nil
+}
+
+// presentationSource collects what the presentation of an approved request is built from. Every
+// credential shared with the request must have a signed Verifiable Credential.
+func presentationSource(request models.Request) (vc.PresentationRequest, *customErrors.ApiError) {
+	if initializers.ChainID == nil {
+		return vc.PresentationRequest{}, customErrors.ErrChainDisabled
+	}
+	if request.Status != models.Approved {
+		return vc.PresentationRequest{}, customErrors.ErrRequestNotApproved
+	}
+	if !common.IsHexAddress(request.StudentWallet) || !common.IsHexAddress(request.RecipientWallet) {
+		return vc.PresentationRequest{}, customErrors.ErrInvalidData
+	}
+
+	var transcriptIDs []string
+	if err := initializers.DB.Model(&models.RequestTranscript{}).Where("request_id = ?", request.ID).
+		Order("transcript_id").Pluck("transcript_id", &transcriptIDs).Error; err != nil {
+		log.Error("Failed to fetch transcripts: ", err)
+		return vc.PresentationRequest{}, customErrors.ErrInternalServer
+	}
+	var credentials []models.VerifiableCredential
+	if err := initializers.DB.Where("token_id IN ?", transcriptIDs).Order("token_id").Find(&credentials).Error; err != nil {
+		log.Error("Failed to fetch verifiable credentials: ", err)
+		return vc.PresentationRequest{}, customErrors.ErrInternalServer
+	}
+	if len(credentials) != len(transcriptIDs) {
+		return vc.PresentationRequest{}, customErrors.ErrPresentationPending
+	}
+
+	documents := make([]string, len(credentials))
+	for i, credential := range credentials {
+		documents[i] = credential.Document
+	}
+	return vc.PresentationRequest{
+		ChainID:     initializers.ChainID,
+		RequestID:   request.ID,
+		Holder:      common.HexToAddress(request.StudentWallet),
+		Audience:    common.HexToAddress(request.RecipientWallet),
+		Credentials: documents,
+	}, nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"api/internal/models"
+	"api/internal/push"
+	"api/internal/repository"
+	"errors"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Web Push handlers, for browsers subscribing to request and credential alerts
+
+func GetVAPIDPublicKey(c *gin.Context) {
+	if initializers.VAPIDKey == nil {
+		panic(customErrors.ErrPushDisabled)
+	}
+	c.JSON(http.StatusOK, gin.H{"public_key": initializers.VAPIDKey.PublicKey()})
+}
+
+func CreatePushSubscription(c *gin.Context) {
+	if initializers.VAPIDKey == nil {
+		panic(customErrors.ErrPushDisabled)
+	}
+
+	walletAddress := c.GetHeader("Wallet-Address")
+	var input repository.PushSubscriptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Error("Binding error: ", err)
+		panic(customErrors.ErrInvalidSubscription)
+	}
+
+	if err := input.Validate(); err != nil {
+		log.Error("Invalid push subscription: ", err)
+		panic(err)
+	}
+
+	subscription, err := push.Subscribe(initializers.DB, walletAddress, input.Endpoint, input.Keys.P256dh, input.Keys.Auth, c.Request.UserAgent())
+	if errors.Is(err, push.ErrEndpointTaken) {
+		panic(customErrors.ErrSubscriptionConflict)
+	} else if err != nil {
+		log.Error("Failed to save push subscription: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Push subscription saved successfully", "subscription": pushSubscriptionResponse(subscription)})
+}
+
+func GetPushSubscriptions(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	subscriptions, err := push.ListSubscriptions(initializers.DB, walletAddress)
+	if err != nil {
+		log.Error("Failed to get push subscriptions: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	subscriptionList := make([]gin.H, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionList = append(subscriptionList, pushSubscriptionResponse(subscription))
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Push subscriptions retrieved successfully", "subscriptions": subscriptionList})
+}
+
+func DeletePushSubscription(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	deleted, err := push.Unsubscribe(initializers.DB, walletAddress, c.Param("subscription_id"))
+	if err != nil {
+		log.Error("Failed to delete push subscription: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	if !deleted {
+		panic(customErrors.ErrSubscriptionNotFound)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Push subscription deleted successfully"})
+}
+
+// pushSubscriptionResponse leaves out the browser keys, which are only needed for encryption.
+func pushSubscriptionResponse(subscription models.PushSubscription) gin.H {
+	return gin.H{
+		"subscription_id": subscription.ID,
+		"endpoint":        subscription.Endpoint,
+		"user_agent":      subscription.UserAgent,
+		"created_at":      subscription.CreatedAt,
+		"last_push_at":    subscription.LastPushAt,
+	}
+}
@@ -6,9 +6,8 @@ import (
 	"api/internal/models"
 	"api/internal/repository"
 	"api/pkg/utils"
+	"encoding/base64"
 	"errors"
-	"fmt"
-	"gorm.io/gorm"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/gin-gonic/gin"
@@ -45,6 +44,7 @@ func CreateRequest(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", utils.RequestETag(request.Version, 0))
 	c.JSON(http.StatusCreated, gin.H{"message": "Request created successfully", "request": request})
 }
 
@@ -72,164 +72,95 @@ func RespondRequest(c *gin.Context) {
 		return
 	}
 
-	// Find the request belonging to this student
-	var request models.Request
-	if err := initializers.DB.First(&request, "id = ? AND student_wallet = ?", input.RequestID, walletAddress).Error; err != nil {
-		log.Error("Request not found: ", err)
-		panic(customErrors.ErrRequestNotFound)
-		return
-	}
-
-	// Check that the request is in a pending state
-	if request.Status != models.Pending {
-		log.Error("Request is not in a pending state")
-		panic(customErrors.ErrRequestNotPending)
-		return
+	// Optional optimistic concurrency check against the ETag the client last saw
+	expectedVersion, err := utils.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		panic(err)
 	}
 
-	// Process the response
-	if input.Response == repository.Accept {
-		// Set status to Approved
-		request.Status = models.Approved
-		request.UpdatedAt = time.Now()
-
-		// Insert transcript records into the RequestTranscript table.
-		// Optional: Clear existing transcript records if needed:
-		if err := initializers.DB.Where("request_id = ?", request.ID).Delete(&models.RequestTranscript{}).Error; err != nil {
-			log.Error("Failed to delete existing transcripts: ", err)
-			panic(customErrors.ErrFailedToSaveRequest)
-			return
-		}
-
-		// For each transcript provided in the input, create a RequestTranscript record.
-
-		// NOTE: Check in blockchain if all the transcripts in the list are owned by student_wallet
-
-		for _, transcriptID := range input.TranscriptList {
-			var transcript models.Transcript
-			if err := initializers.DB.First(&transcript, "transcript_id = ? AND owner_wallet = ?", transcriptID.String(), walletAddress).Error; err != nil {
-				if errors.Is(err, gorm.ErrRecordNotFound) {
-					log.Error("Transcript not found or not owned by the wallet address: ", transcriptID.String())
-					panic(customErrors.ErrUnauthorizedTranscript)
-				}
-				log.Error("Database error while checking transcript ownership: ", err)
-				panic(customErrors.ErrInternalServer)
-			}
-			// Set status to Approved
-			request.Status = models.Approved
-			request.UpdatedAt = time.Now()
-
-			// Clear existing transcript records if needed
-			if err := initializers.DB.Where("request_id = ?", request.ID).Delete(&models.RequestTranscript{}).Error; err != nil {
-				log.Error("Failed to delete existing transcripts: ", err)
-				panic(customErrors.ErrFailedToSaveRequest)
-			}
-
-			// For each transcript provided in the input, create a RequestTranscript record
-			for _, transcript := range input.TranscriptList {
-				rt := models.RequestTranscript{
-					RequestID:    request.ID,
-					TranscriptID: transcript.String(),
-				}
-				if err := initializers.DB.Create(&rt).Error; err != nil {
-					log.Error("Failed to store transcript record: ", err)
-					panic(customErrors.ErrFailedToSaveRequest)
-				}
-			}
-
-			// Update the request record in the database
-			if err := initializers.DB.Model(&request).Updates(map[string]interface{}{
-				"status":     request.Status,
-				"updated_at": request.UpdatedAt,
-			}).Error; err != nil {
-				log.Error("Failed to update request: ", err)
-				panic(customErrors.ErrFailedToSaveRequest)
-			}
-			//rt := models.RequestTranscript{
-			//	RequestID:    request.ID,
-			//	TranscriptID: transcript.String(), // Convert uint256.Int to string if needed
-			//}
-			//if err := initializers.DB.Create(&rt).Error; err != nil {
-			//	log.Error("Failed to store transcript record: ", err)
-			//	panic(customErrors.ErrFailedToSaveRequest)
-			//	return
-			//}
-		}
-
-		// Update the request record in the database
-		if err := initializers.DB.Model(&request).Updates(map[string]interface{}{
-			"status":     request.Status,
-			"updated_at": request.UpdatedAt,
-		}).Error; err != nil {
-			log.Error("Failed to update request: ", err)
-			panic(customErrors.ErrFailedToSaveRequest)
-			return
+	request, err := utils.RespondToRequest(initializers.DB, walletAddress, input, expectedVersion, utils.AuditClient(c))
+	if err != nil {
+		var apiErr *customErrors.ApiError
+		if errors.As(err, &apiErr) {
+			log.Error("Failed to respond to request: ", err)
+			panic(apiErr)
 		}
+		log.Error("Failed to save response: ", err)
+		panic(customErrors.ErrFailedToSaveRequest)
+	}
 
-	} else if input.Response == repository.Reject {
-		// Set status to Denied and update the reason if provided
-		request.Status = models.Denied
-		if input.Reason != "" {
-			request.Reason = input.Reason
-		}
-		request.UpdatedAt = time.Now()
-		if err := initializers.DB.Save(&request).Error; err != nil {
-			log.Error("Failed to save request: ", err)
-			panic(customErrors.ErrFailedToSaveRequest)
-			return
+	response := gin.H{
+		"message": "Request responded successfully",
+		"status":  request.Status,
+		"version": request.Version,
+	}
+	// Hand the student the presentation to sign for the recipient right away
+	if request.Status == models.Approved {
+		presentation, typedData, err := presentationTypedData(request)
+		if err != nil {
+			response["presentation_error"] = err.Message
+		} else {
+			response["presentation"] = gin.H{"presentation": presentation, "typed_data": typedData}
 		}
-	} else {
-		log.Error("Invalid response value")
-		panic(customErrors.ErrInvalidData)
-		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Request responded successfully"})
+	c.Header("ETag", utils.RequestETag(request.Version, 0))
+	c.JSON(http.StatusOK, response)
 }
 
+// GetRequest returns a request to its student or recipient. The optional role query parameter
+// ("student" or "recipient") picks the side to read it as; without it the side is taken from
+// which party the wallet is. Nothing is read from the body, so browsers can fetch it.
 func GetRequest(c *gin.Context) {
 	// Get wallet address from headers
 	walletAddress := c.GetHeader("Wallet-Address")
 	requestID := c.Param("request_id")
 
-	// Parse JSON input to get wallet type (student_wallet or recipient_wallet)
-	var input repository.GetWalletType
-	if err := c.ShouldBindJSON(&input); err != nil {
-		log.Error("Invalid wallet type binding: ", err)
-		panic(customErrors.ErrInvalidWalletType)
-		return
+	role := repository.RoleEnum(c.Query("role"))
+	query := initializers.DB.Where("id = ?", requestID)
+	switch role {
+	case repository.StudentRole, repository.RecipientRole:
+		query = query.Where(role.Column()+" = ?", walletAddress)
+	case "":
+		query = query.Where("(student_wallet = ? OR recipient_wallet = ?)", walletAddress, walletAddress)
+	default:
+		panic(customErrors.ErrInvalidRole)
 	}
 
-	// Optional: validate wallet type if needed
-	if err := input.Validate(); err != nil {
-		log.Error("Wallet type validation failed: ", err)
-		panic(err)
+	var request models.Request
+	if err := query.First(&request).Error; err != nil {
+		log.Error("Request not found or DB error: ", err)
+		panic(customErrors.ErrRequestNotFound)
 		return
 	}
+	if role == "" {
+		role = repository.RecipientRole
+		if request.StudentWallet == walletAddress {
+			role = repository.StudentRole
+		}
+	}
 
-	// Ensure walletType is either "student_wallet" or "recipient_wallet"
-	var request models.Request
-	var err error
-
-	switch input.WalletType {
-	case repository.StudentWallet:
-		err = initializers.DB.
-			Where("id = ? AND student_wallet = ?", requestID, walletAddress).
-			First(&request).Error
-	case repository.RecipientWallet:
-		err = initializers.DB.
-			Where("id = ? AND recipient_wallet = ?", requestID, walletAddress).
-			First(&request).Error
-	default:
-		log.Error("Invalid wallet type used in query")
-		panic(customErrors.ErrInvalidWalletType)
-		return
+	var approvalTimestamp *models.Timestamp
+	if request.Status == models.Approved {
+		var err error
+		approvalTimestamp, err = utils.ApprovalTimestamp(initializers.DB, request.ID)
+		if err != nil {
+			log.Error("Failed to fetch approval timestamp: ", err)
+			panic(customErrors.ErrInternalServer)
+		}
 	}
 
-	if err != nil {
-		log.Error("Request not found or DB error: ", err)
-		panic(customErrors.ErrRequestNotFound)
-		return
+	// Let clients revalidate cached copies of the request
+	var timestampID uint
+	if approvalTimestamp != nil {
+		timestampID = approvalTimestamp.ID
+	}
+	etag := utils.RequestETag(request.Version, timestampID)
+	c.Header("ETag", etag)
+
+	// Record the view so the request no longer shows as unread
+	if err := utils.MarkRequestViewed(initializers.DB, request.ID, walletAddress); err != nil {
+		log.Error("Failed to mark request as viewed: ", err)
 	}
 
 	// Build the base response
@@ -239,6 +170,7 @@ func GetRequest(c *gin.Context) {
 		"recipient_wallet": request.RecipientWallet,
 		"student_wallet":   request.StudentWallet,
 		"expiry_timestamp": request.ExpiryTimestamp,
+		"version":          request.Version,
 	}
 
 	// Add reason or transcripts depending on status
@@ -252,34 +184,120 @@ func GetRequest(c *gin.Context) {
 			panic(customErrors.ErrInternalServer)
 			return
 		}
+		// Recipients may ask for signed verification receipts for their records
+		withReceipts := role == repository.RecipientRole && c.Query("receipts") == "true"
 		var transcriptList []gin.H
 		for _, t := range transcripts {
-			transcriptList = append(transcriptList, gin.H{
+			entry := gin.H{
 				"request_id":    t.RequestID,
 				"transcript_id": t.TranscriptID,
-			})
+			}
+			if withReceipts {
+				receipt, timestamp, err := verificationReceipt(c, request, t.TranscriptID)
+				if err != nil {
+					entry["receipt_error"] = err.Message
+				} else {
+					entry["receipt"] = receipt
+				}
+				if timestamp != nil {
+					entry["receipt_timestamp"] = timestampResponse(*timestamp)
+				}
+			}
+			transcriptList = append(transcriptList, entry)
 		}
 		response["transcripts"] = transcriptList
 
+		if approvalTimestamp != nil {
+			response["approval_timestamp"] = timestampResponse(*approvalTimestamp)
+		}
+		if withReceipts {
+			// A new receipt is issued as soon as the verification outcome changes, never serve a
+			// cached copy
+			c.Header("Cache-Control", "no-store")
+			c.JSON(http.StatusOK, response)
+			return
+		}
+
 	case models.Denied:
 		response["reason"] = request.Reason
 	}
 
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
+// timestampResponse returns an RFC 3161 timestamp with the document it covers, so both can be
+// checked with standard tools such as openssl ts -verify.
+func timestampResponse(timestamp models.Timestamp) gin.H {
+	return gin.H{
+		"authority":     timestamp.Authority,
+		"gen_time":      timestamp.GenTime,
+		"serial_number": timestamp.SerialNumber,
+		"digest":        timestamp.Digest,
+		"document":      timestamp.Document,
+		"token":         base64.StdEncoding.EncodeToString(timestamp.Token),
+	}
+}
+
+// requestSummary builds the listing representation of a request.
+func requestSummary(request models.Request) gin.H {
+	return gin.H{
+		"request_id":       request.ID,
+		"status":           request.Status,
+		"recipient_wallet": request.RecipientWallet,
+		"student_wallet":   request.StudentWallet,
+		"created_at":       request.CreatedAt,
+		"updated_at":       request.UpdatedAt,
+		"expiry_timestamp": request.ExpiryTimestamp,
+		"version":          request.Version,
+	}
+}
+
 func GetRequests(c *gin.Context) {
 	walletAddress := c.GetHeader("Wallet-Address")
-	var input repository.GetWalletType
-	if err := c.ShouldBindJSON(&input); err != nil {
+
+	// Parse query string for role, filters, sort order and cursor
+	var input repository.ListRequestsInput
+	if err := c.ShouldBindQuery(&input); err != nil {
 		log.Error("Binding error: ", err)
 		panic(customErrors.ErrInsufficientData)
-		return
 	}
-	var requests []models.Request
-	if err := initializers.DB.Find(&requests, fmt.Sprintf("%v = ?", input.WalletType), walletAddress).Error; err != nil {
+
+	if err := input.Validate(); err != nil {
+		log.Error("Invalid list filters: ", err)
+		panic(err)
+	}
+
+	requests, total, nextCursor, err := utils.ListRequests(initializers.DB, walletAddress, input)
+	if err != nil {
+		var apiErr *customErrors.ApiError
+		if errors.As(err, &apiErr) {
+			panic(apiErr)
+		}
 		log.Error("Failed to get requests: ", err)
 		panic(customErrors.ErrInternalServer)
-		return
 	}
+
+	statusCounts, err := utils.CountRequestsByStatus(initializers.DB, walletAddress, input)
+	if err != nil {
+		log.Error("Failed to count requests: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	requestList := make([]gin.H, 0, len(requests))
+	for _, request := range requests {
+		requestList = append(requestList, requestSummary(request))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Requests retrieved successfully",
+		"requests":      requestList,
+		"total":         total,
+		"status_counts": statusCounts,
+		"next_cursor":   nextCursor,
+	})
 }
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"api/internal/realtime"
+	"api/pkg/constants"
+	"api/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const streamHeartbeat = 25 * time.Second
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     checkOrigin,
+}
+
+// checkOrigin accepts WebSocket handshakes from the configured client origins, or the API's own
+// origin when none are configured. Clients other than browsers send no Origin and are accepted.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if len(initializers.ClientOrigins) == 0 {
+		parsed, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(parsed.Host, r.Host)
+	}
+	for _, allowed := range initializers.ClientOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateStreamTicket returns a single-use ticket for opening an event stream from a browser, which
+// cannot send the session headers on EventSource or WebSocket requests.
+func CreateStreamTicket(c *gin.Context) {
+	ticket, err := utils.CreateStreamTicket(c.GetHeader("Wallet-Address"))
+	if err != nil {
+		log.Error("Failed to create stream ticket: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.JSON(http.StatusCreated, gin.H{"ticket": ticket, "expires_in": constants.StreamTicketTimeout * 60})
+}
+
+// subscribe opens the caller's event subscription, resuming after Last-Event-ID when given.
+// The subscription ends when ctx is cancelled.
+func subscribe(ctx context.Context, c *gin.Context) <-chan realtime.Event {
+	walletAddress := c.GetHeader("Wallet-Address")
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	events, err := realtime.Subscribe(ctx, walletAddress, lastEventID)
+	if errors.Is(err, realtime.ErrInvalidEventID) {
+		panic(customErrors.ErrInvalidLastEventID)
+	} else if err != nil {
+		log.Error("Failed to subscribe to events: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	return events
+}
+
+// StreamEvents pushes the caller's request and access changes as Server-Sent Events.
+func StreamEvents(c *gin.Context) {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	events := subscribe(ctx, c)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+			return err == nil
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
+
+// StreamEventsWebSocket pushes the same events as StreamEvents over a WebSocket, one JSON
+// message per event.
+func StreamEventsWebSocket(c *gin.Context) {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	events := subscribe(ctx, c)
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written the error response
+		log.Error("WebSocket upgrade failed: ", err)
+		return
+	}
+	defer conn.Close()
+
+	// Drain client frames so pongs and close messages are processed
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeat))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			message, err := json.Marshal(event)
+			if err != nil {
+				log.Error("Failed to encode event: ", err)
+				continue
+			}
+			conn.SetWriteDeadline(time.Now().Add(streamHeartbeat))
+			if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamHeartbeat)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"api/internal/audit"
 	"api/internal/customErrors"
 	"api/internal/initializers"
+	"api/internal/ipfsuri"
 	"api/internal/models"
 	"api/internal/repository"
 	"api/pkg/utils"
@@ -30,6 +32,9 @@ func AddTranscript(c *gin.Context) {
 		panic(customErrors.ErrInsufficientData)
 		return
 	}
+	if err := input.Validate(); err != nil {
+		panic(err)
+	}
 
 	transcript := models.Transcript{
 		TranscriptID:     input.TranscriptID,
@@ -63,22 +68,44 @@ func GetTranscripts(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Approved transcripts retrieved successfully", "transcripts": transcriptIDs})
 }
 
+// CheckAccess takes the IPFS URI as the last path segment, which only fits a bare CID, or in
+// the ipfs_uri query parameter, which fits any form ipfsuri.Parse accepts.
 func CheckAccess(c *gin.Context) {
 	// Get student's wallet address from header
 	walletAddress := c.GetHeader("Wallet-Address")
 	ipfsURI := c.Param("ipfs_uri")
+	if ipfsURI == "" {
+		ipfsURI = c.Query("ipfs_uri")
+	}
 	if ipfsURI == "" {
 		log.Error("IPFS URI is required")
 		panic(customErrors.ErrInsufficientData)
 		return
 	}
+	uri, err := ipfsuri.Parse(ipfsURI)
+	if err != nil {
+		panic(customErrors.ErrInvalidIPFSURI)
+	}
 	// Check if the student has access to the IPFS URI
-	hasAccess, err := utils.CheckAccess(initializers.DB, walletAddress, ipfsURI)
+	transcript, hasAccess, err := utils.CheckAccess(initializers.DB, walletAddress, uri)
 	if err != nil {
 		log.Error("Failed to check access: ", err)
 		panic(customErrors.ErrUnprocessableEntity)
 		return
 	}
+
+	// Owners are entitled to know who opened their transcripts, so no access without a trace
+	if transcript.TranscriptID != "" {
+		outcome := models.AuditDenied
+		if hasAccess {
+			outcome = models.AuditAllowed
+		}
+		if err := audit.Record(initializers.DB, utils.AuditClient(c), models.AuditAccessCheck, walletAddress,
+			transcript.OwnerWallet, transcript.TranscriptID, outcome, map[string]string{"ipfs_uri": uri.String()}); err != nil {
+			log.Error("Failed to record access check: ", err)
+			panic(customErrors.ErrInternalServer)
+		}
+	}
 	if hasAccess {
 		c.JSON(http.StatusOK, gin.H{"message": "Access granted"})
 	} else {
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"api/internal/customErrors"
+	"api/internal/envelope"
+	"api/internal/initializers"
+	"api/internal/ipfs"
+	"api/internal/ipfsuri"
+	"api/internal/kms"
+	"api/internal/repository"
+	"api/pkg/utils"
+	"errors"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+// Types a credential file may have, sniffed from its content rather than taken from the client
+var allowedUploadTypes = map[string]bool{
+	"application/pdf": true,
+	"image/gif":       true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+}
+
+// CreateUpload stores a credential file on IPFS and pins it. The response carries what the
+// institution passes to issueCredential: the IPFS URI and the keccak256 hash of the stored
+// bytes, along with the EIP-191 digest its wallet signs over that hash. With encrypt set the
+// file is envelope encrypted first, and the CID and hash are those of the ciphertext. Only
+// institutions may upload, see InstitutionMiddleware.
+func CreateUpload(c *gin.Context) {
+	if initializers.IPFS == nil {
+		panic(customErrors.ErrIPFSDisabled)
+	}
+
+	walletAddress := c.GetHeader("Wallet-Address")
+
+	var input repository.UploadInput
+	if err := c.ShouldBind(&input); err != nil {
+		log.Error("Binding error: ", err)
+		panic(uploadBodyError(err))
+	}
+	if input.Encrypt && initializers.KMS == nil {
+		panic(customErrors.ErrEncryptionDisabled)
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		panic(uploadBodyError(err))
+	}
+	if header.Size > utils.UploadMaxBytes() {
+		panic(customErrors.ErrRequestTooLarge)
+	}
+	file, err := header.Open()
+	if err != nil {
+		log.Error("Failed to open upload: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		log.Error("Failed to read upload: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	if len(data) == 0 {
+		panic(customErrors.ErrInsufficientData)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedUploadTypes[contentType] {
+		panic(customErrors.ErrUnsupportedMediaType)
+	}
+
+	stored := data
+	var wrapped kms.WrappedKey
+	if input.Encrypt {
+		stored, wrapped, err = envelope.Seal(c.Request.Context(), initializers.KMS, data)
+		if err != nil {
+			log.Error("Failed to encrypt upload: ", err)
+			panic(customErrors.ErrInternalServer)
+		}
+	}
+
+	root, blocks, err := ipfs.BuildFile(stored)
+	if err != nil {
+		log.Error("Failed to build the upload DAG: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	for _, block := range blocks {
+		if err := initializers.IPFS.PutBlock(c.Request.Context(), block); err != nil {
+			log.Error("Failed to store upload block: ", err)
+			panic(customErrors.ErrUploadFailed)
+		}
+	}
+	if err := initializers.Pinner.Pin(c.Request.Context(), root, header.Filename); err != nil {
+		log.Error("Failed to pin upload: ", err)
+		panic(customErrors.ErrUploadFailed)
+	}
+	if input.Encrypt {
+		if err := envelope.StoreKey(initializers.DB, root.String(), walletAddress, wrapped); err != nil {
+			log.Error("Failed to store document key: ", err)
+			panic(customErrors.ErrInternalServer)
+		}
+	}
+
+	hash := crypto.Keccak256(stored)
+	response := gin.H{
+		"message":        "File uploaded successfully",
+		"cid":            root.String(),
+		"ipfs_uri":       ipfsuri.URI{CID: root}.String(),
+		"name":           header.Filename,
+		"content_type":   contentType,
+		"size":           len(stored),
+		"hash":           hexutil.Encode(hash),
+		"signing_digest": hexutil.Encode(accounts.TextHash(hash)),
+		"pinned_by":      initializers.Pinner.Name(),
+		"encrypted":      input.Encrypt,
+	}
+	if input.Encrypt {
+		response["algorithm"] = envelope.Algorithm
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// uploadBodyError reports a body over the size limit as such and anything else as missing data.
+func uploadBodyError(err error) *customErrors.ApiError {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return customErrors.ErrRequestTooLarge
+	}
+	return customErrors.ErrInsufficientData
+}
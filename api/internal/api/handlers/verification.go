@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"api/internal/models"
+	"api/internal/receipt"
+	"api/internal/verification"
+	"api/pkg/utils"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GetCredentialVerification returns the verification report of a credential: whether its media
+// still hashes to what the issuer signed, whether the issuer is still an institution and whether
+// it was revoked. Reports are cached per token for a few minutes.
+func GetCredentialVerification(c *gin.Context) {
+	if initializers.NFTCMS == nil {
+		panic(customErrors.ErrChainDisabled)
+	}
+	if initializers.IPFS == nil {
+		panic(customErrors.ErrIPFSDisabled)
+	}
+
+	report, err := verification.Get(c.Request.Context(), initializers.NFTCMS, initializers.IPFS, tokenIDParam(c))
+	if err != nil {
+		panic(verificationError(err))
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// VerifyCredentialPublic lets anyone holding a token ID check a credential without a wallet or
+// session: who issued it, whether the issuer is still an institution, whether it was revoked
+// and why, and whether the media matches what the issuer signed. A file received as the
+// credential may be sent as the multipart field "file", or its keccak256 as the hash query
+// parameter, to check it is the signed one. Only facts already public on chain are returned.
+func VerifyCredentialPublic(c *gin.Context) {
+	if initializers.NFTCMS == nil {
+		panic(customErrors.ErrChainDisabled)
+	}
+	if initializers.IPFS == nil {
+		panic(customErrors.ErrIPFSDisabled)
+	}
+	tokenID := tokenIDParam(c)
+
+	var fileHash *common.Hash
+	if rawHash := c.Query("hash"); rawHash != "" {
+		decoded, err := hexutil.Decode(rawHash)
+		if err != nil || len(decoded) != common.HashLength {
+			panic(customErrors.ErrInvalidHash)
+		}
+		hash := common.BytesToHash(decoded)
+		fileHash = &hash
+	} else if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			panic(uploadBodyError(err))
+		}
+		file, err := header.Open()
+		if err != nil {
+			log.Error("Failed to open upload: ", err)
+			panic(customErrors.ErrInternalServer)
+		}
+		defer file.Close()
+		hasher := crypto.NewKeccakState()
+		if _, err := io.Copy(hasher, file); err != nil {
+			log.Error("Failed to read upload: ", err)
+			panic(customErrors.ErrInternalServer)
+		}
+		hash := common.BytesToHash(hasher.Sum(nil))
+		fileHash = &hash
+	}
+
+	report, err := verification.Public(c.Request.Context(), initializers.DB, initializers.NFTCMS, initializers.IPFS, tokenID, fileHash)
+	if err != nil {
+		panic(verificationError(err))
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetReceiptKeys returns the JWK Set verification receipts and status lists can be checked
+// against offline. Keys that signed in the past stay in the set after a rotation.
+func GetReceiptKeys(c *gin.Context) {
+	set, err := initializers.ReceiptKeys.Set(c.Request.Context())
+	if err != nil {
+		log.Error("Failed to fetch receipt keys: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, set)
+}
+
+// verificationReceipt verifies the credential shared through the approved request and returns
+// the result as a receipt signed for its recipient. Receipts are stored: the latest one is served
+// again while the outcome it asserts still holds, and a new one is issued once the credential's
+// status, issuer role or media check changed. When an authority is configured each receipt is
+// timestamped once; a receipt is still returned when timestamping fails.
+func verificationReceipt(c *gin.Context, request models.Request, transcriptID string) (string, *models.Timestamp, *customErrors.ApiError) {
+	if initializers.NFTCMS == nil {
+		return "", nil, customErrors.ErrChainDisabled
+	}
+	if initializers.IPFS == nil {
+		return "", nil, customErrors.ErrIPFSDisabled
+	}
+	tokenID, ok := new(big.Int).SetString(transcriptID, 10)
+	if !ok || tokenID.Sign() <= 0 {
+		return "", nil, customErrors.ErrInvalidTokenID
+	}
+
+	report, err := verification.Get(c.Request.Context(), initializers.NFTCMS, initializers.IPFS, tokenID)
+	if err != nil {
+		return "", nil, verificationError(err)
+	}
+	passed := make(map[string]bool, len(report.Checks))
+	for _, check := range report.Checks {
+		passed[check.Name] = check.Passed
+	}
+	claims := receipt.Claims{
+		Issuer:           initializers.ReceiptIssuer,
+		Subject:          report.TokenID,
+		IssuedAt:         time.Now().Unix(),
+		ID:               uuid.NewString(),
+		RequestID:        request.ID,
+		Recipient:        request.RecipientWallet,
+		TokenID:          report.TokenID,
+		Owner:            report.Owner,
+		CredentialIssuer: report.Issuer,
+		IssuerAuthorized: passed[verification.CheckIssuerRole],
+		Status:           report.Status,
+		Hash:             receipt.HashResult{MediaHash: report.MediaHash, Matches: passed[verification.CheckSignature]},
+		Verified:         report.Verified,
+		Contract:         initializers.NFTCMS.Address.Hex(),
+		BlockNumber:      report.BlockNumber,
+		VerifiedAt:       report.VerifiedAt,
+	}
+	if initializers.ChainID != nil {
+		claims.ChainID = initializers.ChainID.String()
+	}
+
+	issued, err := utils.LatestReceipt(initializers.DB, request.ID, transcriptID)
+	if err != nil {
+		log.Error("Failed to fetch verification receipt: ", err)
+		return "", nil, customErrors.ErrInternalServer
+	}
+	if issued == nil || issued.Outcome != claims.Outcome() {
+		signed, err := initializers.ReceiptKeys.Signer.Sign(claims)
+		if err != nil {
+			log.Error("Failed to sign verification receipt: ", err)
+			return "", nil, customErrors.ErrInternalServer
+		}
+		issued = &models.VerificationReceipt{
+			ID:           claims.ID,
+			RequestID:    request.ID,
+			TranscriptID: transcriptID,
+			Document:     signed,
+			Outcome:      claims.Outcome(),
+		}
+		if err := initializers.DB.Create(issued).Error; err != nil {
+			log.Error("Failed to save verification receipt: ", err)
+			return "", nil, customErrors.ErrInternalServer
+		}
+	}
+	if initializers.TSA == nil {
+		return issued.Document, nil, nil
+	}
+
+	timestamp, err := utils.ReceiptTimestamp(initializers.DB, *issued)
+	if err != nil {
+		log.Error("Failed to fetch receipt timestamp: ", err)
+		return issued.Document, nil, nil
+	}
+	if timestamp == nil {
+		// Also retries receipts whose timestamping failed before
+		stored, err := utils.TimestampReceipt(c.Request.Context(), initializers.DB, initializers.TSA, *issued)
+		if err != nil {
+			log.Error("Failed to timestamp verification receipt: ", err)
+			return issued.Document, nil, nil
+		}
+		timestamp = &stored
+	}
+	return issued.Document, timestamp, nil
+}
+
+func tokenIDParam(c *gin.Context) *big.Int {
+	tokenID, ok := new(big.Int).SetString(c.Param("token_id"), 10)
+	if !ok || tokenID.Sign() <= 0 {
+		panic(customErrors.ErrInvalidTokenID)
+	}
+	return tokenID
+}
+
+func verificationError(err error) *customErrors.ApiError {
+	if errors.Is(err, verification.ErrNotMinted) {
+		return customErrors.ErrCredentialNotFound
+	}
+	log.Error("Failed to verify credential: ", err)
+	return customErrors.ErrVerificationFailed
+}
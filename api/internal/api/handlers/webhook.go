@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"api/internal/models"
+	"api/internal/repository"
+	"api/internal/webhooks"
+	"errors"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// Webhook handlers, for recipients and institutions integrating their own systems
+
+func CreateWebhook(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	var input repository.WebhookEndpointInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Error("Binding error: ", err)
+		panic(customErrors.ErrInsufficientData)
+	}
+
+	if err := input.Validate(); err != nil {
+		log.Error("Invalid webhook: ", err)
+		panic(err)
+	}
+
+	endpoint, err := webhooks.CreateEndpoint(initializers.DB, walletAddress, input.URL, input.EventTypes)
+	if err != nil {
+		log.Error("Failed to create webhook: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	// The secret is only ever returned here
+	response := webhookResponse(endpoint)
+	response["secret"] = endpoint.Secret
+	c.JSON(http.StatusCreated, gin.H{"message": "Webhook created successfully", "webhook": response})
+}
+
+func GetWebhooks(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	endpoints, err := webhooks.ListEndpoints(initializers.DB, walletAddress)
+	if err != nil {
+		log.Error("Failed to get webhooks: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	webhookList := make([]gin.H, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		webhookList = append(webhookList, webhookResponse(endpoint))
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhooks retrieved successfully", "webhooks": webhookList})
+}
+
+func DeleteWebhook(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	deleted, err := webhooks.DeleteEndpoint(initializers.DB, c.Param("webhook_id"), walletAddress)
+	if err != nil {
+		log.Error("Failed to delete webhook: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	if !deleted {
+		panic(customErrors.ErrWebhookNotFound)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+func GetWebhookDeliveries(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	endpoint, err := webhooks.GetEndpoint(initializers.DB, c.Param("webhook_id"), walletAddress)
+	if err != nil {
+		log.Error("Webhook not found or DB error: ", err)
+		panic(customErrors.ErrWebhookNotFound)
+	}
+
+	// ?status=dead lists the dead-letter queue
+	status := models.WebhookDeliveryStatus(c.Query("status"))
+	switch status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryDead:
+	default:
+		panic(customErrors.ErrInvalidStatusFilter)
+	}
+
+	deliveries, err := webhooks.ListDeliveries(initializers.DB, endpoint.ID, status, 100)
+	if err != nil {
+		log.Error("Failed to get webhook deliveries: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	deliveryList := make([]gin.H, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveryList = append(deliveryList, deliveryResponse(delivery))
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deliveries retrieved successfully", "deliveries": deliveryList})
+}
+
+func RedeliverWebhook(c *gin.Context) {
+	walletAddress := c.GetHeader("Wallet-Address")
+	delivery, err := webhooks.Redeliver(initializers.DB, c.Param("delivery_id"), walletAddress)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			panic(customErrors.ErrWebhookNotFound)
+		}
+		log.Error("Failed to redeliver webhook: ", err)
+		panic(customErrors.ErrInternalServer)
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Webhook delivery queued", "delivery_id": delivery.ID})
+}
+
+func webhookResponse(endpoint models.WebhookEndpoint) gin.H {
+	return gin.H{
+		"webhook_id":  endpoint.ID,
+		"url":         endpoint.URL,
+		"event_types": endpoint.EventTypes,
+		"active":      endpoint.Active,
+		"created_at":  endpoint.CreatedAt,
+	}
+}
+
+func deliveryResponse(delivery models.WebhookDelivery) gin.H {
+	return gin.H{
+		"delivery_id":      delivery.ID,
+		"event_id":         delivery.EventID,
+		"event_type":       delivery.EventType,
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"next_attempt_at":  delivery.NextAttemptAt,
+		"last_status_code": delivery.LastStatusCode,
+		"last_error":       delivery.LastError,
+		"created_at":       delivery.CreatedAt,
+	}
+}
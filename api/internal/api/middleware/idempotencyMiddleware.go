@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"api/internal/customErrors"
+	"api/pkg/constants"
+	"api/pkg/utils"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// idempotencyRecord is what is kept in Redis for an Idempotency-Key.
+type idempotencyRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Signature   string      `json:"signature,omitempty"` // SHA-256 of the Signature header that authenticated the first attempt
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// bodyRecorder copies everything the handler writes so it can be replayed later.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// How often the lock on a key is renewed while its request is handled, well within
+// IdempotencyLockTimeout
+var idempotencyLockRenewal = constants.IdempotencyLockTimeout * time.Minute / 3
+
+// Headers that belong to the original exchange and must not be replayed
+var nonReplayableHeaders = []string{"Session-Token", "Date", "Content-Length"}
+
+// IdempotencyMiddleware makes POST requests carrying an Idempotency-Key header safe to retry.
+// The first response for a wallet/key pair is kept for IDEMPOTENCY_TTL_MINUTES and replayed
+// byte-for-byte on retries; reusing the key with a different method, path or body is rejected.
+// It must run after authentication so that keys are scoped to a verified wallet.
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return idempotencyMiddleware(false)
+}
+
+// SignedIdempotencyMiddleware is IdempotencyMiddleware for routes authenticated by a signed
+// nonce. It runs before VerifyDigitalSignatureMiddleware, because the first attempt used up the
+// nonce and a retry could never pass the signature check again. A retry is replayed when it
+// carries the signature that authenticated the first attempt; a retry signed over a new nonce
+// has that signature verified first.
+func SignedIdempotencyMiddleware() gin.HandlerFunc {
+	return idempotencyMiddleware(true)
+}
+
+func idempotencyMiddleware(signed bool) gin.HandlerFunc {
+	ttl := utils.GetEnvInt("IDEMPOTENCY_TTL_MINUTES", constants.IdempotencyKeyTimeout)
+
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > constants.IdempotencyKeyMaxLen {
+			panic(customErrors.ErrInvalidIdempotencyKey)
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			panic(customErrors.ErrRequestTooLarge)
+		}
+		if err != nil {
+			panic(customErrors.ErrInsufficientData)
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + "\n" + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+		redisKey := fmt.Sprintf("idempotency:%s:%s", c.GetHeader("Wallet-Address"), key)
+		var signature string
+		if signed {
+			signature = signatureDigest(c)
+		}
+
+		// Claim the key; if someone already has it, replay or reject
+		lock, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint, Signature: signature})
+		acquired, err := utils.StoreInRedisIfAbsent(redisKey, string(lock), constants.IdempotencyLockTimeout)
+		if err != nil {
+			log.Printf("Failed to claim idempotency key: %v", err)
+			panic(customErrors.ErrInternalServer)
+		}
+		if !acquired {
+			replayIdempotentResponse(c, redisKey, fingerprint, signed)
+			return
+		}
+
+		// The lock is short so that a crashed instance frees the key soon, and renewed so that a
+		// slow handler keeps it: a retry must never run the handler a second time
+		stopRenewal := renewIdempotencyLock(redisKey)
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		completed := false
+		defer func() {
+			stopRenewal()
+			// Release the key if the handler panicked or failed, so the client can retry
+			if !completed {
+				if err := utils.DeleteFromRedis(redisKey); err != nil {
+					log.Printf("Failed to release idempotency key: %v", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError || c.IsAborted() {
+			return
+		}
+
+		header := recorder.Header().Clone()
+		for _, name := range nonReplayableHeaders {
+			header.Del(name)
+		}
+		record, err := json.Marshal(idempotencyRecord{
+			Fingerprint: fingerprint,
+			Signature:   signature,
+			Completed:   true,
+			Status:      recorder.Status(),
+			Header:      header,
+			Body:        recorder.body.Bytes(),
+		})
+		if err != nil {
+			log.Printf("Failed to encode idempotent response: %v", err)
+			return
+		}
+		stopRenewal() // A renewal after the store would cut the response's TTL to the lock's
+		if err := utils.StoreInRedis(redisKey, string(record), ttl); err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// renewIdempotencyLock extends the lock on the key every idempotencyLockRenewal until the returned
+// function is called. That function waits for a renewal in progress, so none happens after it.
+func renewIdempotencyLock(redisKey string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(idempotencyLockRenewal)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := utils.ExtendInRedis(redisKey, constants.IdempotencyLockTimeout); err != nil {
+					log.Printf("Failed to renew idempotency key: %v", err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, redisKey, fingerprint string, signed bool) {
+	stored, err := utils.RetrieveFromRedis(redisKey)
+	if errors.Is(err, redis.Nil) {
+		// The previous attempt was released between the claim and this read
+		panic(customErrors.ErrIdempotencyKeyInUse)
+	} else if err != nil {
+		log.Printf("Failed to read idempotency key: %v", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(stored), &record); err != nil {
+		log.Printf("Failed to decode idempotent response: %v", err)
+		panic(customErrors.ErrInternalServer)
+	}
+
+	if record.Fingerprint != fingerprint {
+		panic(customErrors.ErrIdempotencyKeyReused)
+	}
+	if !record.Completed {
+		panic(customErrors.ErrIdempotencyKeyInUse)
+	}
+	// The key was claimed before authentication; only the client that signed may see the response
+	if signed && record.Signature != signatureDigest(c) {
+		if err := utils.VerifyDigitalSignature(c); err != nil {
+			panic(err)
+		}
+	}
+
+	for name, values := range record.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Writer.WriteHeader(record.Status)
+	if _, err := c.Writer.Write(record.Body); err != nil {
+		log.Printf("Failed to replay idempotent response: %v", err)
+	}
+	c.Abort()
+}
+
+func signatureDigest(c *gin.Context) string {
+	digest := sha256.Sum256([]byte(c.GetHeader("Signature")))
+	return hex.EncodeToString(digest[:])
+}
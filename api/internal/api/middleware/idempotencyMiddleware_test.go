@@ -0,0 +1,314 @@
+package middleware
+
+import (
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"api/pkg/constants"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves the few commands the middleware uses (GET, SET with NX, DEL, EXPIRE) over
+// RESP, from a map. Expiry is recorded but keys never expire.
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	ttls     map[string]string // Last expiry set on the key, as sent
+	renewals map[string]int    // EXPIRE commands per key
+}
+
+func startFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &fakeRedis{values: make(map[string]string), ttls: make(map[string]string), renewals: make(map[string]int)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go store.serve(conn)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{Addr: listener.Addr().String(), Protocol: 2, DisableIndentity: true})
+	previous := initializers.RedisClient
+	initializers.RedisClient = client
+	t.Cleanup(func() {
+		initializers.RedisClient = previous
+		client.Close()
+		listener.Close()
+	})
+	return store
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.execute(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil { // $<length>
+			return nil, err
+		}
+		value, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(value, "\r\n")
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) execute(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		value, ok := f.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		for _, option := range args[3:] {
+			if _, exists := f.values[args[1]]; strings.EqualFold(option, "NX") && exists {
+				return "$-1\r\n"
+			}
+		}
+		f.values[args[1]] = args[2]
+		delete(f.ttls, args[1])
+		for i, option := range args[3 : len(args)-1] {
+			if strings.EqualFold(option, "EX") || strings.EqualFold(option, "PX") {
+				f.ttls[args[1]] = strings.ToLower(option) + " " + args[4+i]
+			}
+		}
+		return "+OK\r\n"
+	case "EXPIRE":
+		if _, ok := f.values[args[1]]; !ok {
+			return ":0\r\n"
+		}
+		f.ttls[args[1]] = "ex " + args[2]
+		f.renewals[args[1]]++
+		return ":1\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := f.values[key]; ok {
+				delete(f.values, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func (f *fakeRedis) state(key string) (ttl string, renewals int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ttls[key], f.renewals[key]
+}
+
+func (f *fakeRedis) set(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] = value
+}
+
+func idempotencyTestRouter(signed bool) (*gin.Engine, map[string]int) {
+	gin.SetMode(gin.TestMode)
+	calls := make(map[string]int)
+	router := gin.New()
+	router.Use(ApiErrorHandler())
+	if signed {
+		router.Use(SignedIdempotencyMiddleware())
+	} else {
+		router.Use(IdempotencyMiddleware())
+	}
+	router.POST("/items", func(c *gin.Context) {
+		calls["/items"]++
+		c.Header("Location", "/items/"+strconv.Itoa(calls["/items"]))
+		c.JSON(http.StatusCreated, gin.H{"item": calls["/items"]})
+	})
+	router.POST("/flaky", func(c *gin.Context) {
+		calls["/flaky"]++
+		if calls["/flaky"] == 1 {
+			panic(customErrors.ErrInternalServer)
+		}
+		c.JSON(http.StatusOK, gin.H{"attempt": calls["/flaky"]})
+	})
+	router.POST("/slow", func(c *gin.Context) {
+		calls["/slow"]++
+		time.Sleep(50 * time.Millisecond)
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	router.GET("/items", func(c *gin.Context) {
+		calls["GET /items"]++
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	return router, calls
+}
+
+type idempotencyStep struct {
+	name      string
+	method    string
+	path      string
+	wallet    string
+	key       string
+	signature string
+	body      string
+	status    int
+	replayed  bool
+	calls     map[string]int // Handler calls after the step
+	response  string         // Expected body, when set
+}
+
+func runIdempotencySteps(t *testing.T, router *gin.Engine, calls map[string]int, steps []idempotencyStep) {
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			method := step.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			request := httptest.NewRequest(method, step.path, strings.NewReader(step.body))
+			request.Header.Set("Wallet-Address", step.wallet)
+			request.Header.Set("Idempotency-Key", step.key)
+			if step.signature != "" {
+				request.Header.Set("Signature", step.signature)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != step.status {
+				t.Errorf("status = %d, want %d (%s)", recorder.Code, step.status, recorder.Body)
+			}
+			if replayed := recorder.Header().Get("Idempotent-Replayed") == "true"; replayed != step.replayed {
+				t.Errorf("replayed = %v, want %v", replayed, step.replayed)
+			}
+			if step.response != "" && recorder.Body.String() != step.response {
+				t.Errorf("body = %s, want %s", recorder.Body, step.response)
+			}
+			for path, want := range step.calls {
+				if calls[path] != want {
+					t.Errorf("%s handled %d times, want %d", path, calls[path], want)
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	store := startFakeRedis(t)
+	router, calls := idempotencyTestRouter(false)
+
+	// A request still being handled holds its key with an incomplete record
+	fingerprint := sha256.Sum256([]byte("POST\n/items\n" + `{"name":"busy"}`))
+	lock, _ := json.Marshal(idempotencyRecord{Fingerprint: hex.EncodeToString(fingerprint[:])})
+	store.set("idempotency:0xaaa:busy", string(lock))
+
+	runIdempotencySteps(t, router, calls, []idempotencyStep{
+		{name: "first attempt runs the handler", path: "/items", wallet: "0xaaa", key: "k1", body: `{"name":"a"}`,
+			status: http.StatusCreated, calls: map[string]int{"/items": 1}, response: `{"item":1}`},
+		{name: "retry replays the stored response", path: "/items", wallet: "0xaaa", key: "k1", body: `{"name":"a"}`,
+			status: http.StatusCreated, replayed: true, calls: map[string]int{"/items": 1}, response: `{"item":1}`},
+		{name: "same key with another body is rejected", path: "/items", wallet: "0xaaa", key: "k1", body: `{"name":"b"}`,
+			status: http.StatusUnprocessableEntity, calls: map[string]int{"/items": 1}},
+		{name: "same key on another path is rejected", path: "/flaky", wallet: "0xaaa", key: "k1", body: `{"name":"a"}`,
+			status: http.StatusUnprocessableEntity, calls: map[string]int{"/flaky": 0}},
+		{name: "key in progress is a conflict", path: "/items", wallet: "0xaaa", key: "busy", body: `{"name":"busy"}`,
+			status: http.StatusConflict, calls: map[string]int{"/items": 1}},
+		{name: "keys are scoped to the wallet", path: "/items", wallet: "0xbbb", key: "k1", body: `{"name":"a"}`,
+			status: http.StatusCreated, calls: map[string]int{"/items": 2}, response: `{"item":2}`},
+		{name: "new key runs the handler again", path: "/items", wallet: "0xaaa", key: "k2", body: `{"name":"a"}`,
+			status: http.StatusCreated, calls: map[string]int{"/items": 3}},
+		{name: "failed attempt releases the key", path: "/flaky", wallet: "0xaaa", key: "k3",
+			status: http.StatusInternalServerError, calls: map[string]int{"/flaky": 1}},
+		{name: "retry after a failure runs the handler", path: "/flaky", wallet: "0xaaa", key: "k3",
+			status: http.StatusOK, calls: map[string]int{"/flaky": 2}},
+		{name: "requests without a key are not tracked", path: "/items", wallet: "0xaaa", body: `{"name":"a"}`,
+			status: http.StatusCreated, calls: map[string]int{"/items": 4}},
+		{name: "other methods are not tracked", method: http.MethodGet, path: "/items", wallet: "0xaaa", key: "k1",
+			status: http.StatusOK, calls: map[string]int{"GET /items": 1}},
+		{name: "oversized key is rejected", path: "/items", wallet: "0xaaa", key: strings.Repeat("k", 256),
+			status: http.StatusBadRequest, calls: map[string]int{"/items": 4}},
+	})
+}
+
+func TestSignedIdempotencyMiddleware(t *testing.T) {
+	startFakeRedis(t)
+	router, calls := idempotencyTestRouter(true)
+
+	runIdempotencySteps(t, router, calls, []idempotencyStep{
+		{name: "first attempt runs the handler", path: "/items", wallet: "0xaaa", key: "k1", signature: "0xsig",
+			status: http.StatusCreated, calls: map[string]int{"/items": 1}},
+		{name: "retry with the same signature is replayed", path: "/items", wallet: "0xaaa", key: "k1", signature: "0xsig",
+			status: http.StatusCreated, replayed: true, calls: map[string]int{"/items": 1}},
+		// No nonce is outstanding for the wallet, so the new signature cannot verify
+		{name: "retry with another signature must verify", path: "/items", wallet: "0xaaa", key: "k1", signature: "0xother",
+			status: http.StatusUnprocessableEntity, calls: map[string]int{"/items": 1}},
+	})
+}
+
+func TestIdempotencyLockRenewal(t *testing.T) {
+	store := startFakeRedis(t)
+	previous := idempotencyLockRenewal
+	idempotencyLockRenewal = 5 * time.Millisecond
+	t.Cleanup(func() { idempotencyLockRenewal = previous })
+	router, calls := idempotencyTestRouter(false)
+
+	runIdempotencySteps(t, router, calls, []idempotencyStep{
+		{name: "slow handler", path: "/slow", wallet: "0xaaa", key: "slow",
+			status: http.StatusOK, calls: map[string]int{"/slow": 1}},
+	})
+
+	ttl, renewals := store.state("idempotency:0xaaa:slow")
+	if renewals == 0 {
+		t.Error("the lock was not renewed while the handler ran")
+	}
+	// The stored response keeps its own TTL, no renewal overwrote it
+	if want := fmt.Sprintf("ex %d", constants.IdempotencyKeyTimeout*60); ttl != want {
+		t.Errorf("response stored with expiry %q, want %q", ttl, want)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, after := store.state("idempotency:0xaaa:slow"); after != renewals {
+		t.Errorf("the lock was renewed %d more times after the response was stored", after-renewals)
+	}
+}
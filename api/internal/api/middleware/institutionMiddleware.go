@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"api/internal/chain"
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"log"
+)
+
+// InstitutionMiddleware lets through only wallets holding INSTITUTION_ROLE on the contract. It
+// runs after authentication and before anything reads the request body.
+func InstitutionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if initializers.NFTCMS == nil {
+			panic(customErrors.ErrChainDisabled)
+		}
+		walletAddress := c.GetHeader("Wallet-Address")
+		if !common.IsHexAddress(walletAddress) {
+			panic(customErrors.ErrAccessDenied)
+		}
+		institution, err := initializers.NFTCMS.HasRole(c.Request.Context(), chain.InstitutionRole, common.HexToAddress(walletAddress))
+		if err != nil {
+			log.Printf("Failed to read wallet role: %v", err)
+			panic(customErrors.ErrVerificationFailed)
+		}
+		if !institution {
+			panic(customErrors.ErrAccessDenied)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"api/internal/customErrors"
+	"api/internal/initializers"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"strconv"
+	"time"
+)
+
+// RateLimitMiddleware allows each client IP limit requests per window, counted in Redis so the
+// limit holds across replicas. Windows are fixed; a client may burst up to twice the limit
+// across a window boundary. When Redis is unavailable requests are let through.
+func RateLimitMiddleware(name string, limit int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		start := now.Truncate(window)
+		key := fmt.Sprintf("ratelimit:%s:%s:%d", name, c.ClientIP(), start.Unix())
+
+		ctx := c.Request.Context()
+		count, err := initializers.RedisClient.Incr(ctx, key).Result()
+		if err != nil {
+			log.Printf("Rate limiter failed, letting the request through: %v", err)
+			c.Next()
+			return
+		}
+		if count == 1 {
+			initializers.RedisClient.Expire(ctx, key, window)
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(max(int64(limit)-count, 0), 10))
+		if count > int64(limit) {
+			retryAfter := start.Add(window).Sub(now)
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			panic(customErrors.ErrTooManyRequests)
+		}
+		c.Next()
+	}
+}
@@ -39,3 +39,25 @@ func SessionMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// StreamSessionMiddleware authenticates long-lived streaming connections. Browsers cannot set
+// headers on EventSource or WebSocket requests, so instead of the session headers they may pass
+// a single-use ticket from POST /v1/stream/tickets as the ticket query parameter.
+func StreamSessionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			SessionMiddleware()(c)
+			return
+		}
+
+		walletAddress, err := utils.RedeemStreamTicket(ticket)
+		if err != nil || walletAddress == "" {
+			panic(customErrors.ErrInvalidStreamTicket)
+		}
+
+		// Handlers read the wallet from the header
+		c.Request.Header.Set("Wallet-Address", walletAddress)
+		c.Next()
+	}
+}
@@ -3,7 +3,10 @@ package api
 import (
 	"api/internal/api/handlers"
 	"api/internal/api/middleware"
+	"api/pkg/constants"
+	"api/pkg/utils"
 	"github.com/gin-gonic/gin"
+	"time"
 )
 
 func SetupRoutes(router *gin.Engine) {
@@ -18,24 +21,111 @@ func SetupRoutes(router *gin.Engine) {
 		{
 			sessionGroup := requestGroup.Group("/")
 			{
-				sessionGroup.Use(middleware.SessionMiddleware())
+				sessionGroup.Use(middleware.SessionMiddleware(), middleware.IdempotencyMiddleware())
 				sessionGroup.GET("/", handlers.GetRequests)
 				sessionGroup.GET("/:request_id", handlers.GetRequest)
+				sessionGroup.GET("/:request_id/history", handlers.GetRequestHistory)
+				sessionGroup.GET("/:request_id/messages", handlers.GetMessages)
+				sessionGroup.POST("/:request_id/messages", handlers.SendMessage)
+				sessionGroup.GET("/:request_id/presentation", handlers.GetPresentation)
+				sessionGroup.GET("/:request_id/presentation/typed-data", handlers.GetPresentationTypedData)
+				sessionGroup.POST("/:request_id/presentation", handlers.SignPresentation)
 			}
 			digitalSignatureGroup := requestGroup.Group("/")
 			{
-				digitalSignatureGroup.Use(middleware.VerifyDigitalSignatureMiddleware())
+				digitalSignatureGroup.Use(middleware.SignedIdempotencyMiddleware(), middleware.VerifyDigitalSignatureMiddleware())
 				digitalSignatureGroup.POST("/create", handlers.CreateRequest)               // add mandatory nonce verifier
 				digitalSignatureGroup.POST("/respond/:request_id", handlers.RespondRequest) // add mandatory nonce verifier
 			}
 		}
+		meGroup := version.Group("/me")
+		{
+			meGroup.Use(middleware.SessionMiddleware())
+			meGroup.GET("/inbox", handlers.GetInbox)
+			meGroup.GET("/outbox", handlers.GetOutbox)
+			meGroup.GET("/access-log", handlers.GetAccessLog)
+			meGroup.GET("/access-log/:entry_id/proof", handlers.GetAccessLogProof)
+			meGroup.GET("/email", handlers.GetEmail)
+			meGroup.PUT("/email", handlers.SetEmail)
+			meGroup.DELETE("/email", handlers.DeleteEmail)
+			meGroup.PUT("/notifications", handlers.UpdateNotificationPreferences)
+		}
+		emailGroup := version.Group("/email")
+		{
+			emailGroup.GET("/verify", handlers.VerifyEmail)
+		}
+		streamGroup := version.Group("/stream")
+		{
+			streamGroup.POST("/tickets", middleware.SessionMiddleware(), handlers.CreateStreamTicket)
+			streamGroup.GET("/events", middleware.StreamSessionMiddleware(), handlers.StreamEvents)
+			streamGroup.GET("/ws", middleware.StreamSessionMiddleware(), handlers.StreamEventsWebSocket)
+		}
+		webhookGroup := version.Group("/webhooks")
+		{
+			webhookGroup.Use(middleware.SessionMiddleware(), middleware.IdempotencyMiddleware())
+			webhookGroup.GET("/", handlers.GetWebhooks)
+			webhookGroup.POST("/", handlers.CreateWebhook)
+			webhookGroup.DELETE("/:webhook_id", handlers.DeleteWebhook)
+			webhookGroup.GET("/:webhook_id/deliveries", handlers.GetWebhookDeliveries)
+			webhookGroup.POST("/deliveries/:delivery_id/redeliver", handlers.RedeliverWebhook)
+		}
+		pushGroup := version.Group("/push")
+		{
+			pushGroup.GET("/vapid-public-key", handlers.GetVAPIDPublicKey)
+			subscriptionGroup := pushGroup.Group("/subscriptions")
+			{
+				subscriptionGroup.Use(middleware.SessionMiddleware(), middleware.IdempotencyMiddleware())
+				subscriptionGroup.GET("/", handlers.GetPushSubscriptions)
+				subscriptionGroup.POST("/", handlers.CreatePushSubscription)
+				subscriptionGroup.DELETE("/:subscription_id", handlers.DeletePushSubscription)
+			}
+		}
+		contentGroup := version.Group("/content")
+		{
+			contentGroup.Use(middleware.SessionMiddleware(), middleware.IdempotencyMiddleware())
+			contentGroup.GET("/:cid", handlers.GetContent)
+			contentGroup.HEAD("/:cid", handlers.GetContent)
+			contentGroup.POST("/:cid/key", handlers.ReleaseContentKey)
+		}
+		uploadGroup := version.Group("/uploads")
+		{
+			// Only institutions preparing a mint may pin files; room for the multipart envelope around the file
+			uploadGroup.Use(middleware.SessionMiddleware(), middleware.InstitutionMiddleware(), middleware.BodyLimitMiddleware(utils.UploadMaxBytes()+1<<20), middleware.IdempotencyMiddleware())
+			uploadGroup.POST("/", handlers.CreateUpload)
+		}
+		credentialGroup := version.Group("/credentials")
+		{
+			credentialGroup.Use(middleware.SessionMiddleware(), middleware.IdempotencyMiddleware())
+			credentialGroup.GET("/:token_id/verification", handlers.GetCredentialVerification)
+			credentialGroup.GET("/:token_id/vc", handlers.GetVerifiableCredential)
+			credentialGroup.GET("/:token_id/vc/typed-data", handlers.GetCredentialTypedData)
+			credentialGroup.POST("/:token_id/vc/proof", handlers.AttachCredentialProof)
+		}
+		receiptGroup := version.Group("/receipts")
+		{
+			receiptGroup.GET("/keys", handlers.GetReceiptKeys)
+		}
+		publicGroup := version.Group("/public")
+		{
+			publicCredentialGroup := publicGroup.Group("/credentials")
+			{
+				publicCredentialGroup.Use(
+					middleware.RateLimitMiddleware("public-verify", utils.GetEnvInt("PUBLIC_VERIFY_RATE_LIMIT", constants.PublicVerifyRateLimit), time.Minute),
+					middleware.BodyLimitMiddleware(utils.UploadMaxBytes()+1<<20),
+				)
+				publicCredentialGroup.GET("/:token_id/verify", handlers.VerifyCredentialPublic)
+				publicCredentialGroup.POST("/:token_id/verify", handlers.VerifyCredentialPublic)
+			}
+			publicGroup.GET("/status-lists/:list_id", handlers.GetStatusList)
+		}
 		transcriptGroup := version.Group("/transcripts")
 		{
 			sessionGroup := transcriptGroup.Group("/")
 			{
-				sessionGroup.Use(middleware.SessionMiddleware())
+				sessionGroup.Use(middleware.SessionMiddleware(), middleware.IdempotencyMiddleware())
 				sessionGroup.GET("/", handlers.GetTranscripts)
 				sessionGroup.POST("/", handlers.AddTranscript)
+				sessionGroup.GET("/access", handlers.CheckAccess)
 				sessionGroup.GET("/:ipfs_uri", handlers.CheckAccess)
 			}
 		}
@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"api/internal/chain"
+	"api/internal/models"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// Anchor submits the oldest pending anchor, or builds one over the entries not anchored yet
+// (at most maxEntries of them). It returns the anchor it submitted, nil when there was nothing to
+// anchor. A failed submission leaves the anchor pending so the same root is retried.
+func Anchor(ctx context.Context, db *gorm.DB, anchorer chain.Anchorer, maxEntries int) (*models.AuditAnchor, error) {
+	var anchor models.AuditAnchor
+	err := db.Where("status = ?", models.AnchorPending).Order("id ASC").Take(&anchor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		built, err := buildAnchor(db, maxEntries)
+		if err != nil || built == nil {
+			return nil, err
+		}
+		anchor = *built
+	} else if err != nil {
+		return nil, err
+	}
+
+	root := common.HexToHash(anchor.Root)
+	receipt, err := anchorer.Anchor(ctx, root, anchor.FirstEntryID, anchor.LastEntryID)
+	if err != nil {
+		db.Model(&anchor).Update("last_error", err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+	updates := models.AuditAnchor{
+		Status:      models.AnchorAnchored,
+		TxHash:      receipt.TxHash.Hex(),
+		BlockNumber: receipt.BlockNumber,
+		AnchoredAt:  &now,
+	}
+	if receipt.ChainID != nil {
+		updates.ChainID = receipt.ChainID.String()
+	}
+	if receipt.Contract != (common.Address{}) {
+		updates.Contract = receipt.Contract.Hex()
+	}
+	if err := db.Model(&anchor).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return &anchor, db.First(&anchor, anchor.ID).Error
+}
+
+// buildAnchor stores the Merkle root and inclusion proofs of the next unanchored entries.
+func buildAnchor(db *gorm.DB, maxEntries int) (*models.AuditAnchor, error) {
+	var anchor *models.AuditAnchor
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var previous models.AuditAnchor
+		err := tx.Order("last_entry_id DESC").Take(&previous).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var entries []models.AuditEntry
+		if err := tx.Where("id > ?", previous.LastEntryID).Order("id ASC").Limit(maxEntries).Find(&entries).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		// Never anchor entries that no longer match their chain
+		prevHash := GenesisHash
+		var before models.AuditEntry
+		err = tx.Select("hash").Where("id < ?", entries[0].ID).Order("id DESC").Take(&before).Error
+		if err == nil {
+			prevHash = before.Hash
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		leaves := make([][32]byte, 0, len(entries))
+		for _, entry := range entries {
+			hash, err := Hash(entry)
+			if err != nil {
+				return err
+			}
+			if entry.PrevHash != prevHash || entry.Hash != hash {
+				return fmt.Errorf("%w at entry %d", ErrChainBroken, entry.ID)
+			}
+			prevHash = entry.Hash
+
+			raw, err := hex.DecodeString(entry.Hash)
+			if err != nil {
+				return err
+			}
+			leaves = append(leaves, Leaf(raw))
+		}
+
+		root, proofs := MerkleTree(leaves)
+		anchor = &models.AuditAnchor{
+			Root:         hexutil.Encode(root[:]),
+			FirstEntryID: entries[0].ID,
+			LastEntryID:  entries[len(entries)-1].ID,
+			Status:       models.AnchorPending,
+		}
+		if err := tx.Create(anchor).Error; err != nil {
+			return err
+		}
+
+		rows := make([]models.AuditProof, 0, len(entries))
+		for i, entry := range entries {
+			rows = append(rows, models.AuditProof{
+				EntryID:  entry.ID,
+				AnchorID: anchor.ID,
+				Leaf:     hexutil.Encode(leaves[i][:]),
+				Proof:    encodeProof(proofs[i]),
+			})
+		}
+		return tx.CreateInBatches(&rows, 500).Error
+	})
+	return anchor, err
+}
+
+func encodeProof(proof [][32]byte) []string {
+	encoded := make([]string, 0, len(proof))
+	for _, node := range proof {
+		encoded = append(encoded, hexutil.Encode(node[:]))
+	}
+	return encoded
+}
+
+// GetEntryProof returns an entry about the wallet's data with its inclusion proof and anchor.
+// The proof is nil until the entry has been included in an anchor.
+func GetEntryProof(db *gorm.DB, entryID uint64, wallet string) (models.AuditEntry, *models.AuditProof, error) {
+	var entry models.AuditEntry
+	if err := db.Where("id = ? AND subject_wallet = ?", entryID, strings.ToLower(wallet)).Take(&entry).Error; err != nil {
+		return entry, nil, err
+	}
+	var proof models.AuditProof
+	err := db.Joins("Anchor").Where("audit_proofs.entry_id = ?", entryID).Take(&proof).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entry, nil, nil
+	}
+	if err != nil {
+		return entry, nil, err
+	}
+	return entry, &proof, nil
+}
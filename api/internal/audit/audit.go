@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"api/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// GenesisHash is the PrevHash of the first entry.
+var GenesisHash = strings.Repeat("0", 64)
+
+// lockKey serializes appends with a transaction scoped advisory lock, so every entry is chained
+// to the one committed right before it.
+const lockKey = 7_236_001
+
+// Client describes where an action came from.
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// Record appends an entry to the audit log. Pass the transaction of the change it describes
+// when there is one, so the entry is only kept if the change is.
+func Record(db *gorm.DB, client Client, action models.AuditAction, actor, subject, resource string, outcome models.AuditOutcome, metadata map[string]string) error {
+	entry := models.AuditEntry{
+		Action:        action,
+		ActorWallet:   strings.ToLower(actor),
+		SubjectWallet: strings.ToLower(subject),
+		Resource:      resource,
+		Outcome:       outcome,
+		ClientIP:      client.IP,
+		UserAgent:     client.UserAgent,
+		Metadata:      metadata,
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+			return err
+		}
+		var last models.AuditEntry
+		err := tx.Select("hash").Order("id DESC").Limit(1).Take(&last).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			entry.PrevHash = GenesisHash
+		case err != nil:
+			return err
+		default:
+			entry.PrevHash = last.Hash
+		}
+
+		// Postgres keeps microseconds, truncate so the stored time hashes the same
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		hash, err := Hash(entry)
+		if err != nil {
+			return err
+		}
+		entry.Hash = hash
+		return tx.Create(&entry).Error
+	})
+}
+
+// Hash computes the hash of an entry from its content and PrevHash.
+func Hash(entry models.AuditEntry) (string, error) {
+	content, err := json.Marshal(struct {
+		Action        models.AuditAction  `json:"action"`
+		ActorWallet   string              `json:"actor_wallet"`
+		SubjectWallet string              `json:"subject_wallet"`
+		Resource      string              `json:"resource"`
+		Outcome       models.AuditOutcome `json:"outcome"`
+		ClientIP      string              `json:"client_ip"`
+		UserAgent     string              `json:"user_agent"`
+		Metadata      map[string]string   `json:"metadata"`
+		CreatedAt     string              `json:"created_at"`
+	}{
+		Action:        entry.Action,
+		ActorWallet:   entry.ActorWallet,
+		SubjectWallet: entry.SubjectWallet,
+		Resource:      entry.Resource,
+		Outcome:       entry.Outcome,
+		ClientIP:      entry.ClientIP,
+		UserAgent:     entry.UserAgent,
+		Metadata:      entry.Metadata,
+		CreatedAt:     entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(entry.PrevHash), content...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ErrChainBroken is returned by Verify when an entry was changed, removed or inserted.
+var ErrChainBroken = errors.New("audit chain broken")
+
+// Verify checks the chain from the entry after afterID, given the hash that entry must link to
+// (GenesisHash and 0 to check everything). It returns the ID and hash of the last entry checked.
+func Verify(db *gorm.DB, afterID uint64, prevHash string) (uint64, string, error) {
+	const batchSize = 1000
+	for {
+		var entries []models.AuditEntry
+		if err := db.Where("id > ?", afterID).Order("id ASC").Limit(batchSize).Find(&entries).Error; err != nil {
+			return afterID, prevHash, err
+		}
+		for _, entry := range entries {
+			hash, err := Hash(entry)
+			if err != nil {
+				return afterID, prevHash, err
+			}
+			if entry.PrevHash != prevHash || entry.Hash != hash {
+				return afterID, prevHash, fmt.Errorf("%w at entry %d", ErrChainBroken, entry.ID)
+			}
+			afterID, prevHash = entry.ID, entry.Hash
+		}
+		if len(entries) < batchSize {
+			return afterID, prevHash, nil
+		}
+	}
+}
+
+// ListForSubject returns the entries about the wallet's data, newest first, starting before
+// beforeID when it is non-zero.
+func ListForSubject(db *gorm.DB, wallet string, action models.AuditAction, beforeID uint64, limit int) ([]models.AuditEntry, error) {
+	query := db.Where("subject_wallet = ?", strings.ToLower(wallet))
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	var entries []models.AuditEntry
+	err := query.Order("id DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
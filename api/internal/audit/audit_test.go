@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"api/internal/models"
+	"testing"
+	"time"
+)
+
+func testEntry() models.AuditEntry {
+	return models.AuditEntry{
+		Action:        models.AuditAccessCheck,
+		ActorWallet:   "0xrecipient",
+		SubjectWallet: "0xstudent",
+		Resource:      "bafy",
+		Outcome:       models.AuditAllowed,
+		ClientIP:      "203.0.113.7",
+		UserAgent:     "test",
+		Metadata:      map[string]string{"cid": "bafy"},
+		PrevHash:      GenesisHash,
+		CreatedAt:     time.Date(2026, 10, 19, 12, 0, 0, 123456000, time.UTC),
+	}
+}
+
+// linkEntries links the entries the way Record does.
+func linkEntries(t *testing.T, entries []models.AuditEntry) []models.AuditEntry {
+	prevHash := GenesisHash
+	for i := range entries {
+		entries[i].PrevHash = prevHash
+		hash, err := Hash(entries[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[i].Hash = hash
+		prevHash = hash
+	}
+	return entries
+}
+
+func TestHashCoversEveryField(t *testing.T) {
+	base, err := Hash(testEntry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(base) != 64 {
+		t.Fatalf("hash %q is not hex SHA-256", base)
+	}
+
+	tests := []struct {
+		name   string
+		change func(*models.AuditEntry)
+	}{
+		{"action", func(e *models.AuditEntry) { e.Action = models.AuditAccessDeny }},
+		{"actor", func(e *models.AuditEntry) { e.ActorWallet = "0xother" }},
+		{"subject", func(e *models.AuditEntry) { e.SubjectWallet = "0xother" }},
+		{"resource", func(e *models.AuditEntry) { e.Resource = "bafz" }},
+		{"outcome", func(e *models.AuditEntry) { e.Outcome = models.AuditDenied }},
+		{"client IP", func(e *models.AuditEntry) { e.ClientIP = "203.0.113.8" }},
+		{"user agent", func(e *models.AuditEntry) { e.UserAgent = "other" }},
+		{"metadata", func(e *models.AuditEntry) { e.Metadata["cid"] = "bafz" }},
+		{"metadata removed", func(e *models.AuditEntry) { e.Metadata = nil }},
+		{"time", func(e *models.AuditEntry) { e.CreatedAt = e.CreatedAt.Add(time.Microsecond) }},
+		{"previous hash", func(e *models.AuditEntry) { e.PrevHash = base }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := testEntry()
+			tt.change(&entry)
+			hash, err := Hash(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hash == base {
+				t.Errorf("changing the %s does not change the hash", tt.name)
+			}
+		})
+	}
+}
+
+func TestHashIgnoresStorageDetails(t *testing.T) {
+	base, _ := Hash(testEntry())
+
+	tests := []struct {
+		name   string
+		change func(*models.AuditEntry)
+	}{
+		{"ID", func(e *models.AuditEntry) { e.ID = 42 }},
+		{"stored hash", func(e *models.AuditEntry) { e.Hash = "stale" }},
+		{"time zone", func(e *models.AuditEntry) { e.CreatedAt = e.CreatedAt.In(time.FixedZone("UTC+2", 2*60*60)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := testEntry()
+			tt.change(&entry)
+			if hash, _ := Hash(entry); hash != base {
+				t.Errorf("changing the %s changes the hash", tt.name)
+			}
+		})
+	}
+}
+
+func TestChainDetectsTampering(t *testing.T) {
+	entries := linkEntries(t, []models.AuditEntry{testEntry(), testEntry(), testEntry()})
+	for i := 1; i < len(entries); i++ {
+		if entries[i].PrevHash != entries[i-1].Hash {
+			t.Fatalf("entry %d does not link to entry %d", i, i-1)
+		}
+	}
+
+	// Rewriting an entry and rehashing it breaks the link from the next one
+	tampered := entries[1]
+	tampered.Outcome = models.AuditDenied
+	rehashed, _ := Hash(tampered)
+	if rehashed == entries[2].PrevHash {
+		t.Fatal("rewritten entry still links to its successor")
+	}
+	// Removing an entry leaves its successor pointing at a hash no longer in the chain
+	if entries[2].PrevHash == entries[0].Hash {
+		t.Fatal("chain survives removing an entry")
+	}
+}
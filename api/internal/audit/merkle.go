@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"bytes"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The tree matches OpenZeppelin's MerkleProof: leaves are keccak256 of the entry hash, each pair
+// is hashed in sorted order, and a node without a sibling moves up unchanged.
+
+// Leaf returns the Merkle leaf of an entry hash.
+func Leaf(entryHash []byte) [32]byte {
+	return crypto.Keccak256Hash(entryHash)
+}
+
+func hashPair(a, b [32]byte) [32]byte {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
+
+// MerkleTree returns the root over the leaves and the proof of each leaf, in leaf order.
+func MerkleTree(leaves [][32]byte) ([32]byte, [][][32]byte) {
+	proofs := make([][][32]byte, len(leaves))
+	if len(leaves) == 0 {
+		return [32]byte{}, proofs
+	}
+
+	// positions[i] is the index of leaf i's ancestor in the current level
+	positions := make([]int, len(leaves))
+	for i := range positions {
+		positions[i] = i
+	}
+	level := leaves
+	for len(level) > 1 {
+		next := make([][32]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, hashPair(level[i], level[i+1]))
+			}
+		}
+		for leaf, position := range positions {
+			if sibling := position ^ 1; sibling < len(level) {
+				proofs[leaf] = append(proofs[leaf], level[sibling])
+			}
+			positions[leaf] = position / 2
+		}
+		level = next
+	}
+	return level[0], proofs
+}
+
+// VerifyProof reports whether the proof links the leaf to the root.
+func VerifyProof(leaf [32]byte, proof [][32]byte, root [32]byte) bool {
+	computed := leaf
+	for _, sibling := range proof {
+		computed = hashPair(computed, sibling)
+	}
+	return computed == root
+}
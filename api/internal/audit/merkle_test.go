@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"fmt"
+	"testing"
+)
+
+func testLeaves(n int) [][32]byte {
+	leaves := make([][32]byte, n)
+	for i := range leaves {
+		leaves[i] = Leaf([]byte(fmt.Sprintf("entry-%d", i)))
+	}
+	return leaves
+}
+
+func TestMerkleTree(t *testing.T) {
+	tests := []struct {
+		name   string
+		leaves int
+		root   func(leaves [][32]byte) [32]byte
+	}{
+		{"no leaves", 0, func([][32]byte) [32]byte { return [32]byte{} }},
+		{"single leaf", 1, func(l [][32]byte) [32]byte { return l[0] }},
+		{"pair", 2, func(l [][32]byte) [32]byte { return hashPair(l[0], l[1]) }},
+		{"odd leaf moves up", 3, func(l [][32]byte) [32]byte { return hashPair(hashPair(l[0], l[1]), l[2]) }},
+		{"full tree", 4, func(l [][32]byte) [32]byte {
+			return hashPair(hashPair(l[0], l[1]), hashPair(l[2], l[3]))
+		}},
+		{"uneven tree", 5, func(l [][32]byte) [32]byte {
+			return hashPair(hashPair(hashPair(l[0], l[1]), hashPair(l[2], l[3])), l[4])
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaves := testLeaves(tt.leaves)
+			root, proofs := MerkleTree(leaves)
+			if want := tt.root(leaves); root != want {
+				t.Fatalf("root = %x, want %x", root, want)
+			}
+			if len(proofs) != len(leaves) {
+				t.Fatalf("got %d proofs for %d leaves", len(proofs), len(leaves))
+			}
+			for i, leaf := range leaves {
+				if !VerifyProof(leaf, proofs[i], root) {
+					t.Errorf("proof of leaf %d does not verify", i)
+				}
+			}
+		})
+	}
+}
+
+func TestHashPairIsSorted(t *testing.T) {
+	leaves := testLeaves(2)
+	if hashPair(leaves[0], leaves[1]) != hashPair(leaves[1], leaves[0]) {
+		t.Fatal("hashPair depends on the order of its arguments")
+	}
+}
+
+func TestVerifyProofRejects(t *testing.T) {
+	leaves := testLeaves(7)
+	root, proofs := MerkleTree(leaves)
+	tampered := append([][32]byte(nil), proofs[2]...)
+	tampered[0][0] ^= 0xff
+
+	tests := []struct {
+		name  string
+		leaf  [32]byte
+		proof [][32]byte
+		root  [32]byte
+	}{
+		{"leaf not in tree", Leaf([]byte("entry-99")), proofs[2], root},
+		{"proof of another leaf", leaves[2], proofs[3], root},
+		{"tampered sibling", leaves[2], tampered, root},
+		{"truncated proof", leaves[2], proofs[2][:len(proofs[2])-1], root},
+		{"other root", leaves[2], proofs[2], leaves[0]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if VerifyProof(tt.leaf, tt.proof, tt.root) {
+				t.Error("proof verified")
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package chain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"strings"
+)
+
+// AuditAnchorABI is the subset of the AuditAnchor contract ABI the API uses.
+const AuditAnchorABI = `[
+	{"type":"function","name":"anchor","stateMutability":"nonpayable","inputs":[{"name":"root","type":"bytes32"},{"name":"firstEntry","type":"uint256"},{"name":"lastEntry","type":"uint256"}],"outputs":[]},
+	{"type":"event","name":"Anchored","anonymous":false,"inputs":[{"name":"root","type":"bytes32","indexed":true},{"name":"firstEntry","type":"uint256","indexed":false},{"name":"lastEntry","type":"uint256","indexed":false}]}
+]`
+
+// AnchorReceipt identifies the transaction that anchored a root.
+type AnchorReceipt struct {
+	ChainID     *big.Int
+	Contract    common.Address
+	TxHash      common.Hash
+	BlockNumber uint64
+}
+
+// Anchorer submits audit log Merkle roots to a chain.
+type Anchorer interface {
+	// Anchor submits the root of the entries firstEntry..lastEntry and waits for it to be mined.
+	// Submitting a root that is already anchored returns the original receipt.
+	Anchor(ctx context.Context, root [32]byte, firstEntry, lastEntry uint64) (AnchorReceipt, error)
+}
+
+// AnchorBackend is what ContractAnchorer needs from a node; ethclient.Client satisfies it.
+type AnchorBackend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+// ContractAnchorer anchors roots with the deployed AuditAnchor contract.
+type ContractAnchorer struct {
+	Address  common.Address
+	chainID  *big.Int
+	backend  AnchorBackend
+	key      *ecdsa.PrivateKey
+	abi      abi.ABI
+	contract *bind.BoundContract
+}
+
+func NewContractAnchorer(address common.Address, backend AnchorBackend, key *ecdsa.PrivateKey, chainID *big.Int) (*ContractAnchorer, error) {
+	parsed, err := abi.JSON(strings.NewReader(AuditAnchorABI))
+	if err != nil {
+		return nil, err
+	}
+	return &ContractAnchorer{
+		Address:  address,
+		chainID:  chainID,
+		backend:  backend,
+		key:      key,
+		abi:      parsed,
+		contract: bind.NewBoundContract(address, parsed, backend, backend, backend),
+	}, nil
+}
+
+func (a *ContractAnchorer) Anchor(ctx context.Context, root [32]byte, firstEntry, lastEntry uint64) (AnchorReceipt, error) {
+	opts, err := bind.NewKeyedTransactorWithChainID(a.key, a.chainID)
+	if err != nil {
+		return AnchorReceipt{}, err
+	}
+	opts.Context = ctx
+
+	tx, err := a.contract.Transact(opts, "anchor", root,
+		new(big.Int).SetUint64(firstEntry), new(big.Int).SetUint64(lastEntry))
+	if err != nil && strings.Contains(err.Error(), "Root already anchored") {
+		// An earlier attempt was mined but its receipt never reached us
+		return a.findAnchored(ctx, root)
+	}
+	if err != nil {
+		return AnchorReceipt{}, err
+	}
+	receipt, err := bind.WaitMined(ctx, a.backend, tx)
+	if err != nil {
+		return AnchorReceipt{}, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return AnchorReceipt{}, fmt.Errorf("anchor transaction %s reverted", tx.Hash().Hex())
+	}
+	return AnchorReceipt{
+		ChainID:     a.chainID,
+		Contract:    a.Address,
+		TxHash:      tx.Hash(),
+		BlockNumber: receipt.BlockNumber.Uint64(),
+	}, nil
+}
+
+// findAnchored returns the receipt of the transaction that emitted Anchored for the root.
+func (a *ContractAnchorer) findAnchored(ctx context.Context, root [32]byte) (AnchorReceipt, error) {
+	logs, err := a.backend.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: big.NewInt(0),
+		Addresses: []common.Address{a.Address},
+		Topics:    [][]common.Hash{{a.abi.Events["Anchored"].ID}, {common.Hash(root)}},
+	})
+	if err != nil {
+		return AnchorReceipt{}, err
+	}
+	if len(logs) == 0 {
+		return AnchorReceipt{}, errors.New("root anchored but no Anchored log found")
+	}
+	return AnchorReceipt{
+		ChainID:     a.chainID,
+		Contract:    a.Address,
+		TxHash:      logs[0].TxHash,
+		BlockNumber: logs[0].BlockNumber,
+	}, nil
+}
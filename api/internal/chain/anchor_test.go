@@ -0,0 +1,187 @@
+package chain
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// auditAnchorCode returns the creation code of a stand-in for the AuditAnchor contract with the
+// same anchor function and Anchored event: it reverts with "Root already anchored" for a known
+// root, otherwise records the root and emits Anchored. The owner check is left out.
+func auditAnchorCode() []byte {
+	word := func(b []byte) []byte {
+		padded := make([]byte, 32)
+		copy(padded, b)
+		return padded
+	}
+	event := crypto.Keccak256([]byte("Anchored(bytes32,uint256,uint256)"))
+	errorSelector := crypto.Keccak256([]byte("Error(string)"))[:4]
+	reason := "Root already anchored"
+
+	runtime := []byte{
+		byte(vm.PUSH1), 0x04, byte(vm.CALLDATALOAD), // root
+		byte(vm.DUP1), byte(vm.SLOAD),
+		byte(vm.PUSH1), 0x00, byte(vm.JUMPI), // revert when anchored, patched below
+		byte(vm.PUSH1), 0x01, byte(vm.DUP2), byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x24, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 0x00, byte(vm.MSTORE), // firstEntry
+		byte(vm.PUSH1), 0x44, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 0x20, byte(vm.MSTORE), // lastEntry
+		byte(vm.PUSH32),
+	}
+	runtime = append(runtime, event...)
+	runtime = append(runtime, byte(vm.PUSH1), 0x40, byte(vm.PUSH1), 0x00, byte(vm.LOG2), byte(vm.STOP))
+
+	runtime[6] = byte(len(runtime))
+	runtime = append(runtime, byte(vm.JUMPDEST), byte(vm.PUSH32))
+	runtime = append(runtime, word(errorSelector)...)
+	runtime = append(runtime,
+		byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x04, byte(vm.MSTORE),
+		byte(vm.PUSH1), byte(len(reason)), byte(vm.PUSH1), 0x24, byte(vm.MSTORE),
+		byte(vm.PUSH32))
+	runtime = append(runtime, word([]byte(reason))...)
+	runtime = append(runtime,
+		byte(vm.PUSH1), 0x44, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x64, byte(vm.PUSH1), 0x00, byte(vm.REVERT))
+
+	creation := []byte{
+		byte(vm.PUSH1), byte(len(runtime)), byte(vm.DUP1),
+		byte(vm.PUSH1), 0x0b, byte(vm.PUSH1), 0x00, byte(vm.CODECOPY),
+		byte(vm.PUSH1), 0x00, byte(vm.RETURN),
+	}
+	return append(creation, runtime...)
+}
+
+// newTestAnchorer deploys the stand-in contract on a simulated chain that mines a block every few
+// milliseconds, as Anchor waits for its transaction to be mined.
+func newTestAnchorer(t *testing.T) (*ContractAnchorer, *backends.SimulatedBackend) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := backends.NewSimulatedBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1e18)},
+	}, 30_000_000)
+	t.Cleanup(func() { backend.Close() })
+
+	chainID, err := backend.ChainID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(key, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := abi.JSON(strings.NewReader(AuditAnchorABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, _, _, err := bind.DeployContract(opts, parsed, auditAnchorCode(), backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				backend.Commit()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+
+	anchorer, err := NewContractAnchorer(address, backend, key, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return anchorer, backend
+}
+
+func TestContractAnchorer(t *testing.T) {
+	anchorer, backend := newTestAnchorer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	first := crypto.Keccak256Hash([]byte("first batch"))
+	second := crypto.Keccak256Hash([]byte("second batch"))
+	receipts := make(map[string]AnchorReceipt)
+
+	tests := []struct {
+		name       string
+		root       common.Hash
+		firstEntry uint64
+		lastEntry  uint64
+		sameAs     string // Step whose receipt is returned again
+	}{
+		{"anchors a new root", first, 1, 10, ""},
+		{"returns the original receipt of an anchored root", first, 1, 10, "anchors a new root"},
+		{"anchors the next root", second, 11, 20, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt, err := anchorer.Anchor(ctx, tt.root, tt.firstEntry, tt.lastEntry)
+			if err != nil {
+				t.Fatalf("Anchor: %v", err)
+			}
+			receipts[tt.name] = receipt
+
+			if receipt.Contract != anchorer.Address || receipt.ChainID.Cmp(anchorer.chainID) != 0 {
+				t.Errorf("receipt is for %s on chain %s", receipt.Contract.Hex(), receipt.ChainID)
+			}
+			if tt.sameAs != "" {
+				if want := receipts[tt.sameAs]; receipt.TxHash != want.TxHash || receipt.BlockNumber != want.BlockNumber {
+					t.Errorf("receipt = %+v, want %+v", receipt, want)
+				}
+				return
+			}
+
+			mined, err := backend.TransactionReceipt(ctx, receipt.TxHash)
+			if err != nil {
+				t.Fatalf("TransactionReceipt: %v", err)
+			}
+			if mined.BlockNumber.Uint64() != receipt.BlockNumber {
+				t.Errorf("block = %d, mined in %d", receipt.BlockNumber, mined.BlockNumber)
+			}
+			if len(mined.Logs) != 1 || mined.Logs[0].Topics[1] != tt.root {
+				t.Fatalf("transaction did not emit Anchored for the root: %+v", mined.Logs)
+			}
+			entries, err := anchorer.abi.Unpack("Anchored", mined.Logs[0].Data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entries[0].(*big.Int).Uint64() != tt.firstEntry || entries[1].(*big.Int).Uint64() != tt.lastEntry {
+				t.Errorf("Anchored entries = %v, want %d..%d", entries, tt.firstEntry, tt.lastEntry)
+			}
+		})
+	}
+
+	logs, err := backend.FilterLogs(ctx, ethereum.FilterQuery{Addresses: []common.Address{anchorer.Address}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 {
+		t.Errorf("got %d Anchored logs, want one per root", len(logs))
+	}
+}
@@ -0,0 +1,201 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"strings"
+)
+
+// NFTCMSABI is the subset of the NFTCMS contract ABI the API reads.
+const NFTCMSABI = `[
+	{"type":"function","name":"credentials","stateMutability":"view","inputs":[{"name":"","type":"uint256"}],"outputs":[{"name":"tokenId","type":"uint256"},{"name":"ipfsURI","type":"string"},{"name":"status","type":"uint8"},{"name":"signature","type":"bytes"},{"name":"signer","type":"address"}]},
+	{"type":"function","name":"hasRole","stateMutability":"view","inputs":[{"name":"role","type":"bytes32"},{"name":"account","type":"address"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"ownerOf","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"tokenURI","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"verifyCredential","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"},{"name":"hash","type":"bytes32"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"event","name":"CredentialIssued","anonymous":false,"inputs":[{"name":"tokenId","type":"uint256","indexed":true},{"name":"student","type":"address","indexed":true},{"name":"institution","type":"address","indexed":true}]},
+	{"type":"event","name":"CredentialStatusChanged","anonymous":false,"inputs":[{"name":"tokenId","type":"uint256","indexed":true},{"name":"previousStatus","type":"uint8","indexed":false},{"name":"newStatus","type":"uint8","indexed":false},{"name":"reason","type":"string","indexed":false}]}
+]`
+
+// InstitutionRole is NFTCMS.INSTITUTION_ROLE, held by wallets allowed to issue credentials.
+var InstitutionRole = crypto.Keccak256Hash([]byte("INSTITUTION_ROLE"))
+
+// CredentialStatus mirrors NFTCMS.CredentialStatus.
+type CredentialStatus uint8
+
+const (
+	CredentialValid   CredentialStatus = 0
+	CredentialRevoked CredentialStatus = 1
+)
+
+func (s CredentialStatus) String() string {
+	switch s {
+	case CredentialValid:
+		return "valid"
+	case CredentialRevoked:
+		return "revoked"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(s))
+}
+
+// Credential mirrors the NFTCMS.Credential struct.
+type Credential struct {
+	TokenID   *big.Int
+	IPFSURI   string
+	Status    CredentialStatus
+	Signature []byte
+	Signer    common.Address
+}
+
+// StatusChanged is a decoded CredentialStatusChanged log.
+type StatusChanged struct {
+	TokenID        *big.Int
+	PreviousStatus CredentialStatus
+	NewStatus      CredentialStatus
+	Reason         string
+	BlockNumber    uint64
+	TxHash         common.Hash
+	LogIndex       uint
+}
+
+// NFTCMS is a read-only binding to the deployed credential contract.
+type NFTCMS struct {
+	Address  common.Address
+	backend  bind.ContractBackend
+	abi      abi.ABI
+	contract *bind.BoundContract
+	block    *big.Int // Block calls are made at, the latest when nil
+}
+
+func NewNFTCMS(address common.Address, backend bind.ContractBackend) (*NFTCMS, error) {
+	parsed, err := abi.JSON(strings.NewReader(NFTCMSABI))
+	if err != nil {
+		return nil, err
+	}
+	return &NFTCMS{
+		Address:  address,
+		backend:  backend,
+		abi:      parsed,
+		contract: bind.NewBoundContract(address, parsed, backend, backend, backend),
+	}, nil
+}
+
+// At returns a binding whose calls read the state as of the block, so several calls see the same
+// state.
+func (n *NFTCMS) At(block uint64) *NFTCMS {
+	at := *n
+	at.block = new(big.Int).SetUint64(block)
+	return &at
+}
+
+func (n *NFTCMS) call(ctx context.Context, method string, args ...interface{}) ([]interface{}, error) {
+	var out []interface{}
+	err := n.contract.Call(&bind.CallOpts{Context: ctx, BlockNumber: n.block}, &out, method, args...)
+	return out, err
+}
+
+// Credential returns credentials[tokenId]; an unminted token has a zero TokenID.
+func (n *NFTCMS) Credential(ctx context.Context, tokenID *big.Int) (Credential, error) {
+	out, err := n.call(ctx, "credentials", tokenID)
+	if err != nil {
+		return Credential{}, err
+	}
+	return Credential{
+		TokenID:   *abi.ConvertType(out[0], new(*big.Int)).(**big.Int),
+		IPFSURI:   *abi.ConvertType(out[1], new(string)).(*string),
+		Status:    CredentialStatus(*abi.ConvertType(out[2], new(uint8)).(*uint8)),
+		Signature: *abi.ConvertType(out[3], new([]byte)).(*[]byte),
+		Signer:    *abi.ConvertType(out[4], new(common.Address)).(*common.Address),
+	}, nil
+}
+
+// OwnerOf returns the current holder of the token.
+func (n *NFTCMS) OwnerOf(ctx context.Context, tokenID *big.Int) (common.Address, error) {
+	out, err := n.call(ctx, "ownerOf", tokenID)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return *abi.ConvertType(out[0], new(common.Address)).(*common.Address), nil
+}
+
+// VerifyCredential reports whether the credential's signature is the signer's signature over
+// hash. It reverts for tokens that were never minted.
+func (n *NFTCMS) VerifyCredential(ctx context.Context, tokenID *big.Int, hash common.Hash) (bool, error) {
+	out, err := n.call(ctx, "verifyCredential", tokenID, hash)
+	if err != nil {
+		return false, err
+	}
+	return *abi.ConvertType(out[0], new(bool)).(*bool), nil
+}
+
+// HasRole reports whether the account holds the role.
+func (n *NFTCMS) HasRole(ctx context.Context, role common.Hash, account common.Address) (bool, error) {
+	out, err := n.call(ctx, "hasRole", role, account)
+	if err != nil {
+		return false, err
+	}
+	return *abi.ConvertType(out[0], new(bool)).(*bool), nil
+}
+
+// BlockNumber returns the latest block known to the backend.
+func (n *NFTCMS) BlockNumber(ctx context.Context) (uint64, error) {
+	header, err := n.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return header.Number.Uint64(), nil
+}
+
+// FilterStatusChanged returns the CredentialStatusChanged logs in [from, to].
+func (n *NFTCMS) FilterStatusChanged(ctx context.Context, from, to uint64) ([]StatusChanged, error) {
+	event := n.abi.Events["CredentialStatusChanged"]
+	logs, err := n.backend.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{n.Address},
+		Topics:    [][]common.Hash{{event.ID}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]StatusChanged, 0, len(logs))
+	for _, log := range logs {
+		change, err := n.parseStatusChanged(log)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (n *NFTCMS) parseStatusChanged(log types.Log) (StatusChanged, error) {
+	if len(log.Topics) != 2 {
+		return StatusChanged{}, fmt.Errorf("unexpected CredentialStatusChanged topics: %d", len(log.Topics))
+	}
+	var data struct {
+		PreviousStatus uint8
+		NewStatus      uint8
+		Reason         string
+	}
+	if err := n.abi.UnpackIntoInterface(&data, "CredentialStatusChanged", log.Data); err != nil {
+		return StatusChanged{}, err
+	}
+	return StatusChanged{
+		TokenID:        log.Topics[1].Big(),
+		PreviousStatus: CredentialStatus(data.PreviousStatus),
+		NewStatus:      CredentialStatus(data.NewStatus),
+		Reason:         data.Reason,
+		BlockNumber:    log.BlockNumber,
+		TxHash:         log.TxHash,
+		LogIndex:       log.Index,
+	}, nil
+}
@@ -16,25 +16,72 @@ func (e *ApiError) ErrorWithAdditionalMessage(additionalMessage string) string {
 }
 
 var (
+	ErrAccessDenied           = &ApiError{Status: http.StatusForbidden, Message: "Access denied"}
+	ErrAuditEntryNotFound     = &ApiError{Status: http.StatusNotFound, Message: "Audit entry not found"}
+	ErrChainDisabled          = &ApiError{Status: http.StatusServiceUnavailable, Message: "On-chain features are not configured"}
+	ErrContentIntegrity       = &ApiError{Status: http.StatusBadGateway, Message: "Content from IPFS does not match its CID"}
+	ErrCredentialMetadata     = &ApiError{Status: http.StatusUnprocessableEntity, Message: "Credential metadata is missing or invalid"}
+	ErrCredentialNotFound     = &ApiError{Status: http.StatusNotFound, Message: "Credential not found"}
+	ErrCredentialNotSigned    = &ApiError{Status: http.StatusNotFound, Message: "The issuer has not signed a verifiable credential for this credential yet"}
+	ErrContentNotFound        = &ApiError{Status: http.StatusNotFound, Message: "Content not found"}
+	ErrDocumentKeyNotFound    = &ApiError{Status: http.StatusNotFound, Message: "No encryption key for this content"}
+	ErrEmailNotFound          = &ApiError{Status: http.StatusNotFound, Message: "No notification email set for this wallet"}
+	ErrEncryptionDisabled     = &ApiError{Status: http.StatusServiceUnavailable, Message: "Document encryption is not configured"}
 	ErrFailedToConvertJSON    = &ApiError{Status: http.StatusInternalServerError, Message: "Failed to convert transcript list to JSON"}
 	ErrFailedToCreateNonce    = &ApiError{Status: http.StatusInternalServerError, Message: "Failed to create nonce"}
 	ErrFailedToCreateSession  = &ApiError{Status: http.StatusInternalServerError, Message: "Failed to create session"}
+	ErrFailedToSendEmail      = &ApiError{Status: http.StatusBadGateway, Message: "Failed to send email"}
 	ErrFailedToSaveRequest    = &ApiError{Status: http.StatusInternalServerError, Message: "Failed to save request"}
+	ErrIdempotencyKeyInUse    = &ApiError{Status: http.StatusConflict, Message: "A request with this Idempotency-Key is still being processed"}
+	ErrIdempotencyKeyReused   = &ApiError{Status: http.StatusUnprocessableEntity, Message: "Idempotency-Key was already used with a different request"}
+	ErrIPFSDisabled           = &ApiError{Status: http.StatusServiceUnavailable, Message: "IPFS is not configured"}
+	ErrIPFSUnavailable        = &ApiError{Status: http.StatusBadGateway, Message: "Failed to fetch content from IPFS"}
 	ErrInsufficientData       = &ApiError{Status: http.StatusBadRequest, Message: "Insufficient data"}
 	ErrInsufficientHeaders    = &ApiError{Status: http.StatusBadRequest, Message: "Insufficient headers"}
 	ErrInternalServer         = &ApiError{Status: http.StatusInternalServerError, Message: "Internal server error"}
+	ErrInvalidCID             = &ApiError{Status: http.StatusBadRequest, Message: "Invalid CID"}
+	ErrInvalidCursor          = &ApiError{Status: http.StatusBadRequest, Message: "Invalid cursor"}
 	ErrInvalidData            = &ApiError{Status: http.StatusBadRequest, Message: "Invalid data"}
+	ErrInvalidDateRange       = &ApiError{Status: http.StatusBadRequest, Message: "Invalid date range"}
+	ErrInvalidETag            = &ApiError{Status: http.StatusBadRequest, Message: "Invalid If-Match header"}
+	ErrInvalidEventType       = &ApiError{Status: http.StatusBadRequest, Message: "Invalid event type"}
+	ErrInvalidHash            = &ApiError{Status: http.StatusBadRequest, Message: "Invalid hash, expected a 0x prefixed 32 byte hex string"}
+	ErrInvalidIdempotencyKey  = &ApiError{Status: http.StatusBadRequest, Message: "Invalid Idempotency-Key header"}
+	ErrInvalidIPFSURI         = &ApiError{Status: http.StatusBadRequest, Message: "Invalid IPFS URI, expected a CID, ipfs:// URI or gateway URL"}
+	ErrInvalidLastEventID     = &ApiError{Status: http.StatusBadRequest, Message: "Invalid Last-Event-ID"}
+	ErrInvalidRole            = &ApiError{Status: http.StatusBadRequest, Message: "Invalid input. Ensure 'role' is either 'student' or 'recipient'"}
+	ErrInvalidMsgSignature    = &ApiError{Status: http.StatusBadRequest, Message: "Message signature does not match the sender wallet"}
 	ErrInvalidSessionToken    = &ApiError{Status: http.StatusUnauthorized, Message: "Invalid session token"}
 	ErrInvalidSignatureFormat = &ApiError{Status: http.StatusBadRequest, Message: "Invalid signature format"}
 	ErrInvalidSignature       = &ApiError{Status: http.StatusBadRequest, Message: "Invalid signature "}
 	ErrInvalidSignatureLength = &ApiError{Status: http.StatusBadRequest, Message: "Invalid signature length"}
 	ErrInvalidRecoveryID      = &ApiError{Status: http.StatusBadRequest, Message: "Invalid signature recovery id"}
-	ErrInvalidWalletType      = &ApiError{Status: http.StatusBadRequest, Message: "Invalid input. Ensure 'wallet_type' is either 'student_wallet' or 'recipient_wallet'"}
+	ErrInvalidStreamTicket    = &ApiError{Status: http.StatusUnauthorized, Message: "Invalid or expired stream ticket"}
+	ErrInvalidSortOrder       = &ApiError{Status: http.StatusBadRequest, Message: "Invalid sort order"}
+	ErrInvalidTokenID         = &ApiError{Status: http.StatusBadRequest, Message: "Invalid token ID"}
+	ErrInvalidStatusFilter    = &ApiError{Status: http.StatusBadRequest, Message: "Invalid status filter"}
+	ErrInvalidSubscription    = &ApiError{Status: http.StatusBadRequest, Message: "Invalid push subscription"}
+	ErrInvalidVerifyToken     = &ApiError{Status: http.StatusBadRequest, Message: "Invalid or expired verification link"}
+	ErrInvalidWebhookURL      = &ApiError{Status: http.StatusBadRequest, Message: "Invalid webhook URL, expected an https URL on a public host"}
 	ErrNoWalletAddressHeader  = &ApiError{Status: http.StatusBadRequest, Message: "No Wallet-Address Header Found"}
+	ErrPresentationNotFound   = &ApiError{Status: http.StatusNotFound, Message: "The student has not signed a presentation for this request yet"}
+	ErrPresentationPending    = &ApiError{Status: http.StatusUnprocessableEntity, Message: "Every shared credential needs a verifiable credential signed by its issuer first"}
 	ErrPublicKeyRecovery      = &ApiError{Status: http.StatusFailedDependency, Message: "Error recovering public key"}
+	ErrPushDisabled           = &ApiError{Status: http.StatusServiceUnavailable, Message: "Web Push is not configured"}
+	ErrRequestExpired         = &ApiError{Status: http.StatusConflict, Message: "Request has expired"}
 	ErrRequestNotApproved     = &ApiError{Status: http.StatusUnprocessableEntity, Message: "Request is not in an approved state"}
 	ErrRequestNotFound        = &ApiError{Status: http.StatusUnprocessableEntity, Message: "Request not found"}
 	ErrRequestNotPending      = &ApiError{Status: http.StatusUnprocessableEntity, Message: "Request is not in a pending state"}
+	ErrRequestTooLarge        = &ApiError{Status: http.StatusRequestEntityTooLarge, Message: "Request body is too large"}
+	ErrRequestVersionConflict = &ApiError{Status: http.StatusConflict, Message: "Request was modified by another response, fetch it again and retry"}
+	ErrStatusListNotFound     = &ApiError{Status: http.StatusNotFound, Message: "Status list not found"}
+	ErrSubscriptionConflict   = &ApiError{Status: http.StatusConflict, Message: "Push endpoint is registered to another wallet"}
+	ErrSubscriptionNotFound   = &ApiError{Status: http.StatusNotFound, Message: "Push subscription not found"}
+	ErrTooManyRequests        = &ApiError{Status: http.StatusTooManyRequests, Message: "Too many requests, try again later"}
 	ErrUnprocessableEntity    = &ApiError{Status: http.StatusUnprocessableEntity, Message: "Unprocessable entity"}
 	ErrUnauthorizedTranscript = &ApiError{Status: http.StatusUnauthorized, Message: "Unauthorized to access this transcript"}
+	ErrUnsupportedMediaType   = &ApiError{Status: http.StatusUnsupportedMediaType, Message: "File type is not allowed, upload a PDF or an image"}
+	ErrUploadFailed           = &ApiError{Status: http.StatusBadGateway, Message: "Failed to store the file on IPFS"}
+	ErrVerificationFailed     = &ApiError{Status: http.StatusBadGateway, Message: "Failed to verify the credential, try again later"}
+	ErrWebhookNotFound        = &ApiError{Status: http.StatusNotFound, Message: "Webhook not found"}
 )
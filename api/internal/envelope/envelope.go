@@ -0,0 +1,95 @@
+package envelope
+
+import (
+	"api/internal/kms"
+	"api/internal/models"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	"gorm.io/gorm"
+)
+
+// Algorithm is how documents are encrypted: AES-256-GCM with a random 12 byte nonce prepended
+// to the ciphertext.
+const Algorithm = "AES-256-GCM"
+
+const dataKeySize = 32
+
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
+// Seal encrypts a document under a fresh data key and returns the ciphertext along with the data
+// key wrapped by the KMS. The plaintext data key is discarded.
+func Seal(ctx context.Context, k kms.KMS, plaintext []byte) ([]byte, kms.WrappedKey, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, kms.WrappedKey{}, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, kms.WrappedKey{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, kms.WrappedKey{}, err
+	}
+	ciphertext := aead.Seal(nonce, nonce, plaintext, nil)
+
+	wrapped, err := k.Wrap(ctx, dataKey)
+	if err != nil {
+		return nil, kms.WrappedKey{}, err
+	}
+	return ciphertext, wrapped, nil
+}
+
+// Open decrypts a document sealed by Seal with its unwrapped data key.
+func Open(ciphertext, dataKey []byte) ([]byte, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrCiphertextTooShort
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, nil)
+}
+
+func newAEAD(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// WrapForRecipient encrypts a data key to a wallet's secp256k1 public key using go-ethereum's
+// ECIES (ECDH, AES-128-CTR and HMAC-SHA-256), so only that wallet's private key can read it.
+func WrapForRecipient(dataKey []byte, publicKey *ecdsa.PublicKey) ([]byte, error) {
+	return ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(publicKey), dataKey, nil, nil)
+}
+
+// StoreKey records the wrapped data key of the encrypted document at cid.
+func StoreKey(db *gorm.DB, cid, ownerWallet string, wrapped kms.WrappedKey) error {
+	return db.Create(&models.DocumentKey{
+		CID:         cid,
+		Algorithm:   Algorithm,
+		KMSKeyID:    wrapped.KeyID,
+		WrappedKey:  wrapped.Ciphertext,
+		OwnerWallet: ownerWallet,
+	}).Error
+}
+
+// ReleaseKey returns the plaintext data key of the encrypted document at cid. Callers must have
+// checked that the wallet asking for it may read the document.
+func ReleaseKey(ctx context.Context, db *gorm.DB, k kms.KMS, cid string) (models.DocumentKey, []byte, error) {
+	var documentKey models.DocumentKey
+	if err := db.First(&documentKey, "cid = ?", cid).Error; err != nil {
+		return documentKey, nil, err
+	}
+	dataKey, err := k.Unwrap(ctx, kms.WrappedKey{KeyID: documentKey.KMSKeyID, Ciphertext: documentKey.WrappedKey})
+	return documentKey, dataKey, err
+}
@@ -0,0 +1,83 @@
+package envelope
+
+import (
+	"api/internal/kms"
+	"bytes"
+	"context"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	"path/filepath"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	ctx := context.Background()
+	local, err := kms.NewLocalKMS(filepath.Join(t.TempDir(), "master.key"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		plaintext []byte
+	}{
+		{"empty document", []byte{}},
+		{"small document", []byte("transcript")},
+		{"large document", bytes.Repeat([]byte("transcript "), 100_000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, wrapped, err := Seal(ctx, local, tt.plaintext)
+			if err != nil {
+				t.Fatalf("Seal: %v", err)
+			}
+			if len(tt.plaintext) > 0 && bytes.Contains(ciphertext, tt.plaintext) {
+				t.Fatal("ciphertext contains the plaintext")
+			}
+			dataKey, err := local.Unwrap(ctx, wrapped)
+			if err != nil {
+				t.Fatalf("Unwrap: %v", err)
+			}
+			opened, err := Open(ciphertext, dataKey)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if !bytes.Equal(opened, tt.plaintext) {
+				t.Error("opened document differs from the sealed one")
+			}
+
+			tampered := append([]byte(nil), ciphertext...)
+			tampered[len(tampered)-1] ^= 1
+			if _, err := Open(tampered, dataKey); err == nil {
+				t.Error("tampered ciphertext opened")
+			}
+			if _, err := Open(ciphertext[:4], dataKey); err != ErrCiphertextTooShort {
+				t.Errorf("truncated ciphertext: err = %v", err)
+			}
+		})
+	}
+}
+
+func TestWrapForRecipient(t *testing.T) {
+	recipient, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataKey := bytes.Repeat([]byte{9}, dataKeySize)
+
+	wrapped, err := WrapForRecipient(dataKey, &recipient.PublicKey)
+	if err != nil {
+		t.Fatalf("WrapForRecipient: %v", err)
+	}
+	unwrapped, err := ecies.ImportECDSA(recipient).Decrypt(wrapped, nil, nil)
+	if err != nil || !bytes.Equal(unwrapped, dataKey) {
+		t.Fatalf("recipient could not decrypt: %v", err)
+	}
+	if _, err := ecies.ImportECDSA(other).Decrypt(wrapped, nil, nil); err == nil {
+		t.Error("another wallet decrypted the data key")
+	}
+}
@@ -0,0 +1,51 @@
+package initializers
+
+import (
+	"api/internal/chain"
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+var AuditAnchorer chain.Anchorer
+
+// InitAnchor sets up where audit log roots are anchored: the AuditAnchor contract, when
+// ETH_RPC_URL, AUDIT_ANCHOR_CONTRACT_ADDRESS and AUDIT_ANCHOR_PRIVATE_KEY are set. For local
+// development point them at a dev chain such as a Hardhat node.
+func InitAnchor() {
+	rpcURL := os.Getenv("ETH_RPC_URL")
+	contractAddress := os.Getenv("AUDIT_ANCHOR_CONTRACT_ADDRESS")
+	privateKey := os.Getenv("AUDIT_ANCHOR_PRIVATE_KEY")
+	if rpcURL == "" || contractAddress == "" || privateKey == "" {
+		log.Println("AUDIT_ANCHOR_CONTRACT_ADDRESS or AUDIT_ANCHOR_PRIVATE_KEY not set, audit log anchoring is disabled")
+		return
+	}
+	if !common.IsHexAddress(contractAddress) {
+		log.Fatalf("Invalid AUDIT_ANCHOR_CONTRACT_ADDRESS: %s", contractAddress)
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil {
+		log.Fatalf("Invalid AUDIT_ANCHOR_PRIVATE_KEY: %v", err)
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to the chain: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		log.Fatalf("Failed to get the chain ID: %v", err)
+	}
+
+	AuditAnchorer, err = chain.NewContractAnchorer(common.HexToAddress(contractAddress), client, key, chainID)
+	if err != nil {
+		log.Fatalf("Failed to bind the AuditAnchor contract: %v", err)
+	}
+}
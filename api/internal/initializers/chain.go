@@ -0,0 +1,46 @@
+package initializers
+
+import (
+	"api/internal/chain"
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"log"
+	"math/big"
+	"os"
+	"time"
+)
+
+var (
+	NFTCMS  *chain.NFTCMS
+	ChainID *big.Int // Chain the NFTCMS contract is deployed on
+)
+
+func InitChain() {
+	rpcURL := os.Getenv("ETH_RPC_URL")
+	contractAddress := os.Getenv("NFTCMS_CONTRACT_ADDRESS")
+	if rpcURL == "" || contractAddress == "" {
+		log.Println("ETH_RPC_URL or NFTCMS_CONTRACT_ADDRESS not set, on-chain features are disabled")
+		return
+	}
+	if !common.IsHexAddress(contractAddress) {
+		log.Fatalf("Invalid NFTCMS_CONTRACT_ADDRESS: %s", contractAddress)
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to the chain: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ChainID, err = client.ChainID(ctx)
+	if err != nil {
+		log.Fatalf("Failed to fetch the chain ID: %v", err)
+	}
+
+	NFTCMS, err = chain.NewNFTCMS(common.HexToAddress(contractAddress), client)
+	if err != nil {
+		log.Fatalf("Failed to bind the NFTCMS contract: %v", err)
+	}
+}
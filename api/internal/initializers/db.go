@@ -1,6 +1,7 @@
 package initializers
 
 import (
+	"api/internal/ipfsuri"
 	"api/internal/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -17,11 +18,64 @@ func InitDB() {
 		log.Fatalf("Failed to connect to the database: %v", err)
 	}
 
-	// Auto-migrate the Request model
-	err = db.AutoMigrate(&models.Request{}, &models.RequestTranscript{}, &models.Transcript{})
+	// Auto-migrate the models
+	err = db.AutoMigrate(
+		&models.Request{},
+		&models.RequestTranscript{},
+		&models.Transcript{},
+		&models.RequestView{},
+		&models.RequestEvent{},
+		&models.RequestMessage{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.ChainCursor{},
+		&models.WalletEmail{},
+		&models.EmailNotification{},
+		&models.PushSubscription{},
+		&models.VAPIDKeyRecord{},
+		&models.OutboxEvent{},
+		&models.AuditEntry{},
+		&models.AuditAnchor{},
+		&models.AuditProof{},
+		&models.DocumentKey{},
+		&models.Pin{},
+		&models.TranscriptMetadata{},
+		&models.ReceiptKey{},
+		&models.VerificationReceipt{},
+		&models.Timestamp{},
+		&models.VerifiableCredential{},
+		&models.Presentation{},
+		&models.StatusList{},
+		&models.StatusListEntry{},
+	)
 	if err != nil {
 		log.Fatalf("Failed to migrate models: %v", err)
 	}
+	normalizeTranscriptURIs(db)
 
 	DB = db
 }
+
+// normalizeTranscriptURIs rewrites transcript URIs stored before they were kept in canonical
+// form. Rows whose URIs cannot be parsed, or whose canonical URI is already taken by another
+// row, are left as they are and logged.
+func normalizeTranscriptURIs(db *gorm.DB) {
+	var transcripts []models.Transcript
+	if err := db.Find(&transcripts).Error; err != nil {
+		log.Fatalf("Failed to load transcripts: %v", err)
+	}
+	for _, transcript := range transcripts {
+		metadata, err := ipfsuri.Normalize(transcript.IPFSURIMetadata)
+		if err == nil {
+			var mediaHash string
+			mediaHash, err = ipfsuri.Normalize(transcript.IPFSURIMediaHash)
+			unchanged := metadata == transcript.IPFSURIMetadata && mediaHash == transcript.IPFSURIMediaHash
+			if err == nil && !unchanged {
+				err = db.Model(&transcript).Updates(models.Transcript{IPFSURIMetadata: metadata, IPFSURIMediaHash: mediaHash}).Error
+			}
+		}
+		if err != nil {
+			log.Printf("Failed to normalize the IPFS URIs of transcript %s: %v", transcript.TranscriptID, err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package initializers
+
+import (
+	"api/internal/ipfs"
+	"api/internal/pinning"
+	"log"
+	"os"
+)
+
+var IPFS *ipfs.Client
+
+var Pinner pinning.Pinner
+
+// InitIPFS sets up the IPFS node transcript content is proxied from and uploads are written
+// to, and the pinning backend that keeps uploads available. PINNING_BACKEND selects the
+// backend; only "node", pinning on the IPFS node itself, ships today and is the default.
+func InitIPFS() {
+	apiURL := os.Getenv("IPFS_API_URL")
+	if apiURL == "" {
+		log.Println("IPFS_API_URL not set, the content proxy and uploads are disabled")
+		return
+	}
+	IPFS = ipfs.NewClient(apiURL)
+
+	switch backend := os.Getenv("PINNING_BACKEND"); backend {
+	case "", "node":
+		Pinner = &pinning.NodePinner{IPFS: IPFS}
+	default:
+		log.Fatalf("Unknown PINNING_BACKEND: %s", backend)
+	}
+}
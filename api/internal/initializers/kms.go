@@ -0,0 +1,24 @@
+package initializers
+
+import (
+	"api/internal/kms"
+	"log"
+	"os"
+)
+
+var KMS kms.KMS
+
+// InitKMS sets up the KMS wrapping document data keys and receipt signing keys. Only the file based LocalKMS ships
+// today, selected with KMS_KEY_FILE. The key file must exist unless KMS_GENERATE_KEY=true.
+func InitKMS() {
+	keyFile := os.Getenv("KMS_KEY_FILE")
+	if keyFile == "" {
+		log.Println("KMS_KEY_FILE not set, document encryption is disabled")
+		return
+	}
+	local, err := kms.NewLocalKMS(keyFile, os.Getenv("KMS_GENERATE_KEY") == "true")
+	if err != nil {
+		log.Fatalf("Failed to load the KMS key: %v", err)
+	}
+	KMS = local
+}
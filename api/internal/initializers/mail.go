@@ -0,0 +1,52 @@
+package initializers
+
+import (
+	"api/internal/mail"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"strconv"
+)
+
+var Mailer mail.Transport
+
+// InitMail sets up the transport emails are sent with. With SMTP_HOST set emails are delivered,
+// which requires EMAIL_LINK_SECRET to sign verification links; without it emails are kept in
+// memory and links are signed with a per-process secret.
+func InitMail() {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		log.Println("SMTP_HOST not set, emails are kept in memory and not delivered")
+		if os.Getenv("EMAIL_LINK_SECRET") == "" {
+			// Links signed with a per-process secret stop working after a restart
+			secret := make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				log.Fatalf("Failed to generate email link secret: %v", err)
+			}
+			os.Setenv("EMAIL_LINK_SECRET", hex.EncodeToString(secret))
+		}
+		Mailer = mail.NewMemoryTransport()
+		return
+	}
+	if os.Getenv("EMAIL_LINK_SECRET") == "" {
+		log.Fatalf("EMAIL_LINK_SECRET must be set when SMTP_HOST is set")
+	}
+
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil {
+		port = 587
+	}
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		log.Fatalf("MAIL_FROM must be set when SMTP_HOST is set")
+	}
+
+	Mailer = &mail.SMTPTransport{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	}
+}
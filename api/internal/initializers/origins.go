@@ -0,0 +1,22 @@
+package initializers
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+var ClientOrigins []string
+
+// InitClientOrigins reads CLIENT_ORIGINS, the comma separated origins of the web clients allowed
+// to open WebSocket streams, e.g. https://app.example.com. Without it only same-origin pages may.
+func InitClientOrigins() {
+	for _, origin := range strings.Split(os.Getenv("CLIENT_ORIGINS"), ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			ClientOrigins = append(ClientOrigins, origin)
+		}
+	}
+	if len(ClientOrigins) == 0 {
+		log.Println("CLIENT_ORIGINS not set, WebSocket streams only accept same-origin pages")
+	}
+}
@@ -0,0 +1,48 @@
+package initializers
+
+import (
+	"api/internal/pinning"
+	"log"
+	"os"
+	"strings"
+)
+
+var PinningServices []pinning.Service
+
+var PinningOrigins []string
+
+// InitPinning sets up the pinning services transcript files are kept at. PINNING_SERVICES is a
+// comma separated list of names, each configured with PINNING_SERVICE_<NAME>_ENDPOINT and
+// PINNING_SERVICE_<NAME>_TOKEN; the name "local" is an in-memory stand-in pinning on the IPFS
+// node, for development. It must run after InitIPFS.
+func InitPinning() {
+	names := os.Getenv("PINNING_SERVICES")
+	if names == "" {
+		log.Println("PINNING_SERVICES not set, transcript files are not pinned at pinning services")
+		return
+	}
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == "local" {
+			PinningServices = append(PinningServices, pinning.NewLocalService(name, IPFS))
+			continue
+		}
+
+		prefix := "PINNING_SERVICE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		endpoint, token := os.Getenv(prefix+"_ENDPOINT"), os.Getenv(prefix+"_TOKEN")
+		if endpoint == "" || token == "" {
+			log.Fatalf("%s_ENDPOINT and %s_TOKEN must be set for pinning service %s", prefix, prefix, name)
+		}
+		PinningServices = append(PinningServices, pinning.NewServiceClient(name, endpoint, token))
+	}
+
+	for _, origin := range strings.Split(os.Getenv("PINNING_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			PinningOrigins = append(PinningOrigins, origin)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package initializers
+
+import (
+	"github.com/gin-gonic/gin"
+	"log"
+	"os"
+	"strings"
+)
+
+// ConfigureProxies sets which proxies the router takes the client IP from, used by rate limits
+// and the audit log. By default no proxy is trusted and the client IP is the peer address.
+// TRUSTED_PROXIES lists the IPs or CIDRs of reverse proxies whose X-Forwarded-For is believed;
+// TRUSTED_PLATFORM names a header set by the hosting platform instead, "cloudflare", "google" or
+// any header name.
+func ConfigureProxies(router *gin.Engine) {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	switch platform := os.Getenv("TRUSTED_PLATFORM"); platform {
+	case "":
+	case "cloudflare":
+		router.TrustedPlatform = gin.PlatformCloudflare
+	case "google":
+		router.TrustedPlatform = gin.PlatformGoogleAppEngine
+	default:
+		router.TrustedPlatform = platform
+	}
+}
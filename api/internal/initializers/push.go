@@ -0,0 +1,30 @@
+package initializers
+
+import (
+	"api/internal/push"
+	"context"
+	"log"
+	"os"
+)
+
+var VAPIDKey *push.VAPIDKey
+
+// InitPush loads the Web Push VAPID key. It must run after InitDB and InitKMS, which wraps the
+// stored key when VAPID_PRIVATE_KEY is not set.
+func InitPush() {
+	subject := os.Getenv("VAPID_SUBJECT")
+	if subject == "" {
+		log.Println("VAPID_SUBJECT not set, Web Push notifications are disabled")
+		return
+	}
+
+	configured := os.Getenv("VAPID_PRIVATE_KEY")
+	if configured == "" && KMS == nil {
+		log.Fatalf("KMS_KEY_FILE must be set unless VAPID_PRIVATE_KEY is, the generated VAPID key is stored wrapped by the KMS")
+	}
+	key, err := push.LoadVAPIDKey(context.Background(), DB, KMS, configured, subject)
+	if err != nil {
+		log.Fatalf("Failed to load VAPID key: %v", err)
+	}
+	VAPIDKey = key
+}
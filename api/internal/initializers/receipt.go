@@ -0,0 +1,32 @@
+package initializers
+
+import (
+	"api/internal/receipt"
+	"context"
+	"log"
+	"os"
+)
+
+var (
+	ReceiptKeys   *receipt.Keys
+	ReceiptIssuer string // iss of verification receipts
+)
+
+// InitReceipts loads the keys verification receipts are signed with. It must run after InitDB and
+// InitKMS, which wraps the stored keys. RECEIPT_SIGNING_KEY rotates the signing key; keys it
+// replaces stay published.
+func InitReceipts() {
+	if KMS == nil {
+		log.Fatalf("KMS_KEY_FILE must be set, receipt signing keys are stored wrapped by the KMS")
+	}
+	keys, err := receipt.LoadKeys(context.Background(), DB, KMS, os.Getenv("RECEIPT_SIGNING_KEY"))
+	if err != nil {
+		log.Fatalf("Failed to load receipt signing keys: %v", err)
+	}
+	ReceiptKeys = keys
+
+	ReceiptIssuer = os.Getenv("RECEIPT_ISSUER")
+	if ReceiptIssuer == "" {
+		ReceiptIssuer = "nft-cms-api"
+	}
+}
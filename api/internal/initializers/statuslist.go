@@ -0,0 +1,21 @@
+package initializers
+
+import (
+	"api/internal/statuslist"
+	"log"
+	"os"
+	"strings"
+)
+
+var StatusLists *statuslist.Lists
+
+// InitStatusLists sets up the revocation status lists. They are signed with the receipt signing
+// key, so it must run after InitReceipts. API_PUBLIC_URL is the issuer of the lists and the base
+// of their URLs, which are signed into every credential and must be absolute.
+func InitStatusLists() {
+	baseURL := strings.TrimRight(os.Getenv("API_PUBLIC_URL"), "/")
+	if baseURL == "" {
+		log.Fatalf("API_PUBLIC_URL must be set, credentials point verifiers at status lists under it")
+	}
+	StatusLists = &statuslist.Lists{DB: DB, Key: ReceiptKeys.Signer, BaseURL: baseURL}
+}
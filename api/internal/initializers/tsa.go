@@ -0,0 +1,36 @@
+package initializers
+
+import (
+	"api/internal/tsa"
+	"log"
+	"net/url"
+	"os"
+)
+
+var TSA tsa.Authority
+
+// InitTSA sets up the RFC 3161 time-stamp authority approvals and receipts are timestamped by.
+// TSA_BACKEND=local uses an in-process stand-in whose tokens prove nothing to third parties;
+// otherwise TSA_URL selects the authority.
+func InitTSA() {
+	if os.Getenv("TSA_BACKEND") == "local" {
+		log.Println("Timestamping with a local time-stamp authority, tokens are not trusted by anyone else")
+		authority, err := tsa.NewLocalAuthority("local")
+		if err != nil {
+			log.Fatalf("Failed to create the local time-stamp authority: %v", err)
+		}
+		TSA = authority
+		return
+	}
+
+	authorityURL := os.Getenv("TSA_URL")
+	if authorityURL == "" {
+		log.Println("TSA_URL not set, trusted timestamping is disabled")
+		return
+	}
+	parsed, err := url.Parse(authorityURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		log.Fatalf("Invalid TSA_URL: %s", authorityURL)
+	}
+	TSA = tsa.NewClient(parsed.Host, authorityURL)
+}
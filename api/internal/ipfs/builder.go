@@ -0,0 +1,132 @@
+package ipfs
+
+import (
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Layout parameters, matching Kubo's defaults for CIDv1 so a file added here and with
+// `ipfs add --cid-version=1` ends up with the same CID.
+const (
+	chunkSize = 256 << 10
+	maxLinks  = 174
+)
+
+// Block is an encoded block and its CID.
+type Block struct {
+	CID  cid.Cid
+	Data []byte
+}
+
+// buildNode is a node of a DAG being built: its block plus what a parent link needs.
+type buildNode struct {
+	block     Block
+	fileSize  uint64 // File bytes under the node
+	totalSize uint64 // Encoded bytes of the node and everything under it, the link's Tsize
+}
+
+// BuildFile lays data out as a balanced UnixFS DAG of raw leaves and returns the root CID
+// along with every block, children before their parents. A file that fits in one chunk is a
+// single raw block.
+func BuildFile(data []byte) (cid.Cid, []Block, error) {
+	b := &dagBuilder{data: data}
+
+	root, err := b.leaf()
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	for depth := 1; len(b.data) > 0; depth++ {
+		root, err = b.fill([]buildNode{root}, depth)
+		if err != nil {
+			return cid.Undef, nil, err
+		}
+	}
+	return root.block.CID, b.blocks, nil
+}
+
+type dagBuilder struct {
+	data   []byte
+	blocks []Block
+}
+
+func (b *dagBuilder) leaf() (buildNode, error) {
+	n := min(chunkSize, len(b.data))
+	chunk := b.data[:n]
+	b.data = b.data[n:]
+
+	id, err := sum(codecRaw, chunk)
+	if err != nil {
+		return buildNode{}, err
+	}
+	block := Block{CID: id, Data: chunk}
+	b.blocks = append(b.blocks, block)
+	return buildNode{block: block, fileSize: uint64(n), totalSize: uint64(n)}, nil
+}
+
+// fill adds subtrees of the given depth to children until the node is full or the data runs
+// out, then encodes the node.
+func (b *dagBuilder) fill(children []buildNode, depth int) (buildNode, error) {
+	for len(children) < maxLinks && len(b.data) > 0 {
+		var child buildNode
+		var err error
+		if depth == 1 {
+			child, err = b.leaf()
+		} else {
+			child, err = b.fill(nil, depth-1)
+		}
+		if err != nil {
+			return buildNode{}, err
+		}
+		children = append(children, child)
+	}
+
+	encoded, fileSize, totalSize := encodeFileNode(children)
+	id, err := sum(codecDagPB, encoded)
+	if err != nil {
+		return buildNode{}, err
+	}
+	block := Block{CID: id, Data: encoded}
+	b.blocks = append(b.blocks, block)
+	return buildNode{block: block, fileSize: fileSize, totalSize: totalSize + uint64(len(encoded))}, nil
+}
+
+// encodeFileNode encodes a dag-pb node linking to children, in the canonical field order
+// (Links before Data) so the bytes, and with them the CID, are reproducible.
+func encodeFileNode(children []buildNode) ([]byte, uint64, uint64) {
+	var unixfsData []byte
+	var fileSize, totalSize uint64
+	for _, child := range children {
+		fileSize += child.fileSize
+		totalSize += child.totalSize
+	}
+	unixfsData = protowire.AppendTag(unixfsData, 1, protowire.VarintType) // Data.Type
+	unixfsData = protowire.AppendVarint(unixfsData, unixfsFile)
+	unixfsData = protowire.AppendTag(unixfsData, 3, protowire.VarintType) // Data.filesize
+	unixfsData = protowire.AppendVarint(unixfsData, fileSize)
+	for _, child := range children {
+		unixfsData = protowire.AppendTag(unixfsData, 4, protowire.VarintType) // Data.blocksizes
+		unixfsData = protowire.AppendVarint(unixfsData, child.fileSize)
+	}
+
+	var encoded []byte
+	for _, child := range children {
+		var link []byte
+		link = protowire.AppendTag(link, 1, protowire.BytesType) // PBLink.Hash
+		link = protowire.AppendBytes(link, child.block.CID.Bytes())
+		link = protowire.AppendTag(link, 2, protowire.BytesType) // PBLink.Name, always present
+		link = protowire.AppendString(link, "")
+		link = protowire.AppendTag(link, 3, protowire.VarintType) // PBLink.Tsize
+		link = protowire.AppendVarint(link, child.totalSize)
+
+		encoded = protowire.AppendTag(encoded, 2, protowire.BytesType) // PBNode.Links
+		encoded = protowire.AppendBytes(encoded, link)
+	}
+	encoded = protowire.AppendTag(encoded, 1, protowire.BytesType) // PBNode.Data
+	encoded = protowire.AppendBytes(encoded, unixfsData)
+	return encoded, fileSize, totalSize
+}
+
+func sum(codec uint64, data []byte) (cid.Cid, error) {
+	return cid.Prefix{Version: 1, Codec: codec, MhType: multihash.SHA2_256, MhLength: -1}.Sum(data)
+}
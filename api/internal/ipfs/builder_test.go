@@ -0,0 +1,99 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestBuildFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		blocks int
+	}{
+		{"empty", 0, 1},
+		{"one byte", 1, 1},
+		{"one chunk", chunkSize, 1},
+		{"two chunks", chunkSize + 1, 3},
+		{"full node", maxLinks * chunkSize, maxLinks + 1},
+		// The full node, a new leaf under a new node, and a root above both
+		{"two levels", maxLinks*chunkSize + 1, maxLinks + 1 + 2 + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			data := make([]byte, tt.size)
+			rand.New(rand.NewSource(int64(tt.size))).Read(data)
+
+			root, blocks, err := BuildFile(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(blocks) != tt.blocks {
+				t.Errorf("%d blocks, want %d", len(blocks), tt.blocks)
+			}
+			if last := blocks[len(blocks)-1].CID; !last.Equals(root) {
+				t.Errorf("last block is %s, want the root %s", last, root)
+			}
+			// A file of one chunk is a single raw block, a larger one has a dag-pb root
+			wantCodec := uint64(codecDagPB)
+			if tt.blocks == 1 {
+				wantCodec = codecRaw
+			}
+			if root.Type() != wantCodec {
+				t.Errorf("root codec 0x%x, want 0x%x", root.Type(), wantCodec)
+			}
+
+			// Store the blocks through the client, as uploads do, then read the file back
+			_, client := newFakeNode(t)
+			for _, block := range blocks {
+				if err := client.PutBlock(ctx, block); err != nil {
+					t.Fatalf("PutBlock %s: %v", block.CID, err)
+				}
+			}
+			file, err := client.Open(ctx, root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.Size() != int64(tt.size) {
+				t.Errorf("Size = %d, want %d", file.Size(), tt.size)
+			}
+			got, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatal("file read back differs from the data it was built from")
+			}
+
+			// Ranges across block boundaries, as http.ServeContent asks for them
+			if tt.size > chunkSize {
+				offset := int64(chunkSize - 2)
+				if _, err := file.Seek(offset, io.SeekStart); err != nil {
+					t.Fatal(err)
+				}
+				part := make([]byte, 3)
+				if _, err := io.ReadFull(file, part); err != nil {
+					t.Fatal(err)
+				}
+				if want := data[offset : offset+3]; !bytes.Equal(part, want) {
+					t.Errorf("range at %d = %x, want %x", offset, part, want)
+				}
+			}
+		})
+	}
+}
+
+// A file added with `ipfs add --cid-version=1` gets the same CID.
+func TestBuildFileMatchesKubo(t *testing.T) {
+	root, _, err := BuildFile(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"; root.String() != want {
+		t.Errorf("empty file CID = %s, want %s", root, want)
+	}
+}
@@ -0,0 +1,142 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ipfs/go-cid"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound     = errors.New("block not found")
+	ErrHashMismatch = errors.New("block does not hash to its CID")
+)
+
+// maxBlockSize bounds what is read for one block; IPFS itself refuses blocks over 2 MiB.
+const maxBlockSize = 2 << 20
+
+// Client reads and writes blocks through an IPFS node's HTTP RPC API (Kubo's /api/v0). The node is not
+// trusted: every block is checked against the hash in its CID before it is used.
+type Client struct {
+	APIURL string
+	HTTP   *http.Client
+}
+
+func NewClient(apiURL string) *Client {
+	return &Client{
+		APIURL: strings.TrimRight(apiURL, "/"),
+		HTTP:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Block returns the verified bytes of the block.
+func (c *Client) Block(ctx context.Context, id cid.Cid) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/api/v0/block/get?arg=%s", c.APIURL, url.QueryEscape(id.String()))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.HTTP.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		if response.StatusCode == http.StatusNotFound || strings.Contains(string(body), "not found") {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("ipfs block/get %s: %s: %s", id, response.Status, strings.TrimSpace(string(body)))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxBlockSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBlockSize {
+		return nil, fmt.Errorf("ipfs block %s exceeds %d bytes", id, maxBlockSize)
+	}
+
+	computed, err := id.Prefix().Sum(data)
+	if err != nil {
+		return nil, err
+	}
+	if !computed.Equals(id) {
+		return nil, fmt.Errorf("%w: %s", ErrHashMismatch, id)
+	}
+	return data, nil
+}
+
+// PutBlock stores a block on the node and checks the node derived the same CID from it.
+func (c *Client) PutBlock(ctx context.Context, block Block) error {
+	codec := "raw"
+	if block.CID.Type() == codecDagPB {
+		codec = "dag-pb"
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("data", block.CID.String())
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(block.Data); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	var result struct {
+		Key string
+	}
+	query := url.Values{"cid-codec": {codec}, "mhtype": {"sha2-256"}}
+	if err := c.call(ctx, "block/put", query, form.FormDataContentType(), &body, &result); err != nil {
+		return err
+	}
+	if result.Key != block.CID.String() {
+		return fmt.Errorf("%w: node stored %s as %s", ErrHashMismatch, block.CID, result.Key)
+	}
+	return nil
+}
+
+// Pin recursively pins the DAG under id on the node so its garbage collector keeps it.
+func (c *Client) Pin(ctx context.Context, id cid.Cid) error {
+	query := url.Values{"arg": {id.String()}, "recursive": {"true"}}
+	return c.call(ctx, "pin/add", query, "", nil, nil)
+}
+
+// call posts to an RPC API command and decodes the JSON response into result, if given.
+func (c *Client) call(ctx context.Context, command string, query url.Values, contentType string, body io.Reader, result interface{}) error {
+	endpoint := fmt.Sprintf("%s/api/v0/%s?%s", c.APIURL, command, query.Encode())
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	response, err := c.HTTP.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("ipfs %s: %s: %s", command, response.Status, strings.TrimSpace(string(message)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
@@ -0,0 +1,129 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"github.com/ipfs/go-cid"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeNode is the part of Kubo's RPC API the client uses, keeping blocks in memory. It trusts
+// nothing and checks nothing, so tests can make it misbehave.
+type fakeNode struct {
+	mu     sync.Mutex
+	blocks map[string][]byte
+}
+
+// newFakeNode serves a fakeNode over HTTP and returns a client pointed at it.
+func newFakeNode(t *testing.T) (*fakeNode, *Client) {
+	node := &fakeNode{blocks: make(map[string][]byte)}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	return node, NewClient(server.URL + "/")
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch r.URL.Path {
+	case "/api/v0/block/get":
+		data, ok := n.blocks[r.URL.Query().Get("arg")]
+		if !ok {
+			http.Error(w, `{"Message":"block was not found locally (offline): ipld: could not find node"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Write(data)
+	case "/api/v0/block/put":
+		file, _, err := r.FormFile("data")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		codec := uint64(codecRaw)
+		if r.URL.Query().Get("cid-codec") == "dag-pb" {
+			codec = codecDagPB
+		}
+		id, _ := sum(codec, data)
+		n.blocks[id.String()] = data
+		w.Write([]byte(`{"Key":"` + id.String() + `","Size":0}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (n *fakeNode) set(id cid.Cid, data []byte) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.blocks[id.String()] = data
+}
+
+func TestBlock(t *testing.T) {
+	ctx := context.Background()
+	node, client := newFakeNode(t)
+
+	data := []byte("transcript")
+	id, err := sum(codecRaw, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing, err := sum(codecRaw, []byte("missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.set(id, data)
+
+	got, err := client.Block(ctx, id)
+	if err != nil || string(got) != string(data) {
+		t.Fatalf("Block = %q, %v", got, err)
+	}
+	if _, err := client.Block(ctx, missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Block of a missing CID: err = %v, want ErrNotFound", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"other bytes", []byte("forged transcript")},
+		{"truncated", data[:len(data)-1]},
+		{"empty", []byte{}},
+		{"bytes of another block", []byte("missing")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node.set(id, tt.data)
+			if got, err := client.Block(ctx, id); !errors.Is(err, ErrHashMismatch) {
+				t.Errorf("Block = %q, %v, want ErrHashMismatch", got, err)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsTamperedChild(t *testing.T) {
+	ctx := context.Background()
+	node, client := newFakeNode(t)
+
+	data := make([]byte, chunkSize+1)
+	root, blocks, err := BuildFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range blocks {
+		node.set(block.CID, block.Data)
+	}
+	// The second leaf holds the last byte of the file
+	node.set(blocks[1].CID, []byte{1})
+
+	file, err := client.Open(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(file); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("reading a file with a tampered block: err = %v, want ErrHashMismatch", err)
+	}
+}
@@ -0,0 +1,127 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"github.com/ipfs/go-cid"
+	"io"
+)
+
+// maxDepth bounds how deep a file DAG may nest, guarding against malicious graphs.
+const maxDepth = 32
+
+// File reads a UnixFS file block by block. It implements io.ReadSeeker, fetching only the
+// blocks under the requested range, so it can back http.ServeContent.
+type File struct {
+	ctx    context.Context
+	client *Client
+	root   node
+	size   int64
+	offset int64
+
+	nodes    map[cid.Cid]node // Decoded intermediate nodes, reused across reads
+	leafID   cid.Cid          // Last leaf read, reads are usually smaller than a block
+	leafNode node
+}
+
+// Open fetches and verifies the root block of the file.
+func (c *Client) Open(ctx context.Context, id cid.Cid) (*File, error) {
+	block, err := c.Block(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	root, err := decodeNode(id, block)
+	if err != nil {
+		return nil, err
+	}
+	return &File{
+		ctx:    ctx,
+		client: c,
+		root:   root,
+		size:   int64(root.size),
+		nodes:  make(map[cid.Cid]node),
+	}, nil
+}
+
+func (f *File) Size() int64 {
+	return f.size
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.size
+	default:
+		return 0, errors.New("ipfs: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("ipfs: negative position")
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *File) Read(p []byte) (int, error) {
+	if f.offset >= f.size {
+		return 0, io.EOF
+	}
+	data, err := f.dataAt(f.root, uint64(f.offset), 0)
+	if err != nil {
+		return 0, err
+	}
+	n := copy(p, data)
+	f.offset += int64(n)
+	return n, nil
+}
+
+// dataAt returns the file bytes of the block holding offset, from offset to the end of the block.
+func (f *File) dataAt(n node, offset uint64, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, ErrNotAFile
+	}
+	if offset < uint64(len(n.data)) {
+		return n.data[offset:], nil
+	}
+	offset -= uint64(len(n.data))
+	for i, size := range n.blockSizes {
+		if offset >= size {
+			offset -= size
+			continue
+		}
+		child, err := f.node(n.links[i])
+		if err != nil {
+			return nil, err
+		}
+		if child.size != size {
+			return nil, ErrNotAFile
+		}
+		return f.dataAt(child, offset, depth+1)
+	}
+	return nil, io.ErrUnexpectedEOF
+}
+
+func (f *File) node(id cid.Cid) (node, error) {
+	if n, ok := f.nodes[id]; ok {
+		return n, nil
+	}
+	if id == f.leafID {
+		return f.leafNode, nil
+	}
+	block, err := f.client.Block(f.ctx, id)
+	if err != nil {
+		return node{}, err
+	}
+	n, err := decodeNode(id, block)
+	if err != nil {
+		return node{}, err
+	}
+	if len(n.links) > 0 {
+		f.nodes[id] = n
+	} else {
+		f.leafID, f.leafNode = id, n
+	}
+	return n, nil
+}
@@ -0,0 +1,98 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ipfs/go-cid"
+	"google.golang.org/protobuf/encoding/protowire"
+	"strings"
+)
+
+// UnixFS directory node types
+const (
+	unixfsDirectory = 1
+	unixfsHAMTShard = 5
+)
+
+var (
+	ErrNotADirectory = errors.New("path goes through something that is not a UnixFS directory")
+	ErrNoSuchLink    = errors.New("no such file in directory")
+)
+
+// Resolve walks a slash separated path through the UnixFS directories under root and returns
+// the CID it ends at. Sharded (HAMT) directories are not supported.
+func (c *Client) Resolve(ctx context.Context, root cid.Cid, path string) (cid.Cid, error) {
+	current := root
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+		if current.Type() != codecDagPB {
+			return cid.Undef, fmt.Errorf("%w: %s", ErrNotADirectory, current)
+		}
+		block, err := c.Block(ctx, current)
+		if err != nil {
+			return cid.Undef, err
+		}
+		links, err := decodeDirectory(block)
+		if err != nil {
+			return cid.Undef, fmt.Errorf("%s: %w", current, err)
+		}
+		next, ok := links[name]
+		if !ok {
+			return cid.Undef, fmt.Errorf("%w: %s in %s", ErrNoSuchLink, name, current)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// decodeDirectory returns the named links of a dag-pb UnixFS directory block.
+func decodeDirectory(block []byte) (map[string]cid.Cid, error) {
+	links := make(map[string]cid.Cid)
+	var nodeType uint64
+	err := eachField(block, func(number protowire.Number, typ protowire.Type, value []byte) error {
+		switch {
+		case number == 1 && typ == protowire.BytesType: // PBNode.Data
+			return eachField(value, func(number protowire.Number, _ protowire.Type, value []byte) error {
+				if number == 1 { // Data.Type
+					nodeType, _ = protowire.ConsumeVarint(value)
+				}
+				return nil
+			})
+		case number == 2 && typ == protowire.BytesType: // PBNode.Links
+			id, err := decodeLink(value)
+			if err != nil {
+				return err
+			}
+			name, err := decodeLinkName(value)
+			if err != nil {
+				return err
+			}
+			links[name] = id
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch nodeType {
+	case unixfsDirectory:
+		return links, nil
+	case unixfsHAMTShard:
+		return nil, fmt.Errorf("%w: sharded directories are not supported", ErrNotADirectory)
+	}
+	return nil, ErrNotADirectory
+}
+
+func decodeLinkName(encoded []byte) (string, error) {
+	var name string
+	err := eachField(encoded, func(number protowire.Number, typ protowire.Type, value []byte) error {
+		if number == 2 && typ == protowire.BytesType { // PBLink.Name
+			name = string(value)
+		}
+		return nil
+	})
+	return name, err
+}
@@ -0,0 +1,156 @@
+package ipfs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ipfs/go-cid"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Multicodec codes of the block formats a file can be made of
+const (
+	codecRaw   = 0x55
+	codecDagPB = 0x70
+)
+
+// UnixFS node types
+const (
+	unixfsRaw  = 0
+	unixfsFile = 2
+)
+
+var ErrNotAFile = errors.New("CID is not a UnixFS file")
+
+// node is a decoded block of a UnixFS file: leaf data followed by links to children holding the
+// rest of the file, in order.
+type node struct {
+	data       []byte
+	links      []cid.Cid
+	blockSizes []uint64 // File bytes under each link
+	size       uint64   // File bytes under this node
+}
+
+// decodeNode decodes a raw or dag-pb UnixFS file block.
+func decodeNode(id cid.Cid, block []byte) (node, error) {
+	switch id.Type() {
+	case codecRaw:
+		return node{data: block, size: uint64(len(block))}, nil
+	case codecDagPB:
+	default:
+		return node{}, fmt.Errorf("%w: unsupported codec 0x%x", ErrNotAFile, id.Type())
+	}
+
+	var n node
+	var unixfsData []byte
+	var hasData bool
+	err := eachField(block, func(number protowire.Number, typ protowire.Type, value []byte) error {
+		switch {
+		case number == 1 && typ == protowire.BytesType: // PBNode.Data
+			unixfsData, hasData = value, true
+		case number == 2 && typ == protowire.BytesType: // PBNode.Links
+			link, err := decodeLink(value)
+			if err != nil {
+				return err
+			}
+			n.links = append(n.links, link)
+		}
+		return nil
+	})
+	if err != nil {
+		return node{}, err
+	}
+	if !hasData {
+		return node{}, ErrNotAFile
+	}
+
+	var nodeType uint64
+	var fileSize uint64
+	var hasFileSize bool
+	err = eachField(unixfsData, func(number protowire.Number, typ protowire.Type, value []byte) error {
+		switch number {
+		case 1: // Data.Type
+			nodeType, _ = protowire.ConsumeVarint(value)
+		case 2: // Data.Data
+			n.data = value
+		case 3: // Data.filesize
+			fileSize, _ = protowire.ConsumeVarint(value)
+			hasFileSize = true
+		case 4: // Data.blocksizes, packed or not
+			for len(value) > 0 {
+				size, length := protowire.ConsumeVarint(value)
+				if length < 0 {
+					return protowire.ParseError(length)
+				}
+				n.blockSizes = append(n.blockSizes, size)
+				value = value[length:]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return node{}, err
+	}
+	if nodeType != unixfsFile && nodeType != unixfsRaw {
+		return node{}, ErrNotAFile
+	}
+	if len(n.blockSizes) != len(n.links) {
+		return node{}, fmt.Errorf("%w: %d links but %d block sizes", ErrNotAFile, len(n.links), len(n.blockSizes))
+	}
+
+	n.size = uint64(len(n.data))
+	for _, size := range n.blockSizes {
+		n.size += size
+	}
+	if hasFileSize && fileSize != n.size {
+		return node{}, fmt.Errorf("%w: filesize %d does not match its blocks (%d)", ErrNotAFile, fileSize, n.size)
+	}
+	return n, nil
+}
+
+func decodeLink(encoded []byte) (cid.Cid, error) {
+	var link cid.Cid
+	err := eachField(encoded, func(number protowire.Number, typ protowire.Type, value []byte) error {
+		if number == 1 && typ == protowire.BytesType { // PBLink.Hash
+			var err error
+			link, err = cid.Cast(value)
+			return err
+		}
+		return nil
+	})
+	if err == nil && !link.Defined() {
+		err = fmt.Errorf("%w: link without hash", ErrNotAFile)
+	}
+	return link, err
+}
+
+// eachField calls fn with every field of a protobuf message. Varint fields are passed encoded.
+func eachField(message []byte, fn func(protowire.Number, protowire.Type, []byte) error) error {
+	for len(message) > 0 {
+		number, typ, length := protowire.ConsumeTag(message)
+		if length < 0 {
+			return protowire.ParseError(length)
+		}
+		message = message[length:]
+
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(message)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			value, length = v, n
+		default:
+			length = protowire.ConsumeFieldValue(number, typ, message)
+			if length < 0 {
+				return protowire.ParseError(length)
+			}
+			value = message[:length]
+		}
+		if err := fn(number, typ, value); err != nil {
+			return err
+		}
+		message = message[length:]
+	}
+	return nil
+}
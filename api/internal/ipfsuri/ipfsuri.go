@@ -0,0 +1,96 @@
+package ipfsuri
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ipfs/go-cid"
+	"net/url"
+	"path"
+	"strings"
+)
+
+var ErrInvalid = errors.New("not an IPFS URI")
+
+// URI identifies content on IPFS: a root CID and an optional path inside the DAG under it.
+type URI struct {
+	CID  cid.Cid
+	Path string // Cleaned path below the root without a leading slash, empty for the root itself
+}
+
+// Parse accepts the forms transcripts reference content by and returns them in one shape:
+//
+//	<cid>[/path]
+//	/ipfs/<cid>[/path]
+//	ipfs://<cid>[/path]
+//	ipfs://ipfs/<cid>[/path]              (legacy double prefix)
+//	http(s)://<host>/ipfs/<cid>[/path]     (path gateway)
+//	http(s)://<cid>.ipfs.<host>[/path]     (subdomain gateway)
+//
+// Gateway query strings and fragments are ignored.
+func Parse(raw string) (URI, error) {
+	raw = strings.TrimSpace(raw)
+	var rest string
+	switch {
+	case strings.HasPrefix(raw, "ipfs://"):
+		rest = strings.TrimPrefix(strings.TrimPrefix(raw, "ipfs://"), "ipfs/")
+		rest, _, _ = strings.Cut(rest, "?")
+		rest, _, _ = strings.Cut(rest, "#")
+	case strings.HasPrefix(raw, "http://"), strings.HasPrefix(raw, "https://"):
+		gateway, err := url.Parse(raw)
+		if err != nil {
+			return URI{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		if label, _, found := strings.Cut(gateway.Hostname(), ".ipfs."); found {
+			rest = label + "/" + gateway.Path
+		} else if after, found := strings.CutPrefix(gateway.Path, "/ipfs/"); found {
+			rest = after
+		} else {
+			return URI{}, fmt.Errorf("%w: %s is not a gateway URL", ErrInvalid, raw)
+		}
+	default:
+		rest = strings.TrimPrefix(raw, "/ipfs/")
+	}
+
+	root, subpath, _ := strings.Cut(rest, "/")
+	id, err := cid.Decode(root)
+	if err != nil {
+		return URI{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	if subpath != "" {
+		subpath = path.Clean("/" + subpath)
+		if subpath == "/" {
+			subpath = ""
+		}
+		subpath = strings.TrimPrefix(subpath, "/")
+	}
+	return URI{CID: id, Path: subpath}, nil
+}
+
+// Canonical returns the URI with its root CID in CIDv1 form, so CIDv0 and CIDv1 of the same
+// content, in any multibase, compare equal.
+func (u URI) Canonical() URI {
+	if u.CID.Version() == 0 {
+		u.CID = cid.NewCidV1(cid.DagProtobuf, u.CID.Hash())
+	}
+	return u
+}
+
+// String formats the canonical URI as ipfs://<cidv1 base32>[/path], the form transcripts are
+// stored and looked up by.
+func (u URI) String() string {
+	u = u.Canonical()
+	if u.Path == "" {
+		return "ipfs://" + u.CID.String()
+	}
+	return "ipfs://" + u.CID.String() + "/" + u.Path
+}
+
+// Normalize parses raw and returns its canonical string form.
+func Normalize(raw string) (string, error) {
+	uri, err := Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	return uri.String(), nil
+}
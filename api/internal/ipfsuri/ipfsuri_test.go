@@ -0,0 +1,121 @@
+package ipfsuri
+
+import (
+	"errors"
+	"testing"
+)
+
+const (
+	cidV0       = "QmdfTbBqBPQ7VNxZEYEj14VmRuZBkqFbiwReogJgS1zR1n"
+	cidV1       = "bafybeihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"
+	cidV1Base58 = "zdj7Wkkhxcu2rsiN6GUyHCLsSLL47kdUNfjbFqBUUhMFTZKBi" // cidV1 in base58btc
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		cid  string // As parsed, before Canonical
+		path string
+	}{
+		{"bare CIDv0", cidV0, cidV0, ""},
+		{"bare CIDv1", cidV1, cidV1, ""},
+		{"bare CID with path", cidV1 + "/transcript.pdf", cidV1, "transcript.pdf"},
+		{"surrounding whitespace", " " + cidV1 + "\n", cidV1, ""},
+		{"ipfs path", "/ipfs/" + cidV1 + "/a/b.json", cidV1, "a/b.json"},
+		{"ipfs scheme", "ipfs://" + cidV0, cidV0, ""},
+		{"ipfs scheme with path", "ipfs://" + cidV1 + "/metadata.json", cidV1, "metadata.json"},
+		{"legacy double prefix", "ipfs://ipfs/" + cidV1 + "/metadata.json", cidV1, "metadata.json"},
+		{"ipfs scheme with query and fragment", "ipfs://" + cidV1 + "/a.pdf?filename=x#page=2", cidV1, "a.pdf"},
+		{"path gateway", "https://ipfs.io/ipfs/" + cidV0 + "/a.pdf", cidV0, "a.pdf"},
+		{"path gateway with query", "http://127.0.0.1:8080/ipfs/" + cidV1 + "?download=true", cidV1, ""},
+		{"subdomain gateway", "https://" + cidV1 + ".ipfs.dweb.link/a.pdf", cidV1, "a.pdf"},
+		{"subdomain gateway root", "https://" + cidV1 + ".ipfs.dweb.link/", cidV1, ""},
+		{"trailing slash", "ipfs://" + cidV1 + "/", cidV1, ""},
+		{"dot segments are cleaned", "ipfs://" + cidV1 + "/a/./b/../c.pdf", cidV1, "a/c.pdf"},
+		{"path cannot climb above the root", "ipfs://" + cidV1 + "/../../etc/passwd", cidV1, "etc/passwd"},
+		{"duplicate slashes", "/ipfs/" + cidV1 + "//a//b", cidV1, "a/b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uri, err := Parse(tt.raw)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.raw, err)
+			}
+			if uri.CID.String() != tt.cid || uri.Path != tt.path {
+				t.Errorf("Parse(%q) = %s %q, want %s %q", tt.raw, uri.CID, uri.Path, tt.cid, tt.path)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", ""},
+		{"not a CID", "transcript.pdf"},
+		{"truncated CID", cidV1[:20]},
+		{"ipfs scheme without CID", "ipfs://"},
+		{"ipns name", "ipfs://ipns/example.com"},
+		{"plain web URL", "https://example.com/transcript.pdf"},
+		{"gateway without CID", "https://ipfs.io/ipfs/"},
+		{"subdomain gateway with bad label", "https://nope.ipfs.dweb.link/a.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if uri, err := Parse(tt.raw); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse(%q) = %v, %v, want ErrInvalid", tt.raw, uri, err)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"CIDv0 becomes CIDv1", cidV0, "ipfs://" + cidV1},
+		{"CIDv1 is kept", "ipfs://" + cidV1, "ipfs://" + cidV1},
+		{"base58 CIDv1 becomes base32", cidV1Base58, "ipfs://" + cidV1},
+		{"path is kept", "https://ipfs.io/ipfs/" + cidV0 + "/a/../b.pdf", "ipfs://" + cidV1 + "/b.pdf"},
+		{"subdomain gateway", "https://" + cidV1 + ".ipfs.dweb.link/b.pdf", "ipfs://" + cidV1 + "/b.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.raw)
+			if err != nil {
+				t.Fatalf("Normalize(%q): %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %s, want %s", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	forms := []string{cidV0, cidV1, cidV1Base58, "/ipfs/" + cidV0, "https://ipfs.io/ipfs/" + cidV1Base58}
+	var first URI
+	for i, raw := range forms {
+		uri, err := Parse(raw)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", raw, err)
+		}
+		canonical := uri.Canonical()
+		if canonical.CID.Version() != 1 {
+			t.Errorf("Canonical(%q) is CIDv%d", raw, canonical.CID.Version())
+		}
+		if canonical.Canonical() != canonical {
+			t.Errorf("Canonical(%q) is not idempotent", raw)
+		}
+		if i == 0 {
+			first = canonical
+		} else if !canonical.CID.Equals(first.CID) {
+			t.Errorf("Canonical(%q) = %s, want %s", raw, canonical.CID, first.CID)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"api/internal/audit"
+	"api/internal/chain"
+	"api/pkg/constants"
+	"api/pkg/utils"
+	"context"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// AnchorAuditLog periodically anchors the Merkle root of new audit entries on chain. The interval
+// is AUDIT_ANCHOR_INTERVAL_MINUTES, AuditAnchorInterval by default.
+func AnchorAuditLog(ctx context.Context, db *gorm.DB, anchorer chain.Anchorer) {
+	interval := utils.GetEnvInt("AUDIT_ANCHOR_INTERVAL_MINUTES", constants.AuditAnchorInterval)
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+	for {
+		// Catch up in batches after downtime
+		for {
+			anchorCtx, cancel := context.WithTimeout(ctx, constants.AuditAnchorTimeout*time.Minute)
+			anchor, err := audit.Anchor(anchorCtx, db, anchorer, constants.AuditAnchorMaxEntries)
+			cancel()
+			if err != nil {
+				log.Printf("Failed to anchor audit log: %v", err)
+				break
+			}
+			if anchor == nil {
+				break
+			}
+			log.Printf("Anchored audit entries %d-%d in %s", anchor.FirstEntryID, anchor.LastEntryID, anchor.TxHash)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
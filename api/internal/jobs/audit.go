@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"api/internal/audit"
+	"api/pkg/constants"
+	"context"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// VerifyAuditLog periodically re-hashes the audit log and logs an error when the chain is
+// broken. Entries already checked are not checked again until the process restarts.
+func VerifyAuditLog(ctx context.Context, db *gorm.DB) {
+	ticker := time.NewTicker(constants.AuditVerifyInterval * time.Minute)
+	defer ticker.Stop()
+	lastID, lastHash := uint64(0), audit.GenesisHash
+	for {
+		id, hash, err := audit.Verify(db, lastID, lastHash)
+		if err != nil {
+			log.Printf("Audit log verification failed: %v", err)
+		}
+		lastID, lastHash = id, hash
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
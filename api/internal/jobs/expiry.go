@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"api/internal/notifications"
+	"api/pkg/constants"
+	"api/pkg/utils"
+	"context"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// ExpireRequests periodically moves pending requests past their expiry to the expired state.
+func ExpireRequests(ctx context.Context, db *gorm.DB) {
+	ticker := time.NewTicker(constants.RequestExpiryInterval * time.Minute)
+	defer ticker.Stop()
+	for {
+		for {
+			expired, err := utils.ExpireRequests(db, time.Now(), 100)
+			if err != nil {
+				log.Printf("Failed to expire requests: %v", err)
+				break
+			}
+			if expired < 100 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RemindExpiringRequests periodically emails students about pending requests that expire soon.
+func RemindExpiringRequests(ctx context.Context, db *gorm.DB) {
+	ticker := time.NewTicker(constants.ExpiryReminderInterval * time.Minute)
+	defer ticker.Stop()
+	for {
+		if err := notifications.RemindExpiring(db, time.Now(), constants.ExpiryReminderWindow*time.Minute); err != nil {
+			log.Printf("Failed to send expiry reminders: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
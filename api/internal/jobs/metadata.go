@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"api/internal/ipfs"
+	"api/internal/metadata"
+	"api/pkg/constants"
+	"context"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// IndexTranscriptMetadata periodically fetches and validates the metadata of new transcripts and
+// retries metadata that was unreachable.
+func IndexTranscriptMetadata(ctx context.Context, db *gorm.DB, client *ipfs.Client) {
+	ticker := time.NewTicker(constants.MetadataIndexInterval * time.Minute)
+	defer ticker.Stop()
+	for {
+		for {
+			indexed, err := metadata.IndexPending(ctx, db, client, 100)
+			if err != nil {
+				log.Printf("Failed to index transcript metadata: %v", err)
+				break
+			}
+			if indexed < 100 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
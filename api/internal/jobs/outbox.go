@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"api/internal/initializers"
+	"api/internal/models"
+	"api/internal/notifications"
+	"api/internal/outbox"
+	"api/internal/push"
+	"api/internal/realtime"
+	"api/internal/verification"
+	"api/internal/webhooks"
+	"api/pkg/utils"
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"math/big"
+)
+
+// OutboxRelay returns the relay publishing outbox events to webhooks, the realtime streams and
+// the in-process email and Web Push notifiers.
+func OutboxRelay(db *gorm.DB) *outbox.Relay {
+	return outbox.NewRelay(db,
+		outbox.WebhookSink{DB: db},
+		outbox.RealtimeSink{},
+		outbox.Subscriber{
+			ID:      "access-granted",
+			Types:   []string{string(models.EventRequestApproved)},
+			Handler: accessGranted,
+		},
+		outbox.Subscriber{
+			ID: "request-push",
+			Types: []string{
+				string(models.EventRequestCreated),
+				string(models.EventRequestApproved),
+				string(models.EventRequestDenied),
+				string(models.EventRequestExpired),
+			},
+			Handler: func(ctx context.Context, event models.OutboxEvent) error {
+				return requestPush(ctx, db, event)
+			},
+		},
+		outbox.Subscriber{
+			ID:    "request-email",
+			Types: []string{string(models.EventRequestCreated)},
+			Handler: func(ctx context.Context, event models.OutboxEvent) error {
+				return requestEmail(ctx, db, event)
+			},
+		},
+		outbox.Subscriber{
+			ID:    "revocation-push",
+			Types: []string{webhooks.EventCredentialRevoked},
+			Handler: func(ctx context.Context, event models.OutboxEvent) error {
+				return push.Notify(db, initializers.VAPIDKey, event.Audience,
+					push.CredentialRevokedMessage(payloadString(event, "token_id"), payloadString(event, "reason")))
+			},
+		},
+		outbox.Subscriber{
+			ID:    "revocation-email",
+			Types: []string{webhooks.EventCredentialRevoked},
+			Handler: func(ctx context.Context, event models.OutboxEvent) error {
+				return notifications.CredentialRevoked(db, event.Audience, payloadString(event, "token_id"),
+					payloadString(event, "institution"), payloadString(event, "reason"), payloadString(event, "tx_hash"))
+			},
+		},
+		outbox.Subscriber{
+			ID:    "approval-timestamp",
+			Types: []string{string(models.EventRequestApproved)},
+			Handler: func(ctx context.Context, event models.OutboxEvent) error {
+				if initializers.TSA == nil {
+					return nil
+				}
+				return utils.TimestampApproval(ctx, db, initializers.TSA, event.AggregateID)
+			},
+		},
+		outbox.Subscriber{
+			ID:      "verification-cache",
+			Types:   []string{webhooks.EventCredentialRevoked},
+			Handler: invalidateVerification,
+		},
+		outbox.Subscriber{
+			ID:    "status-list",
+			Types: []string{webhooks.EventCredentialRevoked},
+			Handler: func(ctx context.Context, event models.OutboxEvent) error {
+				return initializers.StatusLists.PublishFor(ctx, payloadString(event, "token_id"))
+			},
+		},
+	)
+}
+
+// accessGranted tells the recipient's connected clients they can now open the shared transcripts.
+func accessGranted(ctx context.Context, event models.OutboxEvent) error {
+	return realtime.Publish([]string{payloadString(event, "recipient_wallet")}, realtime.EventAccessGranted, event.Payload)
+}
+
+// invalidateVerification drops the cached verification report of a revoked credential so the
+// revocation shows up right away.
+func invalidateVerification(ctx context.Context, event models.OutboxEvent) error {
+	tokenID, ok := new(big.Int).SetString(payloadString(event, "token_id"), 10)
+	if !ok {
+		return nil
+	}
+	return verification.Invalidate(ctx, tokenID)
+}
+
+func requestPush(ctx context.Context, db *gorm.DB, event models.OutboxEvent) error {
+	request, err := outboxRequest(ctx, db, event)
+	if err != nil {
+		return err
+	}
+	wallets, message, ok := push.RequestEventMessage(request, models.RequestEventType(event.Type))
+	if !ok {
+		return nil
+	}
+	return push.Notify(db, initializers.VAPIDKey, wallets, message)
+}
+
+func requestEmail(ctx context.Context, db *gorm.DB, event models.OutboxEvent) error {
+	request, err := outboxRequest(ctx, db, event)
+	if err != nil {
+		return err
+	}
+	return notifications.RequestCreated(db, request)
+}
+
+// outboxRequest loads the request a request event was recorded for.
+func outboxRequest(ctx context.Context, db *gorm.DB, event models.OutboxEvent) (models.Request, error) {
+	var request models.Request
+	err := db.WithContext(ctx).First(&request, "id = ?", event.AggregateID).Error
+	return request, err
+}
+
+func payloadString(event models.OutboxEvent, key string) string {
+	value, ok := event.Payload[key]
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
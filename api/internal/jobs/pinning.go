@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"api/internal/pinning"
+	"api/pkg/constants"
+	"api/pkg/utils"
+	"context"
+	"log"
+	"time"
+)
+
+// MonitorPins periodically pins new transcript files at every pinning service and re-verifies
+// existing pins. The interval is PINNING_CHECK_INTERVAL_MINUTES, PinCheckInterval by default.
+func MonitorPins(ctx context.Context, monitor *pinning.Monitor) {
+	interval := utils.GetEnvInt("PINNING_CHECK_INTERVAL_MINUTES", constants.PinCheckInterval)
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+	for {
+		if err := monitor.Sync(ctx); err != nil {
+			log.Printf("Failed to pin transcript files: %v", err)
+		}
+		if err := monitor.Check(ctx); err != nil {
+			log.Printf("Failed to check pins: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
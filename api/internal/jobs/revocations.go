@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"api/internal/audit"
+	"api/internal/chain"
+	"api/internal/models"
+	"api/internal/outbox"
+	"api/internal/statuslist"
+	"api/internal/webhooks"
+	"api/pkg/constants"
+	"api/pkg/utils"
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+const (
+	revocationCursor = "credential_status_changed"
+	maxBlockRange    = 2000
+)
+
+// WatchRevocations follows CredentialStatusChanged logs and publishes credential.revoked to the
+// credential's institution, owner and the recipients it was shared with.
+func WatchRevocations(ctx context.Context, db *gorm.DB, nftcms *chain.NFTCMS) {
+	ticker := time.NewTicker(constants.ChainPollInterval * time.Second)
+	defer ticker.Stop()
+	for {
+		if err := processRevocations(ctx, db, nftcms); err != nil {
+			log.Printf("Failed to process credential status changes: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func processRevocations(ctx context.Context, db *gorm.DB, nftcms *chain.NFTCMS) error {
+	head, err := nftcms.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	if head < constants.ChainConfirmations {
+		return nil
+	}
+	safe := head - constants.ChainConfirmations
+
+	cursor := models.ChainCursor{Name: revocationCursor}
+	if err := db.First(&cursor, "name = ?", revocationCursor).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		// Start from the current block instead of replaying the whole chain
+		cursor.BlockNumber = uint64(utils.GetEnvInt("NFTCMS_START_BLOCK", int(safe)))
+	} else if err != nil {
+		return err
+	}
+
+	for from := cursor.BlockNumber + 1; from <= safe; from += maxBlockRange {
+		to := min(from+maxBlockRange-1, safe)
+		changes, err := nftcms.FilterStatusChanged(ctx, from, to)
+		if err != nil {
+			return err
+		}
+		// The chain is read before the transaction, which holds the audit log lock once the
+		// first revocation is recorded
+		var revocations []revocation
+		for _, change := range changes {
+			if change.NewStatus != chain.CredentialRevoked {
+				continue
+			}
+			revoked, err := readRevocation(ctx, nftcms, change)
+			if err != nil {
+				return err
+			}
+			revocations = append(revocations, revoked)
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, revoked := range revocations {
+				if err := publishRevocation(tx, revoked); err != nil {
+					return err
+				}
+			}
+			return tx.Clauses(clause.OnConflict{UpdateAll: true}).
+				Create(&models.ChainCursor{Name: revocationCursor, BlockNumber: to}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revocation is a revoked status change with the parties read from the chain.
+type revocation struct {
+	change chain.StatusChanged
+	issuer common.Address
+	owner  common.Address
+}
+
+func readRevocation(ctx context.Context, nftcms *chain.NFTCMS, change chain.StatusChanged) (revocation, error) {
+	credential, err := nftcms.Credential(ctx, change.TokenID)
+	if err != nil {
+		return revocation{}, err
+	}
+	owner, err := nftcms.OwnerOf(ctx, change.TokenID)
+	if err != nil {
+		return revocation{}, err
+	}
+	return revocation{change: change, issuer: credential.Signer, owner: owner}, nil
+}
+
+// publishRevocation audits the revocation, flags it in the status lists and queues
+// credential.revoked in the transaction that advances the cursor.
+func publishRevocation(tx *gorm.DB, revoked revocation) error {
+	change := revoked.change
+	audience, err := utils.CredentialAudience(tx, change.TokenID.String())
+	if err != nil {
+		return err
+	}
+	audience = append(audience, revoked.issuer.Hex())
+
+	if err := statuslist.MarkRevoked(tx, change.TokenID.String()); err != nil {
+		return err
+	}
+	if err := audit.Record(tx, audit.Client{}, models.AuditCredentialRevoke, revoked.issuer.Hex(), revoked.owner.Hex(),
+		outbox.CredentialAggregate(change.TokenID.String()), models.AuditSuccess, map[string]string{
+			"reason":  change.Reason,
+			"tx_hash": change.TxHash.Hex(),
+		}); err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"token_id":        change.TokenID.String(),
+		"institution":     revoked.issuer.Hex(),
+		"previous_status": change.PreviousStatus.String(),
+		"new_status":      change.NewStatus.String(),
+		"reason":          change.Reason,
+		"block_number":    change.BlockNumber,
+		"tx_hash":         change.TxHash.Hex(),
+	}
+	return outbox.Enqueue(tx, outbox.CredentialAggregate(change.TokenID.String()), webhooks.EventCredentialRevoked, audience, payload)
+}
@@ -0,0 +1,21 @@
+package kms
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrUnknownKey = errors.New("wrapped by an unknown master key")
+
+// WrappedKey is a data key encrypted under a KMS master key.
+type WrappedKey struct {
+	KeyID      string // Master key that wrapped it
+	Ciphertext []byte
+}
+
+// KMS wraps and unwraps data keys with master keys that never leave it. Implementations may
+// call out to a cloud KMS or HSM; LocalKMS keeps the master key in a file for dev and test.
+type KMS interface {
+	Wrap(ctx context.Context, dataKey []byte) (WrappedKey, error)
+	Unwrap(ctx context.Context, wrapped WrappedKey) ([]byte, error)
+}
@@ -0,0 +1,92 @@
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalKMS wraps data keys with AES-256-GCM under a master key read from a file. It is meant for
+// development and tests; the key file must be kept as secret as the documents.
+type LocalKMS struct {
+	keyID string
+	aead  cipher.AEAD
+}
+
+// NewLocalKMS loads the hex encoded master key at path. A missing file is an error unless
+// generate is set, in which case a random key is written there: a new key cannot unwrap
+// anything wrapped under the old one, so one must never be created by accident.
+func NewLocalKMS(path string, generate bool) (*LocalKMS, error) {
+	encoded, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !generate {
+		return nil, fmt.Errorf("%s does not exist; set KMS_GENERATE_KEY=true to create a new master key", path)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, err
+		}
+		encoded = []byte(hex.EncodeToString(key))
+		// O_EXCL so a key written concurrently is never overwritten
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		_, err = file.Write(encoded)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s must hold a hex encoded 32 byte key", path)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := sha256.Sum256(key)
+	return &LocalKMS{keyID: "local/" + hex.EncodeToString(fingerprint[:8]), aead: aead}, nil
+}
+
+func (l *LocalKMS) Wrap(ctx context.Context, dataKey []byte) (WrappedKey, error) {
+	nonce := make([]byte, l.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return WrappedKey{}, err
+	}
+	// Bind the ciphertext to the key ID so it cannot be passed off as another key's
+	ciphertext := l.aead.Seal(nonce, nonce, dataKey, []byte(l.keyID))
+	return WrappedKey{KeyID: l.keyID, Ciphertext: ciphertext}, nil
+}
+
+func (l *LocalKMS) Unwrap(ctx context.Context, wrapped WrappedKey) ([]byte, error) {
+	if wrapped.KeyID != l.keyID {
+		return nil, ErrUnknownKey
+	}
+	if len(wrapped.Ciphertext) < l.aead.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	nonce, ciphertext := wrapped.Ciphertext[:l.aead.NonceSize()], wrapped.Ciphertext[l.aead.NonceSize():]
+	return l.aead.Open(nil, nonce, ciphertext, []byte(l.keyID))
+}
@@ -0,0 +1,128 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewLocalKMS(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.key")
+	if err := os.WriteFile(existing, []byte(strings.Repeat("ab", 32)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	short := filepath.Join(dir, "short.key")
+	if err := os.WriteFile(short, []byte("abcd"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		generate bool
+		wantErr  bool
+	}{
+		{"existing key", existing, false, false},
+		{"existing key is kept with generate", existing, true, false},
+		{"missing key", filepath.Join(dir, "missing.key"), false, true},
+		{"missing key is generated", filepath.Join(dir, "nested", "new.key"), true, false},
+		{"malformed key", short, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, err := NewLocalKMS(tt.path, tt.generate)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewLocalKMS succeeded")
+				}
+				if _, statErr := os.Stat(tt.path); tt.path != short && !errors.Is(statErr, os.ErrNotExist) {
+					t.Error("a key file was created without generate")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewLocalKMS: %v", err)
+			}
+
+			// The same file always loads the same master key
+			again, err := NewLocalKMS(tt.path, false)
+			if err != nil {
+				t.Fatalf("reloading: %v", err)
+			}
+			if local.keyID != again.keyID {
+				t.Errorf("key ID changed from %s to %s on reload", local.keyID, again.keyID)
+			}
+			info, err := os.Stat(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.generate && tt.path != existing && info.Mode().Perm() != 0o600 {
+				t.Errorf("generated key file mode = %v", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestLocalKMSWrap(t *testing.T) {
+	ctx := context.Background()
+	local, err := NewLocalKMS(filepath.Join(t.TempDir(), "master.key"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewLocalKMS(filepath.Join(t.TempDir(), "other.key"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataKey := bytes.Repeat([]byte{7}, 32)
+	wrapped, err := local.Wrap(ctx, dataKey)
+	if err != nil {
+		t.Fatalf("Wrap: %v", err)
+	}
+	if bytes.Contains(wrapped.Ciphertext, dataKey) {
+		t.Fatal("wrapped key contains the data key")
+	}
+	again, _ := local.Wrap(ctx, dataKey)
+	if bytes.Equal(again.Ciphertext, wrapped.Ciphertext) {
+		t.Error("wrapping twice gives the same ciphertext")
+	}
+
+	tampered := append([]byte(nil), wrapped.Ciphertext...)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		kms     *LocalKMS
+		wrapped WrappedKey
+		wantErr error // nil for any error
+		ok      bool
+	}{
+		{"round trip", local, wrapped, nil, true},
+		{"other master key", other, wrapped, ErrUnknownKey, false},
+		{"key ID of another master key", other, WrappedKey{KeyID: other.keyID, Ciphertext: wrapped.Ciphertext}, nil, false},
+		{"tampered ciphertext", local, WrappedKey{KeyID: local.keyID, Ciphertext: tampered}, nil, false},
+		{"truncated ciphertext", local, WrappedKey{KeyID: local.keyID, Ciphertext: wrapped.Ciphertext[:4]}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unwrapped, err := tt.kms.Unwrap(ctx, tt.wrapped)
+			if tt.ok {
+				if err != nil || !bytes.Equal(unwrapped, dataKey) {
+					t.Fatalf("Unwrap = %x, %v", unwrapped, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Unwrap succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// Message is a multipart email with a plain text and an HTML body.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Transport sends email. Implementations must be safe for concurrent use.
+type Transport interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// MemoryTransport keeps sent messages in memory instead of delivering them, for tests and
+// local development.
+type MemoryTransport struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) Send(_ context.Context, message Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = append(t.sent, message)
+	return nil
+}
+
+// Sent returns a copy of every message sent so far.
+func (t *MemoryTransport) Sent() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Message(nil), t.sent...)
+}
+
+// Reset forgets the sent messages.
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = nil
+}
@@ -0,0 +1,89 @@
+package mail
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	expires := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		template string
+		data     map[string]interface{}
+		subject  string
+		text     string // Expected in the plain text body
+	}{
+		{TemplateVerifyEmail, map[string]interface{}{"Link": "https://api.example/v1/email/verify?token=t", "ExpiresAt": expires},
+			"", "https://api.example/v1/email/verify?token=t"},
+		{TemplateRequestCreated, map[string]interface{}{"Counterparty": "0xRecipient", "RequestID": "req-1", "ExpiresAt": expires},
+			"New transcript request from 0xRecipient", "Expires: 2026-10-19 12:00 UTC"},
+		{TemplateRequestExpiring, map[string]interface{}{"Counterparty": "0xRecipient", "RequestID": "req-1", "ExpiresAt": expires},
+			"", "req-1"},
+		{TemplateCredentialRevoked, map[string]interface{}{"TokenID": "42", "Institution": "0xInstitution", "Reason": "issued in error", "TxHash": "0xtx"},
+			"", "issued in error"},
+		{TemplatePinLost, map[string]interface{}{"CID": "bafy", "Service": "pinata", "Reason": "pin failed", "Pinned": 1, "Services": 2},
+			"Pin of bafy lost at pinata", "1 of 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			tt.data["Wallet"] = "0xWallet"
+			message, err := Render(tt.template, "student@example.com", tt.data)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if message.To != "student@example.com" {
+				t.Errorf("To = %q", message.To)
+			}
+			if message.Subject == "" || strings.Contains(message.Subject, "\n") {
+				t.Errorf("Subject = %q, want a single line", message.Subject)
+			}
+			if tt.subject != "" && message.Subject != tt.subject {
+				t.Errorf("Subject = %q, want %q", message.Subject, tt.subject)
+			}
+			if !strings.Contains(message.Text, tt.text) {
+				t.Errorf("Text does not contain %q:\n%s", tt.text, message.Text)
+			}
+			if !strings.Contains(message.HTML, "<html") {
+				t.Errorf("HTML is not wrapped in the layout:\n%s", message.HTML)
+			}
+		})
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	message, err := Render(TemplateCredentialRevoked, "student@example.com", map[string]interface{}{
+		"Wallet": "0xWallet", "TokenID": "42", "Institution": "0xInstitution", "Reason": `<script>alert(1)</script>`, "TxHash": "0xtx",
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if strings.Contains(message.HTML, "<script>") {
+		t.Errorf("HTML body contains unescaped input:\n%s", message.HTML)
+	}
+}
+
+func TestMemoryTransport(t *testing.T) {
+	transport := NewMemoryTransport()
+	messages := []Message{{To: "a@example.com", Subject: "one"}, {To: "b@example.com", Subject: "two"}}
+	for _, message := range messages {
+		if err := transport.Send(context.Background(), message); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	sent := transport.Sent()
+	if len(sent) != len(messages) || sent[0] != messages[0] || sent[1] != messages[1] {
+		t.Fatalf("Sent() = %+v, want %+v", sent, messages)
+	}
+	sent[0].Subject = "changed"
+	if transport.Sent()[0].Subject != "one" {
+		t.Error("Sent() returned the transport's own slice")
+	}
+
+	transport.Reset()
+	if len(transport.Sent()) != 0 {
+		t.Errorf("Sent() after Reset = %+v", transport.Sent())
+	}
+}
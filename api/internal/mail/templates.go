@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Template names; each has a .txt file defining "subject" and a .html file defining "content"
+const (
+	TemplateVerifyEmail       = "verify_email"
+	TemplateRequestCreated    = "request_created"
+	TemplateRequestExpiring   = "request_expiring"
+	TemplateCredentialRevoked = "credential_revoked"
+	TemplatePinLost           = "pin_lost"
+)
+
+// Render builds a message from the named template. data must have a Wallet field for the layout.
+func Render(name, to string, data interface{}) (Message, error) {
+	text, err := texttemplate.ParseFS(templateFS, "templates/"+name+".txt")
+	if err != nil {
+		return Message{}, err
+	}
+	var subject, body bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := text.ExecuteTemplate(&body, name+".txt", data); err != nil {
+		return Message{}, err
+	}
+
+	html, err := htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")
+	if err != nil {
+		return Message{}, err
+	}
+	var htmlBody bytes.Buffer
+	if err := html.ExecuteTemplate(&htmlBody, "layout", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(body.String()) + "\n",
+		HTML:    htmlBody.String(),
+	}, nil
+}
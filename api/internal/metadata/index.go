@@ -0,0 +1,136 @@
+package metadata
+
+import (
+	"api/internal/ipfs"
+	"api/internal/ipfsuri"
+	"api/internal/models"
+	"api/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"time"
+)
+
+// maxDocumentSize bounds the metadata read; credential metadata is a few hundred bytes.
+const maxDocumentSize = 64 << 10
+
+var errTooLarge = fmt.Errorf("metadata is larger than %d bytes", maxDocumentSize)
+
+// Index fetches, validates and stores the metadata of the transcript. Content on IPFS never
+// changes, so a valid or invalid result is final; only unreachable metadata is fetched again,
+// after a backoff.
+func Index(ctx context.Context, db *gorm.DB, client *ipfs.Client, transcript models.Transcript) (models.TranscriptMetadata, error) {
+	record := models.TranscriptMetadata{TranscriptID: transcript.TranscriptID}
+	if err := db.Take(&record, "transcript_id = ?", transcript.TranscriptID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return record, err
+	}
+	now := time.Now()
+	record.MetadataURI = transcript.IPFSURIMetadata
+	record.FetchedAt = now
+
+	raw, err := fetch(ctx, client, transcript.IPFSURIMetadata)
+	switch {
+	case err == nil:
+		parsed, problems := Parse(raw, now)
+		if uri, err := ipfsuri.Normalize(parsed.MediaURI); err == nil && uri != transcript.IPFSURIMediaHash {
+			problems = append(problems, "media_uri does not match the transcript's media URI")
+		}
+		record = fromParsed(record, parsed, problems)
+	case isMalformed(err):
+		record = fromParsed(record, Parsed{}, []string{err.Error()})
+	default:
+		record.Status = models.MetadataUnreachable
+		record.Problems = []string{err.Error()}
+		record.Attempts++
+		next := now.Add(backoff(record.Attempts))
+		record.NextFetchAt = &next
+	}
+
+	err = db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&record).Error
+	return record, err
+}
+
+// IndexPending indexes up to limit transcripts whose metadata was never fetched or is due for
+// another try, and returns how many it processed.
+func IndexPending(ctx context.Context, db *gorm.DB, client *ipfs.Client, limit int) (int, error) {
+	var transcripts []models.Transcript
+	err := db.Model(&models.Transcript{}).
+		Joins("LEFT JOIN transcript_metadata ON transcript_metadata.transcript_id = transcripts.transcript_id").
+		Where("transcript_metadata.transcript_id IS NULL OR (transcript_metadata.status = ? AND transcript_metadata.next_fetch_at <= ?)",
+			models.MetadataUnreachable, time.Now()).
+		Order("transcripts.transcript_id").
+		Limit(limit).
+		Find(&transcripts).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, transcript := range transcripts {
+		if _, err := Index(ctx, db, client, transcript); err != nil {
+			return 0, err
+		}
+	}
+	return len(transcripts), nil
+}
+
+func fetch(ctx context.Context, client *ipfs.Client, rawURI string) ([]byte, error) {
+	uri, err := ipfsuri.Parse(rawURI)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, constants.MetadataFetchTimeout*time.Second)
+	defer cancel()
+
+	id, err := client.Resolve(ctx, uri.CID, uri.Path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := client.Open(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if file.Size() > maxDocumentSize {
+		return nil, errTooLarge
+	}
+	return io.ReadAll(file)
+}
+
+// isMalformed reports whether the fetch failed because of what is stored rather than because it
+// could not be reached, in which case fetching again cannot help.
+func isMalformed(err error) bool {
+	return errors.Is(err, errTooLarge) || errors.Is(err, ipfsuri.ErrInvalid) || errors.Is(err, ipfs.ErrNotAFile) ||
+		errors.Is(err, ipfs.ErrNotADirectory) || errors.Is(err, ipfs.ErrNoSuchLink)
+}
+
+func fromParsed(record models.TranscriptMetadata, parsed Parsed, problems []string) models.TranscriptMetadata {
+	record.Status = models.MetadataValid
+	if len(problems) > 0 {
+		record.Status = models.MetadataInvalid
+	}
+	record.Problems = problems
+	record.Document = parsed.Document
+	record.SchemaVersion = parsed.SchemaVersion
+	record.Name = parsed.Name
+	record.Institution = parsed.Institution
+	record.Degree = parsed.Degree
+	record.IssueDate = nil
+	if !parsed.Issued.IsZero() {
+		record.IssueDate = &parsed.Issued
+	}
+	record.MediaURI = parsed.MediaURI
+	record.MediaHash = parsed.Hash
+	record.Attempts = 0
+	record.NextFetchAt = nil
+	return record
+}
+
+func backoff(attempts int) time.Duration {
+	delay := time.Duration(constants.MetadataBaseBackoff) * time.Minute
+	max := time.Duration(constants.MetadataMaxBackoff) * time.Minute
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
@@ -0,0 +1,106 @@
+package metadata
+
+import (
+	"api/internal/ipfsuri"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CurrentVersion is the newest credential metadata schema version. Documents declare theirs in
+// schema_version so the schema can evolve without breaking credentials already minted.
+const CurrentVersion = 1
+
+const maxFieldLength = 255
+
+var hashPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+// Credential is the credential metadata of schema version 1: ERC-721 metadata (name,
+// description, image) plus the fields describing the credential. Unknown fields such as
+// attributes are allowed and kept in the stored document.
+type Credential struct {
+	SchemaVersion int    `json:"schema_version"`
+	Name          string `json:"name"`
+	Description   string `json:"description,omitempty"`
+	Image         string `json:"image,omitempty"`
+	Institution   string `json:"institution"`
+	Degree        string `json:"degree"`
+	IssueDate     string `json:"issue_date"` // YYYY-MM-DD
+	MediaURI      string `json:"media_uri"`  // IPFS URI of the credential file
+	Hash          string `json:"hash"`       // keccak256 of the credential file, as passed to issueCredential
+}
+
+// Parsed is a decoded credential. Validation trims the text fields and canonicalizes MediaURI
+// and Hash in place.
+type Parsed struct {
+	Credential
+	Document map[string]interface{}
+	Issued   time.Time // IssueDate, once validated
+}
+
+// Parse decodes and validates a metadata document. It returns every problem found rather than
+// stopping at the first; the document is valid when there are none. Document is set whenever
+// the input is a JSON object, even an invalid one.
+func Parse(raw []byte, now time.Time) (Parsed, []string) {
+	var parsed Parsed
+	if err := json.Unmarshal(raw, &parsed.Document); err != nil || parsed.Document == nil {
+		return parsed, []string{"metadata is not a JSON object"}
+	}
+
+	version, ok := parsed.Document["schema_version"].(float64)
+	switch {
+	case !ok:
+		return parsed, []string{"schema_version is missing or not a number"}
+	case version != CurrentVersion:
+		return parsed, []string{fmt.Sprintf("schema_version %v is not supported", version)}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if err := decoder.Decode(&parsed.Credential); err != nil {
+		return parsed, []string{fmt.Sprintf("metadata does not match schema version %d: %v", CurrentVersion, err)}
+	}
+	return parsed, parsed.validate(now)
+}
+
+func (p *Parsed) validate(now time.Time) []string {
+	var problems []string
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{{"name", &p.Name}, {"institution", &p.Institution}, {"degree", &p.Degree}} {
+		*field.value = strings.TrimSpace(*field.value)
+		switch {
+		case *field.value == "":
+			problems = append(problems, field.name+" is required")
+		case len(*field.value) > maxFieldLength:
+			problems = append(problems, fmt.Sprintf("%s is longer than %d characters", field.name, maxFieldLength))
+		}
+	}
+
+	if p.IssueDate == "" {
+		problems = append(problems, "issue_date is required")
+	} else if issued, err := time.Parse(time.DateOnly, p.IssueDate); err != nil {
+		problems = append(problems, "issue_date is not a YYYY-MM-DD date")
+	} else if issued.After(now) {
+		problems = append(problems, "issue_date is in the future")
+	} else {
+		p.Issued = issued
+	}
+
+	if p.MediaURI == "" {
+		problems = append(problems, "media_uri is required")
+	} else if uri, err := ipfsuri.Normalize(p.MediaURI); err != nil {
+		problems = append(problems, "media_uri is not an IPFS URI")
+	} else {
+		p.MediaURI = uri
+	}
+
+	if !hashPattern.MatchString(p.Hash) {
+		problems = append(problems, "hash is not a 0x prefixed 32 byte hex string")
+	}
+	p.Hash = strings.ToLower(p.Hash)
+	return problems
+}
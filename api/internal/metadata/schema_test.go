@@ -0,0 +1,170 @@
+package metadata
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testCIDv0 = "QmdfTbBqBPQ7VNxZEYEj14VmRuZBkqFbiwReogJgS1zR1n"
+	testCIDv1 = "bafybeihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"
+	testHash  = "0x4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"
+)
+
+var testNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// document returns valid version 1 metadata with the given fields replaced; a nil value
+// removes the field.
+func document(t *testing.T, fields map[string]interface{}) []byte {
+	t.Helper()
+	doc := map[string]interface{}{
+		"schema_version": 1,
+		"name":           "BSc Computer Science",
+		"institution":    "Example University",
+		"degree":         "Bachelor of Science",
+		"issue_date":     "2024-05-31",
+		"media_uri":      "ipfs://" + testCIDv1,
+		"hash":           testHash,
+	}
+	for name, value := range fields {
+		if value == nil {
+			delete(doc, name)
+		} else {
+			doc[name] = value
+		}
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestParseValid(t *testing.T) {
+	raw := document(t, map[string]interface{}{"attributes": []string{"honours"}})
+	parsed, problems := Parse(raw, testNow)
+	if len(problems) != 0 {
+		t.Fatalf("Parse reported %v", problems)
+	}
+	if want := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC); !parsed.Issued.Equal(want) {
+		t.Errorf("Issued = %v, want %v", parsed.Issued, want)
+	}
+	if _, ok := parsed.Document["attributes"]; !ok {
+		t.Error("unknown fields are not kept in Document")
+	}
+}
+
+func TestParseProblems(t *testing.T) {
+	long := strings.Repeat("a", maxFieldLength+1)
+	tests := []struct {
+		name string
+		raw  []byte
+		want []string
+	}{
+		{"not JSON", []byte(`{"name":`), []string{"metadata is not a JSON object"}},
+		{"not an object", []byte(`["name"]`), []string{"metadata is not a JSON object"}},
+		{"null", []byte(`null`), []string{"metadata is not a JSON object"}},
+		{"no schema version", document(t, map[string]interface{}{"schema_version": nil}),
+			[]string{"schema_version is missing or not a number"}},
+		{"schema version string", document(t, map[string]interface{}{"schema_version": "1"}),
+			[]string{"schema_version is missing or not a number"}},
+		{"unsupported schema version", document(t, map[string]interface{}{"schema_version": 2}),
+			[]string{"schema_version 2 is not supported"}},
+		{"wrong field type", document(t, map[string]interface{}{"name": 7}),
+			[]string{"metadata does not match schema version 1: json: cannot unmarshal number into Go struct field Credential.name of type string"}},
+		{"missing required fields", document(t, map[string]interface{}{"name": nil, "institution": "  ", "degree": ""}),
+			[]string{"name is required", "institution is required", "degree is required"}},
+		{"fields too long", document(t, map[string]interface{}{"name": long, "degree": " " + long[1:] + " "}),
+			[]string{"name is longer than 255 characters"}},
+		{"no issue date", document(t, map[string]interface{}{"issue_date": nil}), []string{"issue_date is required"}},
+		{"issue date format", document(t, map[string]interface{}{"issue_date": "31/05/2024"}),
+			[]string{"issue_date is not a YYYY-MM-DD date"}},
+		{"issue date in the future", document(t, map[string]interface{}{"issue_date": "2024-06-02"}),
+			[]string{"issue_date is in the future"}},
+		{"no media URI", document(t, map[string]interface{}{"media_uri": nil}), []string{"media_uri is required"}},
+		{"media URI not IPFS", document(t, map[string]interface{}{"media_uri": "https://example.com/transcript.pdf"}),
+			[]string{"media_uri is not an IPFS URI"}},
+		{"no hash", document(t, map[string]interface{}{"hash": nil}),
+			[]string{"hash is not a 0x prefixed 32 byte hex string"}},
+		{"hash without prefix", document(t, map[string]interface{}{"hash": testHash[2:]}),
+			[]string{"hash is not a 0x prefixed 32 byte hex string"}},
+		{"hash too short", document(t, map[string]interface{}{"hash": testHash[:65]}),
+			[]string{"hash is not a 0x prefixed 32 byte hex string"}},
+		{"every problem at once", document(t, map[string]interface{}{"name": nil, "issue_date": "tomorrow", "media_uri": "ftp://x", "hash": "0x"}),
+			[]string{"name is required", "issue_date is not a YYYY-MM-DD date", "media_uri is not an IPFS URI", "hash is not a 0x prefixed 32 byte hex string"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, problems := Parse(tt.raw, testNow)
+			if !reflect.DeepEqual(problems, tt.want) {
+				t.Errorf("problems = %q, want %q", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDocumentOfInvalidObject(t *testing.T) {
+	parsed, problems := Parse(document(t, map[string]interface{}{"schema_version": 2}), testNow)
+	if len(problems) == 0 {
+		t.Fatal("unsupported schema version accepted")
+	}
+	if parsed.Document["name"] != "BSc Computer Science" {
+		t.Errorf("Document = %v, want the decoded object", parsed.Document)
+	}
+}
+
+func TestParseCanonicalizes(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   map[string]interface{}
+		wantDate string
+	}{
+		{
+			name:   "text fields are trimmed",
+			fields: map[string]interface{}{"name": "  BSc Computer Science\n", "institution": "\tExample University ", "degree": " Bachelor of Science"},
+		},
+		{
+			name:   "CIDv0 media URI becomes ipfs:// CIDv1",
+			fields: map[string]interface{}{"media_uri": testCIDv0},
+		},
+		{
+			name:   "gateway media URI becomes ipfs:// CIDv1",
+			fields: map[string]interface{}{"media_uri": "https://ipfs.io/ipfs/" + testCIDv0},
+		},
+		{
+			name:   "hash is lowercased",
+			fields: map[string]interface{}{"hash": "0x" + strings.ToUpper(testHash[2:])},
+		},
+		{
+			name:     "issue date today",
+			fields:   map[string]interface{}{"issue_date": "2024-06-01"},
+			wantDate: "2024-06-01",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, problems := Parse(document(t, tt.fields), testNow)
+			if len(problems) != 0 {
+				t.Fatalf("Parse reported %v", problems)
+			}
+			want := Credential{
+				SchemaVersion: 1,
+				Name:          "BSc Computer Science",
+				Institution:   "Example University",
+				Degree:        "Bachelor of Science",
+				IssueDate:     "2024-05-31",
+				MediaURI:      "ipfs://" + testCIDv1,
+				Hash:          testHash,
+			}
+			if tt.wantDate != "" {
+				want.IssueDate = tt.wantDate
+			}
+			if parsed.Credential != want {
+				t.Errorf("Credential = %+v, want %+v", parsed.Credential, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditAccessCheck      AuditAction = "access.check"      // A wallet opened a transcript
+	AuditAccessGrant      AuditAction = "access.grant"      // A student shared a transcript
+	AuditAccessDeny       AuditAction = "access.deny"       // A student denied a request
+	AuditCredentialRevoke AuditAction = "credential.revoke" // An institution revoked a credential
+	AuditLogin            AuditAction = "auth.login"        // A wallet signed in
+	AuditKeyRelease       AuditAction = "key.release"       // A wallet asked for a document key
+)
+
+type AuditOutcome string
+
+const (
+	AuditAllowed AuditOutcome = "allowed"
+	AuditDenied  AuditOutcome = "denied"
+	AuditSuccess AuditOutcome = "success"
+	AuditFailure AuditOutcome = "failure"
+)
+
+// AuditEntry is one record of the append-only audit log. Hash covers the entry and PrevHash,
+// the hash of the entry before it, so editing or deleting an entry breaks the chain.
+type AuditEntry struct {
+	ID            uint64            `gorm:"primaryKey;autoIncrement"`         // Position in the chain
+	Action        AuditAction       `gorm:"type:varchar(50);not null"`        // What happened
+	ActorWallet   string            `gorm:"type:varchar(255);not null"`       // Lower-cased wallet that acted, or system
+	SubjectWallet string            `gorm:"type:varchar(255);not null;index"` // Lower-cased wallet whose data was involved
+	Resource      string            `gorm:"type:text;not null"`               // Transcript, request or credential involved
+	Outcome       AuditOutcome      `gorm:"type:varchar(20);not null"`        // allowed, denied, success or failure
+	ClientIP      string            `gorm:"type:varchar(64)"`                 // Client address, empty for background jobs
+	UserAgent     string            `gorm:"type:text"`                        // Client user agent
+	Metadata      map[string]string `gorm:"type:jsonb;serializer:json"`       // Action specific details
+	CreatedAt     time.Time         `gorm:"not null"`                         // Set before hashing, microsecond precision
+	PrevHash      string            `gorm:"type:char(64);not null"`           // Hash of the previous entry
+	Hash          string            `gorm:"type:char(64);not null;uniqueIndex"`
+}
+
+type AuditAnchorStatus string
+
+const (
+	AnchorPending  AuditAnchorStatus = "pending"  // Built, not yet mined
+	AnchorAnchored AuditAnchorStatus = "anchored" // Root is on chain
+)
+
+// AuditAnchor is a Merkle root over a contiguous range of audit entries submitted to a chain.
+type AuditAnchor struct {
+	ID           uint64            `gorm:"primaryKey;autoIncrement"`
+	Root         string            `gorm:"type:char(66);not null;uniqueIndex"`                // 0x-prefixed Merkle root
+	FirstEntryID uint64            `gorm:"not null"`                                          // First audit entry covered
+	LastEntryID  uint64            `gorm:"not null;uniqueIndex"`                              // Last audit entry covered
+	Status       AuditAnchorStatus `gorm:"type:varchar(20);not null;default:'pending';index"` // pending or anchored
+	ChainID      string            `gorm:"type:varchar(78)"`                                  // Chain the root was submitted to
+	Contract     string            `gorm:"type:varchar(42)"`                                  // AuditAnchor contract address
+	TxHash       string            `gorm:"type:char(66)"`                                     // Anchoring transaction
+	BlockNumber  uint64            // Block the transaction was mined in
+	LastError    string            `gorm:"type:text"` // Error of the last failed submission
+	CreatedAt    time.Time         `gorm:"autoCreateTime"`
+	AnchoredAt   *time.Time
+}
+
+// AuditProof is the Merkle inclusion proof of one audit entry in its anchor.
+type AuditProof struct {
+	EntryID  uint64   `gorm:"primaryKey;autoIncrement:false"` // Proven audit entry
+	AnchorID uint64   `gorm:"not null;index"`                 // Anchor the entry is part of
+	Leaf     string   `gorm:"type:char(66);not null"`         // keccak256 of the entry hash
+	Proof    []string `gorm:"type:jsonb;serializer:json"`     // Sibling hashes from the leaf up
+
+	Anchor AuditAnchor `gorm:"foreignKey:AnchorID;constraint:OnDelete:CASCADE"`
+}
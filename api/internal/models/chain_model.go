@@ -0,0 +1,7 @@
+package models
+
+// ChainCursor remembers the last block a chain log consumer has processed.
+type ChainCursor struct {
+	Name        string `gorm:"primaryKey;type:varchar(100)"` // Consumer name
+	BlockNumber uint64 `gorm:"not null"`                     // Last fully processed block
+}
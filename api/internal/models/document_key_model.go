@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// DocumentKey is the wrapped data key of an encrypted document stored on IPFS.
+type DocumentKey struct {
+	CID         string    `gorm:"column:cid;type:text;primaryKey"`              // CID of the encrypted document
+	Algorithm   string    `gorm:"type:varchar(20);not null"`                    // Content encryption, e.g. AES-256-GCM
+	KMSKeyID    string    `gorm:"column:kms_key_id;type:varchar(255);not null"` // Master key that wrapped the data key
+	WrappedKey  []byte    `gorm:"type:bytea;not null"`                          // Data key encrypted by the KMS
+	OwnerWallet string    `gorm:"type:varchar(255);not null"`                   // Wallet that uploaded the document
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
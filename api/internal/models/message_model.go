@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// RequestMessage is a signed message in the discussion thread of a request.
+type RequestMessage struct {
+	ID           string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"` // Auto-generate UUID
+	RequestID    string     `gorm:"type:uuid;not null;index"`                       // Request the thread belongs to
+	SenderWallet string     `gorm:"type:varchar(255);not null"`                     // Student or recipient wallet
+	Body         string     `gorm:"type:text;not null"`                             // Message text
+	Signature    string     `gorm:"type:text;not null"`                             // Sender's signature over the message payload
+	CreatedAt    time.Time  `gorm:"autoCreateTime"`                                 // Creation timestamp
+	ReadAt       *time.Time // Set when the other party reads the message
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type MetadataStatus string
+
+const (
+	MetadataValid       MetadataStatus = "valid"
+	MetadataInvalid     MetadataStatus = "invalid"     // Fetched but malformed or not matching the schema
+	MetadataUnreachable MetadataStatus = "unreachable" // Could not be fetched, retried with backoff
+)
+
+// TranscriptMetadata is the indexed ERC-721 metadata of a transcript.
+type TranscriptMetadata struct {
+	TranscriptID  string                 `gorm:"primaryKey;type:text"`            // Transcript the metadata belongs to
+	MetadataURI   string                 `gorm:"type:text;not null"`              // Canonical URI the metadata was fetched from
+	Status        MetadataStatus         `gorm:"type:varchar(20);not null;index"` // valid, invalid or unreachable
+	Problems      []string               `gorm:"type:jsonb;serializer:json"`      // Why the metadata is invalid or unreachable
+	SchemaVersion int                    `gorm:"not null;default:0"`              // Credential metadata schema version, 0 when unknown
+	Name          string                 `gorm:"type:varchar(255)"`               // Credential name
+	Institution   string                 `gorm:"type:varchar(255);index"`         // Issuing institution
+	Degree        string                 `gorm:"type:varchar(255);index"`         // Degree or qualification
+	IssueDate     *time.Time             `gorm:"type:date;index"`                 // Date the credential was issued
+	MediaURI      string                 `gorm:"type:text"`                       // Canonical URI of the credential file
+	MediaHash     string                 `gorm:"type:varchar(66)"`                // keccak256 of the credential file, 0x prefixed
+	Document      map[string]interface{} `gorm:"type:jsonb;serializer:json"`      // The metadata as fetched, when it is a JSON object
+	Attempts      int                    `gorm:"not null;default:0"`              // Failed fetches in a row
+	NextFetchAt   *time.Time             `gorm:"index"`                           // When an unreachable document is fetched again
+	FetchedAt     time.Time              `gorm:"not null"`                        // Last fetch attempt
+	UpdatedAt     time.Time              `gorm:"autoUpdateTime"`
+}
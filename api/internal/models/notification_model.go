@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Email notification types a wallet can opt in or out of
+const (
+	NotifyRequestCreated    = "request.created"
+	NotifyRequestExpiring   = "request.expiring"
+	NotifyCredentialRevoked = "credential.revoked"
+)
+
+var NotificationTypes = []string{NotifyRequestCreated, NotifyRequestExpiring, NotifyCredentialRevoked}
+
+// WalletEmail is the optional notification email of a wallet.
+type WalletEmail struct {
+	Wallet      string          `gorm:"type:varchar(255);primaryKey"` // Lower-cased wallet address
+	Email       string          `gorm:"type:varchar(320);not null"`   // Notification address
+	VerifiedAt  *time.Time      // Set once the verification link was opened
+	Preferences map[string]bool `gorm:"type:jsonb;serializer:json"` // Per notification type, missing means enabled
+	CreatedAt   time.Time       `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time       `gorm:"autoUpdateTime"`
+}
+
+// Wants reports whether the wallet has a verified address and has not opted out of the type.
+func (w WalletEmail) Wants(notificationType string) bool {
+	if w.VerifiedAt == nil {
+		return false
+	}
+	enabled, ok := w.Preferences[notificationType]
+	return !ok || enabled
+}
+
+// EmailNotification records a sent notification so that it is sent only once.
+type EmailNotification struct {
+	ID        uint      `gorm:"primaryKey"`
+	Wallet    string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_email_notification"` // Recipient wallet
+	Type      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_email_notification"`  // Notification type
+	Reference string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_email_notification"` // Request or token ID
+	SentAt    time.Time `gorm:"autoCreateTime"`
+}
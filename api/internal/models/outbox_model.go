@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxPublished OutboxStatus = "published"
+	OutboxDead      OutboxStatus = "dead" // Gave up after the last retry
+)
+
+// OutboxEvent is a side effect of a database change, written in the same transaction as the
+// change and published to the registered sinks by the relay.
+type OutboxEvent struct {
+	ID             uint64                 `gorm:"primaryKey;autoIncrement"`                          // Publication order
+	EventID        string                 `gorm:"type:uuid;not null;uniqueIndex"`                    // Stable ID sinks can deduplicate on
+	AggregateID    string                 `gorm:"type:varchar(255);not null;index"`                  // Events of the same aggregate are published in order
+	Type           string                 `gorm:"type:varchar(50);not null"`                         // e.g. request.approved
+	Audience       []string               `gorm:"type:jsonb;serializer:json"`                        // Wallets the event concerns
+	Payload        map[string]interface{} `gorm:"type:jsonb;serializer:json"`                        // Event data
+	Status         OutboxStatus           `gorm:"type:varchar(20);not null;default:'pending';index"` // pending, published or dead
+	DeliveredSinks []string               `gorm:"type:jsonb;serializer:json"`                        // Sinks that already accepted the event
+	Attempts       int                    `gorm:"not null;default:0"`                                // Failed publish attempts so far
+	NextAttemptAt  time.Time              `gorm:"not null;index"`                                    // When the relay should try next
+	LastError      string                 `gorm:"type:text"`                                         // Error of the last failed attempt
+	CreatedAt      time.Time              `gorm:"autoCreateTime"`
+	PublishedAt    *time.Time             // When every sink accepted the event
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Pin tracks a pin request for a transcript file at one pinning service.
+type Pin struct {
+	ID          uint64     `gorm:"primaryKey;autoIncrement"`
+	CID         string     `gorm:"column:cid;type:text;not null;uniqueIndex:idx_pin_cid_service"` // Pinned content
+	Service     string     `gorm:"type:varchar(100);not null;uniqueIndex:idx_pin_cid_service"`    // Pinning service name
+	OwnerWallet string     `gorm:"type:varchar(255);not null"`                                    // Owner of the transcript the content belongs to
+	RequestID   string     `gorm:"type:varchar(255);not null"`                                    // Pin request ID at the service
+	Status      string     `gorm:"type:varchar(20);not null"`                                     // queued, pinning, pinned or failed, as last reported
+	Failures    int        `gorm:"not null;default:0"`                                            // Consecutive times the pin was found lost
+	LastError   string     `gorm:"type:text"`                                                     // Why the pin was last found lost or could not be checked
+	CheckedAt   time.Time  `gorm:"not null"`                                                      // Last time the status was fetched
+	PinnedAt    *time.Time // Since when the service reports the content pinned
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
+}
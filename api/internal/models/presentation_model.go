@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Presentation is the Verifiable Presentation a student signed for an approved request,
+// bundling the shared credentials for the recipient.
+type Presentation struct {
+	RequestID string    `gorm:"type:uuid;primaryKey"`      // Request the presentation answers, its challenge
+	Holder    string    `gorm:"type:varchar(42);not null"` // Student wallet that signed it
+	Document  string    `gorm:"type:text;not null"`        // The signed presentation as JSON-LD, served as is
+	SignedAt  time.Time `gorm:"not null"`                  // When the proof was attached
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
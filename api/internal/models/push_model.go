@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// PushSubscription is a browser Web Push subscription registered by a wallet session.
+type PushSubscription struct {
+	ID         string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"` // Auto-generate UUID
+	Wallet     string     `gorm:"type:varchar(255);not null;index"`               // Lower-cased wallet address
+	Endpoint   string     `gorm:"type:text;not null;uniqueIndex"`                 // Push service URL
+	P256dh     string     `gorm:"type:text;not null"`                             // Browser public key, base64url
+	Auth       string     `gorm:"type:text;not null"`                             // Browser auth secret, base64url
+	UserAgent  string     `gorm:"type:text"`                                      // Browser that subscribed
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+	LastPushAt *time.Time // Last successful delivery
+	Failures   int        `gorm:"not null;default:0"` // Consecutive failed deliveries
+}
+
+// VAPIDKeyRecord stores the application server key shared by all API replicas.
+type VAPIDKeyRecord struct {
+	ID         uint      `gorm:"primaryKey"`
+	KMSKeyID   string    `gorm:"column:kms_key_id;type:varchar(255);not null"` // Master key that wrapped the private key
+	WrappedKey []byte    `gorm:"type:bytea;not null"`                          // Private scalar encrypted by the KMS
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
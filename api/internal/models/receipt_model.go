@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ReceiptKey stores a key verification receipts were signed with. Keys are never deleted, so
+// receipts signed before a rotation stay verifiable against the published key set.
+type ReceiptKey struct {
+	KeyID      string    `gorm:"type:varchar(64);primaryKey"`                  // RFC 7638 JWK thumbprint, the kid of receipts
+	PublicKey  string    `gorm:"type:text;not null"`                           // Public JWK as published
+	KMSKeyID   string    `gorm:"column:kms_key_id;type:varchar(255);not null"` // Master key that wrapped the private key
+	WrappedKey []byte    `gorm:"type:bytea;not null"`                          // Private scalar encrypted by the KMS
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// VerificationReceipt is a signed receipt issued to the recipient of an approved request. The
+// latest receipt of a transcript is served again while the verification outcome it asserts
+// holds; a new one is issued when it changes, and earlier ones are kept.
+type VerificationReceipt struct {
+	ID           string    `gorm:"type:uuid;primaryKey"`                                      // jti of the receipt
+	RequestID    string    `gorm:"type:uuid;not null;index:idx_verification_receipt"`         // Request the transcript was shared through
+	TranscriptID string    `gorm:"type:varchar(255);not null;index:idx_verification_receipt"` // Token ID of the verified credential
+	Document     string    `gorm:"type:text;not null"`                                        // Signed receipt, compact JWS
+	Outcome      string    `gorm:"type:char(64);not null"`                                    // Hex SHA-256 of the asserted outcome, see receipt.Claims.Outcome
+	CreatedAt    time.Time `gorm:"autoCreateTime;index:idx_verification_receipt"`
+}
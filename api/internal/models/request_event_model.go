@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type RequestEventType string
+
+const (
+	EventRequestCreated  RequestEventType = "request.created"
+	EventRequestApproved RequestEventType = "request.approved"
+	EventRequestDenied   RequestEventType = "request.denied"
+	EventRequestExpired  RequestEventType = "request.expired"
+	EventMessageSent     RequestEventType = "message.sent"
+	EventMessageRead     RequestEventType = "message.read"
+)
+
+// RequestEvent is an entry in the history of a request.
+type RequestEvent struct {
+	ID          uint                   `gorm:"primaryKey"`                 // Monotonic event ID
+	RequestID   string                 `gorm:"type:uuid;not null;index"`   // Request the event belongs to
+	Type        RequestEventType       `gorm:"type:varchar(50);not null"`  // Event type, e.g. request.approved
+	ActorWallet string                 `gorm:"type:varchar(255);not null"` // Wallet that caused the event
+	Data        map[string]interface{} `gorm:"type:jsonb;serializer:json"` // Event specific details
+	CreatedAt   time.Time              `gorm:"autoCreateTime;not null"`    // Event timestamp
+}
@@ -8,18 +8,20 @@ const (
 	Pending  RequestStatus = "pending"
 	Approved RequestStatus = "approved"
 	Denied   RequestStatus = "denied"
+	Expired  RequestStatus = "expired"
 )
 
 // Request represents a request to access a student's transcript.
 type Request struct {
 	ID              string        `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"` // Auto-generate UUID
-	StudentWallet   string        `gorm:"type:varchar(255);not null"`                     // Owner of the transcript
-	RecipientWallet string        `gorm:"type:varchar(255);not null"`                     // Requesting user
-	Status          RequestStatus `gorm:"type:varchar(50);not null;default:'pending'"`    // Status: pending, approved, denied
+	StudentWallet   string        `gorm:"type:varchar(255);not null;index"`               // Owner of the transcript
+	RecipientWallet string        `gorm:"type:varchar(255);not null;index"`               // Requesting user
+	Status          RequestStatus `gorm:"type:varchar(50);not null;default:'pending'"`    // Status: pending, approved, denied, expired
 	Reason          string        `gorm:"type:text"`                                      // Reason for denial
 	CreatedAt       time.Time     `gorm:"autoCreateTime"`                                 // Creation timestamp
 	UpdatedAt       time.Time     `gorm:"autoUpdateTime"`                                 // Update timestamp
 	ExpiryTimestamp time.Time     `gorm:"not null"`                                       // Expiry timestamp
+	Version         int           `gorm:"not null;default:1"`                             // Optimistic locking version, bumped on every change
 
 	Transcripts []RequestTranscript `gorm:"foreignKey:RequestID;constraint:OnDelete:CASCADE"` // Related transcripts
 }
@@ -37,3 +39,10 @@ type Transcript struct {
 	IPFSURIMediaHash string `gorm:"column:ipfs_uri_mediahash;type:text;unique;not null"` // Actual content URI (e.g., PDF, image) on IPFS
 	OwnerWallet      string `gorm:"type:varchar(255);not null"`                          // Owner of the transcript
 }
+
+// RequestView records when a wallet last opened a request, used to derive unread flags.
+type RequestView struct {
+	RequestID    string    `gorm:"type:uuid;primaryKey"`         // Viewed request
+	Wallet       string    `gorm:"type:varchar(255);primaryKey"` // Viewing wallet
+	LastViewedAt time.Time `gorm:"not null"`                     // Time of the latest view
+}
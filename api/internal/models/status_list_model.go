@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// StatusList is a W3C Bitstring Status List of credential revocations. Document is republished
+// whenever a credential in the list is revoked.
+type StatusList struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement"`
+	NextIndex   int        `gorm:"not null;default:0"` // Next free index in the list
+	Document    string     `gorm:"type:text"`          // Signed status list credential as a vc+jwt, empty until published
+	PublishedAt *time.Time // When Document was last signed
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
+}
+
+// StatusListEntry is the index a credential was assigned in a status list.
+type StatusListEntry struct {
+	TokenID     string    `gorm:"type:text;primaryKey"`                       // Token ID of the credential
+	ListID      uint      `gorm:"not null;uniqueIndex:idx_status_list_entry"` // Status list holding the entry
+	StatusIndex int       `gorm:"not null;uniqueIndex:idx_status_list_entry"` // Bit of the credential in the list
+	Revoked     bool      `gorm:"not null;default:false"`                     // Revoked on chain
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
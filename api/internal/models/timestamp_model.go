@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type TimestampSubject string
+
+const (
+	TimestampApproval TimestampSubject = "approval" // A student's approval of a request
+	TimestampReceipt  TimestampSubject = "receipt"  // A verification receipt issued for a request
+)
+
+// Timestamp is an RFC 3161 time-stamp token over the SHA-256 of Document, proving the document
+// existed no later than GenTime by the clock of Authority rather than our own.
+type Timestamp struct {
+	ID           uint             `gorm:"primaryKey"`
+	RequestID    string           `gorm:"type:uuid;not null;uniqueIndex:idx_timestamp_subject"`         // Request the document belongs to
+	Subject      TimestampSubject `gorm:"type:varchar(20);not null;uniqueIndex:idx_timestamp_subject"`  // approval or receipt
+	SubjectID    string           `gorm:"type:varchar(255);not null;uniqueIndex:idx_timestamp_subject"` // Request event ID of an approval, ID of a receipt
+	Document     string           `gorm:"type:text;not null"`                                           // Exact bytes that were hashed
+	Digest       string           `gorm:"type:char(64);not null"`                                       // Hex SHA-256 of Document
+	Authority    string           `gorm:"type:varchar(255);not null"`                                   // Time-stamp authority that issued Token
+	SerialNumber string           `gorm:"type:varchar(255);not null"`                                   // Serial number of the token at the authority
+	GenTime      time.Time        `gorm:"not null"`                                                     // Time asserted by the authority
+	Token        []byte           `gorm:"not null"`                                                     // DER TimeStampToken
+	CreatedAt    time.Time        `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// VerifiableCredential is the W3C Verifiable Credential export of an NFT credential, stored once
+// its issuer signed it. Signing again replaces it, e.g. after the NFT changed hands.
+type VerifiableCredential struct {
+	TokenID   string    `gorm:"type:text;primaryKey"`      // Token ID of the credential
+	Issuer    string    `gorm:"type:varchar(42);not null"` // Wallet that signed the proof
+	Document  string    `gorm:"type:text;not null"`        // The signed credential as JSON-LD, served as is
+	SignedAt  time.Time `gorm:"not null"`                  // When the proof was attached
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
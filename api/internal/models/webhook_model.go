@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryDead      WebhookDeliveryStatus = "dead" // Gave up after the last retry, kept as the dead-letter queue
+)
+
+// WebhookEndpoint is a URL a wallet registered to receive lifecycle events.
+type WebhookEndpoint struct {
+	ID          string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"` // Auto-generate UUID
+	OwnerWallet string    `gorm:"type:varchar(255);not null;index"`               // Wallet that registered the endpoint
+	URL         string    `gorm:"type:text;not null"`                             // Delivery URL
+	Secret      string    `gorm:"type:text;not null"`                             // HMAC signing secret
+	EventTypes  []string  `gorm:"type:jsonb;serializer:json"`                     // Subscribed event types, empty for all
+	Active      bool      `gorm:"not null;default:true"`                          // Inactive endpoints receive nothing
+	CreatedAt   time.Time `gorm:"autoCreateTime"`                                 // Creation timestamp
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`                                 // Update timestamp
+}
+
+// WebhookDelivery is one event queued for one endpoint.
+type WebhookDelivery struct {
+	ID             string                `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`    // Auto-generate UUID
+	EndpointID     string                `gorm:"type:uuid;not null;uniqueIndex:idx_delivery_event"` // Target endpoint
+	EventID        string                `gorm:"type:uuid;not null;uniqueIndex:idx_delivery_event"` // Shared by every delivery of the same event
+	EventType      string                `gorm:"type:varchar(50);not null"`                         // e.g. request.approved
+	Payload        string                `gorm:"type:text;not null"`                                // JSON body sent to the endpoint
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(20);not null;default:'pending';index"` // pending, succeeded or dead
+	Attempts       int                   `gorm:"not null;default:0"`                                // Delivery attempts so far
+	NextAttemptAt  time.Time             `gorm:"not null;index"`                                    // When the worker should try next
+	LastStatusCode int                   // HTTP status of the last attempt, 0 on network errors
+	LastError      string                `gorm:"type:text"` // Error of the last failed attempt
+	CreatedAt      time.Time             `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time             `gorm:"autoUpdateTime"`
+
+	Endpoint WebhookEndpoint `gorm:"foreignKey:EndpointID;constraint:OnDelete:CASCADE"`
+}
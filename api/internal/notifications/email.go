@@ -0,0 +1,212 @@
+package notifications
+
+import (
+	"api/internal/initializers"
+	"api/internal/mail"
+	"api/internal/models"
+	"api/internal/pinning"
+	"api/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const sendTimeout = 30 * time.Second
+
+// SetEmail stores an unverified address for the wallet and sends it a verification link.
+// Changing the address resets verification and invalidates earlier links.
+func SetEmail(db *gorm.DB, wallet, email string) (models.WalletEmail, error) {
+	record := models.WalletEmail{Wallet: strings.ToLower(wallet), Email: email}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "wallet"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"email": email, "verified_at": nil, "updated_at": time.Now()}),
+	}).Create(&record).Error; err != nil {
+		return record, err
+	}
+	if err := db.First(&record, "wallet = ?", record.Wallet).Error; err != nil {
+		return record, err
+	}
+
+	expiresAt := time.Now().Add(constants.EmailVerificationTimeout * time.Minute)
+	token, err := SignVerificationToken(record.Wallet, email, expiresAt)
+	if err != nil {
+		return record, err
+	}
+	link := strings.TrimRight(os.Getenv("API_PUBLIC_URL"), "/") + "/v1/email/verify?token=" + url.QueryEscape(token)
+
+	message, err := mail.Render(mail.TemplateVerifyEmail, email, map[string]interface{}{
+		"Wallet":    wallet,
+		"Link":      link,
+		"ExpiresAt": expiresAt,
+	})
+	if err != nil {
+		return record, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	return record, initializers.Mailer.Send(ctx, message)
+}
+
+// VerifyEmail marks the address in the token as verified if it is still the wallet's address.
+func VerifyEmail(db *gorm.DB, token string) (models.WalletEmail, error) {
+	wallet, email, err := ParseVerificationToken(token, time.Now())
+	if err != nil {
+		return models.WalletEmail{}, err
+	}
+
+	var record models.WalletEmail
+	if err := db.First(&record, "wallet = ? AND email = ?", wallet, email).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return record, ErrInvalidVerificationToken
+		}
+		return record, err
+	}
+	if record.VerifiedAt == nil {
+		now := time.Now()
+		record.VerifiedAt = &now
+		if err := db.Model(&record).Update("verified_at", now).Error; err != nil {
+			return record, err
+		}
+	}
+	return record, nil
+}
+
+func GetEmail(db *gorm.DB, wallet string) (models.WalletEmail, error) {
+	var record models.WalletEmail
+	err := db.First(&record, "wallet = ?", strings.ToLower(wallet)).Error
+	return record, err
+}
+
+func DeleteEmail(db *gorm.DB, wallet string) error {
+	return db.Where("wallet = ?", strings.ToLower(wallet)).Delete(&models.WalletEmail{}).Error
+}
+
+// UpdatePreferences merges the given per-type preferences into the wallet's settings.
+func UpdatePreferences(db *gorm.DB, wallet string, preferences map[string]bool) (models.WalletEmail, error) {
+	record, err := GetEmail(db, wallet)
+	if err != nil {
+		return record, err
+	}
+	if record.Preferences == nil {
+		record.Preferences = make(map[string]bool)
+	}
+	for notificationType, enabled := range preferences {
+		record.Preferences[notificationType] = enabled
+	}
+	err = db.Model(&record).Updates(models.WalletEmail{Preferences: record.Preferences}).Error
+	return record, err
+}
+
+// send emails the wallet once per notification type and reference, if it opted in. A failed
+// attempt is forgotten and returned, so that the caller can retry it.
+func send(db *gorm.DB, wallet, notificationType, reference, template string, data map[string]interface{}) error {
+	record, err := GetEmail(db, wallet)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !record.Wants(notificationType) {
+		return nil
+	}
+
+	sent := models.EmailNotification{Wallet: record.Wallet, Type: notificationType, Reference: reference}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&sent)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil // Already sent
+	}
+
+	data["Wallet"] = wallet
+	message, err := mail.Render(template, record.Email, data)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = initializers.Mailer.Send(ctx, message)
+		cancel()
+	}
+	if err != nil {
+		db.Delete(&sent)
+		return fmt.Errorf("sending %s email: %w", notificationType, err)
+	}
+	return nil
+}
+
+// RequestCreated tells the student about a new request for their transcripts.
+func RequestCreated(db *gorm.DB, request models.Request) error {
+	return send(db, request.StudentWallet, models.NotifyRequestCreated, request.ID, mail.TemplateRequestCreated, map[string]interface{}{
+		"Counterparty": request.RecipientWallet,
+		"RequestID":    request.ID,
+		"ExpiresAt":    request.ExpiryTimestamp,
+	})
+}
+
+// RequestExpiring reminds the student of a pending request that expires soon.
+func RequestExpiring(db *gorm.DB, request models.Request) error {
+	return send(db, request.StudentWallet, models.NotifyRequestExpiring, request.ID, mail.TemplateRequestExpiring, map[string]interface{}{
+		"Counterparty": request.RecipientWallet,
+		"RequestID":    request.ID,
+		"ExpiresAt":    request.ExpiryTimestamp,
+	})
+}
+
+// CredentialRevoked tells everyone holding or sharing the credential that it was revoked. Every
+// wallet is tried; wallets already emailed are skipped when it is called again.
+func CredentialRevoked(db *gorm.DB, audience []string, tokenID, institution, reason, txHash string) error {
+	var errs []error
+	for _, wallet := range audience {
+		errs = append(errs, send(db, wallet, models.NotifyCredentialRevoked, tokenID, mail.TemplateCredentialRevoked, map[string]interface{}{
+			"TokenID":     tokenID,
+			"Institution": institution,
+			"Reason":      reason,
+			"TxHash":      txHash,
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// PinLost emails PINNING_ALERT_EMAIL, if set, that a pinning service lost a transcript file.
+func PinLost(ctx context.Context, alert pinning.Alert) {
+	to := os.Getenv("PINNING_ALERT_EMAIL")
+	if to == "" {
+		return
+	}
+	message, err := mail.Render(mail.TemplatePinLost, to, map[string]interface{}{
+		"Wallet":   alert.OwnerWallet,
+		"CID":      alert.CID,
+		"Service":  alert.Service,
+		"Reason":   alert.Reason,
+		"Pinned":   alert.Pinned,
+		"Services": alert.Services,
+	})
+	if err == nil {
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err = initializers.Mailer.Send(sendCtx, message)
+		cancel()
+	}
+	if err != nil {
+		log.Printf("Failed to send pin alert email: %v", err)
+	}
+}
+
+// RemindExpiring reminds students of every pending request expiring within the window.
+func RemindExpiring(db *gorm.DB, now time.Time, window time.Duration) error {
+	var requests []models.Request
+	if err := db.Where("status = ? AND expiry_timestamp > ? AND expiry_timestamp <= ?", models.Pending, now, now.Add(window)).
+		Find(&requests).Error; err != nil {
+		return err
+	}
+	var errs []error
+	for _, request := range requests {
+		errs = append(errs, RequestExpiring(db, request))
+	}
+	return errors.Join(errs...)
+}
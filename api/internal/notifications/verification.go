@@ -0,0 +1,62 @@
+package notifications
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+type verificationClaims struct {
+	Wallet    string `json:"w"`
+	Email     string `json:"e"`
+	ExpiresAt int64  `json:"x"`
+}
+
+func linkSecret() []byte {
+	return []byte(os.Getenv("EMAIL_LINK_SECRET"))
+}
+
+// SignVerificationToken returns the token embedded in the verification link sent to email.
+func SignVerificationToken(wallet, email string, expiresAt time.Time) (string, error) {
+	payload, err := json.Marshal(verificationClaims{Wallet: wallet, Email: email, ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(encoded)), nil
+}
+
+// ParseVerificationToken checks the token signature and expiry and returns the wallet and email it was issued for.
+func ParseVerificationToken(token string, now time.Time) (string, string, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return "", "", ErrInvalidVerificationToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, sign(encoded)) {
+		return "", "", ErrInvalidVerificationToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", ErrInvalidVerificationToken
+	}
+	var claims verificationClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || now.Unix() > claims.ExpiresAt {
+		return "", "", ErrInvalidVerificationToken
+	}
+	return claims.Wallet, claims.Email, nil
+}
+
+func sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, linkSecret())
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
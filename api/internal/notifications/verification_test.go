@@ -0,0 +1,56 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerificationToken(t *testing.T) {
+	t.Setenv("EMAIL_LINK_SECRET", "test-secret")
+	now := time.Now()
+	token, err := SignVerificationToken("0xwallet", "student@example.com", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("SignVerificationToken: %v", err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+	other, err := SignVerificationToken("0xother", "student@example.com", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("SignVerificationToken: %v", err)
+	}
+	otherPayload, _, _ := strings.Cut(other, ".")
+
+	tests := []struct {
+		name   string
+		token  string
+		now    time.Time
+		secret string
+		valid  bool
+	}{
+		{"valid token", token, now, "test-secret", true},
+		{"expired", token, now.Add(2 * time.Hour), "test-secret", false},
+		{"signed with another secret", token, now, "rotated-secret", false},
+		{"payload swapped", otherPayload + "." + signature, now, "test-secret", false},
+		{"no signature", payload, now, "test-secret", false},
+		{"garbled signature", payload + ".!!", now, "test-secret", false},
+		{"empty", "", now, "test-secret", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EMAIL_LINK_SECRET", tt.secret)
+			wallet, email, err := ParseVerificationToken(tt.token, tt.now)
+			if !tt.valid {
+				if err != ErrInvalidVerificationToken {
+					t.Fatalf("err = %v, want ErrInvalidVerificationToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVerificationToken: %v", err)
+			}
+			if wallet != "0xwallet" || email != "student@example.com" {
+				t.Errorf("got %s, %s", wallet, email)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package outbox
+
+import (
+	"api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Enqueue stores an event in the outbox. Pass the transaction of the change that caused the
+// event so the event exists if and only if the change committed. Events with the same
+// aggregateID (a request ID, or "credential:<token ID>") reach every sink in the order they
+// were enqueued.
+func Enqueue(db *gorm.DB, aggregateID, eventType string, audience []string, payload map[string]interface{}) error {
+	event := models.OutboxEvent{
+		EventID:       uuid.New().String(),
+		AggregateID:   aggregateID,
+		Type:          eventType,
+		Audience:      audience,
+		Payload:       payload,
+		Status:        models.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}
+	return db.Create(&event).Error
+}
+
+// CredentialAggregate is the aggregate ID of events about an on-chain credential.
+func CredentialAggregate(tokenID string) string {
+	return "credential:" + tokenID
+}
@@ -0,0 +1,145 @@
+package outbox
+
+import (
+	"api/internal/models"
+	"api/pkg/constants"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+// Relay publishes outbox events to its sinks. Only the oldest pending event of each aggregate
+// can be claimed, so events of one aggregate are published in order; a failing event holds back
+// the rest of its aggregate until it is published or given up on. Several relays may run
+// against the same database.
+type Relay struct {
+	DB           *gorm.DB
+	Sinks        []Sink
+	BatchSize    int
+	PollInterval time.Duration
+	Lease        time.Duration // How long a claimed event is hidden from other relays
+}
+
+func NewRelay(db *gorm.DB, sinks ...Sink) *Relay {
+	return &Relay{
+		DB:           db,
+		Sinks:        sinks,
+		BatchSize:    100,
+		PollInterval: time.Second,
+		Lease:        time.Minute,
+	}
+}
+
+// Run publishes due events until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+	var lastPrune time.Time
+	for {
+		// Keep going while there is work, each pass can unblock the next event of an aggregate
+		for {
+			events, err := r.claim()
+			if err != nil {
+				log.Printf("Failed to claim outbox events: %v", err)
+				break
+			}
+			if len(events) == 0 {
+				break
+			}
+			for _, event := range events {
+				r.publish(ctx, event)
+			}
+		}
+
+		if time.Since(lastPrune) > time.Hour {
+			if err := r.prune(); err != nil {
+				log.Printf("Failed to prune outbox: %v", err)
+			}
+			lastPrune = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim leases the due head event of each aggregate by pushing its next attempt forward, so
+// other relays skip it while it is in flight and it is retried if this process dies.
+func (r *Relay) claim() ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, time.Now()).
+			Where("NOT EXISTS (SELECT 1 FROM outbox_events earlier WHERE earlier.aggregate_id = outbox_events.aggregate_id AND earlier.status = ? AND earlier.id < outbox_events.id)", models.OutboxPending).
+			Order("id ASC").
+			Limit(r.BatchSize).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		ids := make([]uint64, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", time.Now().Add(r.Lease)).Error
+	})
+	return events, err
+}
+
+// publish hands the event to every sink that has not accepted it yet and records the outcome.
+func (r *Relay) publish(ctx context.Context, event models.OutboxEvent) {
+	var errs []error
+	for _, sink := range r.Sinks {
+		if contains(event.DeliveredSinks, sink.Name()) {
+			continue
+		}
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		event.DeliveredSinks = append(event.DeliveredSinks, sink.Name())
+	}
+
+	updates := models.OutboxEvent{DeliveredSinks: event.DeliveredSinks}
+	if err := errors.Join(errs...); err == nil {
+		now := time.Now()
+		updates.Status = models.OutboxPublished
+		updates.PublishedAt = &now
+	} else {
+		updates.Attempts = event.Attempts + 1
+		updates.LastError = err.Error()
+		updates.NextAttemptAt = time.Now().Add(Backoff(updates.Attempts))
+		if updates.Attempts >= constants.OutboxMaxAttempts {
+			updates.Status = models.OutboxDead
+			log.Printf("Giving up on outbox event %s (%s): %v", event.EventID, event.Type, err)
+		}
+	}
+	if err := r.DB.Model(&event).Updates(updates).Error; err != nil {
+		log.Printf("Failed to record outbox event %s: %v", event.EventID, err)
+	}
+}
+
+// prune deletes published events past the retention period.
+func (r *Relay) prune() error {
+	cutoff := time.Now().Add(-constants.OutboxRetention * time.Hour)
+	return r.DB.Where("status = ? AND published_at < ?", models.OutboxPublished, cutoff).
+		Delete(&models.OutboxEvent{}).Error
+}
+
+// Backoff returns the delay before the next attempt after the given number of failures.
+func Backoff(attempts int) time.Duration {
+	delay := time.Duration(constants.OutboxBaseBackoff) * time.Second
+	max := time.Duration(constants.OutboxMaxBackoff) * time.Second
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
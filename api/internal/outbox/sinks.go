@@ -0,0 +1,73 @@
+package outbox
+
+import (
+	"api/internal/models"
+	"api/internal/realtime"
+	"api/internal/webhooks"
+	"context"
+	"gorm.io/gorm"
+)
+
+// Sink receives outbox events from the relay. Delivery is at-least-once: an event is handed to
+// a sink again if the relay dies before recording that the sink accepted it, so sinks should
+// tolerate duplicates, ideally by deduplicating on EventID.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event models.OutboxEvent) error
+}
+
+// WebhookSink queues webhook deliveries for the endpoints subscribed to the event. Events that
+// endpoints cannot subscribe to are skipped.
+type WebhookSink struct {
+	DB *gorm.DB
+}
+
+func (s WebhookSink) Name() string {
+	return "webhooks"
+}
+
+func (s WebhookSink) Publish(ctx context.Context, event models.OutboxEvent) error {
+	if !webhooks.IsEventType(event.Type) {
+		return nil
+	}
+	return webhooks.Publish(s.DB.WithContext(ctx), event.EventID, event.Type, event.CreatedAt, event.Audience, event.Payload)
+}
+
+// RealtimeSink appends the event to the Redis stream of every wallet in the audience, from
+// where it reaches connected SSE and WebSocket clients.
+type RealtimeSink struct{}
+
+func (RealtimeSink) Name() string {
+	return "realtime"
+}
+
+func (RealtimeSink) Publish(ctx context.Context, event models.OutboxEvent) error {
+	return realtime.Publish(event.Audience, event.Type, event.Payload)
+}
+
+// Subscriber is an in-process sink that calls Handler for the listed event types.
+type Subscriber struct {
+	ID      string   // Unique name, used to track which subscribers accepted an event
+	Types   []string // Event types to handle, empty for all
+	Handler func(ctx context.Context, event models.OutboxEvent) error
+}
+
+func (s Subscriber) Name() string {
+	return "subscriber:" + s.ID
+}
+
+func (s Subscriber) Publish(ctx context.Context, event models.OutboxEvent) error {
+	if len(s.Types) > 0 && !contains(s.Types, event.Type) {
+		return nil
+	}
+	return s.Handler(ctx, event)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package pinning
+
+import (
+	"api/internal/ipfs"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/ipfs/go-cid"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LocalService is an in-memory pinning service for tests and local development. When IPFS is
+// set, pins are made on that node and fail if the node cannot pin the content; otherwise every
+// pin succeeds. It also serves the Pinning Service API over HTTP so ServiceClient can be pointed
+// at it.
+type LocalService struct {
+	ServiceName string
+	IPFS        *ipfs.Client
+	Token       string // Bearer token required by ServeHTTP, none when empty
+
+	mu   sync.Mutex
+	pins map[string]PinStatus
+}
+
+func NewLocalService(name string, client *ipfs.Client) *LocalService {
+	return &LocalService{ServiceName: name, IPFS: client, pins: make(map[string]PinStatus)}
+}
+
+func (s *LocalService) Name() string {
+	return s.ServiceName
+}
+
+func (s *LocalService) Pin(ctx context.Context, id cid.Cid, name string) error {
+	_, err := s.Add(ctx, Pin{CID: id.String(), Name: name})
+	return err
+}
+
+// Add pins synchronously, so the returned request is already pinned or failed.
+func (s *LocalService) Add(ctx context.Context, pin Pin) (PinStatus, error) {
+	requestID := make([]byte, 16)
+	if _, err := rand.Read(requestID); err != nil {
+		return PinStatus{}, err
+	}
+	status := PinStatus{
+		RequestID: hex.EncodeToString(requestID),
+		Status:    StatusPinned,
+		Created:   time.Now().UTC(),
+		Pin:       pin,
+		Delegates: []string{},
+	}
+
+	id, err := cid.Decode(pin.CID)
+	if err != nil {
+		status.Status, status.Info = StatusFailed, map[string]string{"error": err.Error()}
+	} else if s.IPFS != nil {
+		if err := s.IPFS.Pin(ctx, id); err != nil {
+			status.Status, status.Info = StatusFailed, map[string]string{"error": err.Error()}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pins[status.RequestID] = status
+	return status, nil
+}
+
+func (s *LocalService) Get(_ context.Context, requestID string) (PinStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.pins[requestID]
+	if !ok {
+		return PinStatus{}, ErrPinNotFound
+	}
+	return status, nil
+}
+
+func (s *LocalService) Remove(_ context.Context, requestID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pins[requestID]; !ok {
+		return ErrPinNotFound
+	}
+	delete(s.pins, requestID)
+	return nil
+}
+
+// Drop marks every pin of the CID failed, as if the service had lost the content.
+func (s *LocalService) Drop(rawCID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for requestID, status := range s.pins {
+		if status.Pin.CID == rawCID {
+			status.Status = StatusFailed
+			s.pins[requestID] = status
+		}
+	}
+}
+
+// ServeHTTP implements the subset of the Pinning Service API that ServiceClient uses, plus
+// listing by CID and status.
+func (s *LocalService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeServiceError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid access token")
+		return
+	}
+
+	requestID, hasID := strings.CutPrefix(r.URL.Path, "/pins/")
+	switch {
+	case r.URL.Path == "/pins" && r.Method == http.MethodGet:
+		s.serveList(w, r)
+	case r.URL.Path == "/pins" && r.Method == http.MethodPost:
+		var pin Pin
+		if err := json.NewDecoder(r.Body).Decode(&pin); err != nil || pin.CID == "" {
+			writeServiceError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid pin object")
+			return
+		}
+		status, err := s.Add(r.Context(), pin)
+		if err != nil {
+			writeServiceError(w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", err.Error())
+			return
+		}
+		writeServiceJSON(w, http.StatusAccepted, status)
+	case hasID && r.Method == http.MethodGet:
+		status, err := s.Get(r.Context(), requestID)
+		if err != nil {
+			writeServiceError(w, http.StatusNotFound, "NOT_FOUND", "The specified resource was not found")
+			return
+		}
+		writeServiceJSON(w, http.StatusOK, status)
+	case hasID && r.Method == http.MethodDelete:
+		if err := s.Remove(r.Context(), requestID); err != nil {
+			writeServiceError(w, http.StatusNotFound, "NOT_FOUND", "The specified resource was not found")
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		writeServiceError(w, http.StatusNotFound, "NOT_FOUND", "The specified resource was not found")
+	}
+}
+
+func (s *LocalService) serveList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	cids := map[string]bool{}
+	for _, value := range query["cid"] {
+		for _, id := range strings.Split(value, ",") {
+			cids[id] = true
+		}
+	}
+	statuses := map[Status]bool{StatusPinned: true}
+	if value := query.Get("status"); value != "" {
+		statuses = map[Status]bool{}
+		for _, status := range strings.Split(value, ",") {
+			statuses[Status(status)] = true
+		}
+	}
+
+	s.mu.Lock()
+	results := []PinStatus{}
+	for _, status := range s.pins {
+		if statuses[status.Status] && (len(cids) == 0 || cids[status.Pin.CID]) {
+			results = append(results, status)
+		}
+	}
+	s.mu.Unlock()
+	writeServiceJSON(w, http.StatusOK, map[string]interface{}{"count": len(results), "results": results})
+}
+
+func writeServiceJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeServiceError(w http.ResponseWriter, code int, reason, details string) {
+	writeServiceJSON(w, code, map[string]interface{}{"error": map[string]string{"reason": reason, "details": details}})
+}
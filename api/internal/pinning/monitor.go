@@ -0,0 +1,212 @@
+package pinning
+
+import (
+	"api/internal/ipfsuri"
+	"api/internal/models"
+	"api/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+// Alert is raised the first time a pinning service is found to have lost, or never managed to
+// pin, a transcript file.
+type Alert struct {
+	CID         string
+	Service     string
+	OwnerWallet string
+	Reason      string
+	Pinned      int // Other services still reporting the content pinned
+	Services    int // Services the content is pinned at
+}
+
+// Monitor keeps every file referenced by a transcript pinned at every configured pinning service.
+// Sync requests pins for files not yet pinned at a service; Check re-verifies existing pins and
+// re-pins those that failed, disappeared or stayed queued for longer than QueueTimeout.
+type Monitor struct {
+	DB           *gorm.DB
+	Services     []Service
+	Origins      []string // Multiaddrs of nodes holding the content, passed along with pin requests
+	QueueTimeout time.Duration
+	Alert        func(ctx context.Context, alert Alert)
+}
+
+func NewMonitor(db *gorm.DB, services []Service, origins []string, alert func(context.Context, Alert)) *Monitor {
+	return &Monitor{
+		DB:           db,
+		Services:     services,
+		Origins:      origins,
+		QueueTimeout: constants.PinQueueTimeout * time.Minute,
+		Alert:        alert,
+	}
+}
+
+// Sync requests a pin at every service for each transcript file without one.
+func (m *Monitor) Sync(ctx context.Context) error {
+	var transcripts []models.Transcript
+	if err := m.DB.Select("ipfs_uri_metadata", "ipfs_uri_mediahash", "owner_wallet").Find(&transcripts).Error; err != nil {
+		return err
+	}
+	owners := make(map[string]string)
+	for _, transcript := range transcripts {
+		for _, uri := range []string{transcript.IPFSURIMetadata, transcript.IPFSURIMediaHash} {
+			// The whole DAG under the root is pinned, paths inside it come along
+			if parsed, err := ipfsuri.Parse(uri); err == nil {
+				owners[parsed.Canonical().CID.String()] = transcript.OwnerWallet
+			}
+		}
+	}
+
+	var existing []models.Pin
+	if err := m.DB.Select("cid", "service").Find(&existing).Error; err != nil {
+		return err
+	}
+	pinned := make(map[[2]string]bool, len(existing))
+	for _, pin := range existing {
+		pinned[[2]string{pin.CID, pin.Service}] = true
+	}
+
+	var errs []error
+	for id, owner := range owners {
+		for _, service := range m.Services {
+			if pinned[[2]string{id, service.Name()}] {
+				continue
+			}
+			if err := m.add(ctx, service, id, owner); err != nil {
+				errs = append(errs, fmt.Errorf("%s at %s: %w", id, service.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Monitor) add(ctx context.Context, service Service, id, owner string) error {
+	status, err := service.Add(ctx, Pin{CID: id, Name: id, Origins: m.Origins})
+	if err != nil {
+		return err
+	}
+	record := models.Pin{
+		CID:         id,
+		Service:     service.Name(),
+		OwnerWallet: owner,
+		RequestID:   status.RequestID,
+		Status:      string(status.Status),
+		CheckedAt:   time.Now(),
+	}
+	result := m.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Another replica pinned it first, drop the duplicate request
+		return service.Remove(ctx, status.RequestID)
+	}
+	return nil
+}
+
+// Check fetches the status of every pin and re-pins the ones that were lost.
+func (m *Monitor) Check(ctx context.Context) error {
+	services := make(map[string]Service, len(m.Services))
+	for _, service := range m.Services {
+		services[service.Name()] = service
+	}
+
+	var lastID uint64
+	for {
+		var pins []models.Pin
+		if err := m.DB.Where("id > ?", lastID).Order("id").Limit(100).Find(&pins).Error; err != nil {
+			return err
+		}
+		if len(pins) == 0 {
+			return nil
+		}
+		for _, pin := range pins {
+			lastID = pin.ID
+			service, ok := services[pin.Service]
+			if !ok {
+				continue // Service no longer configured
+			}
+			if err := m.check(ctx, service, pin); err != nil {
+				log.Printf("Failed to check pin of %s at %s: %v", pin.CID, pin.Service, err)
+			}
+		}
+	}
+}
+
+func (m *Monitor) check(ctx context.Context, service Service, pin models.Pin) error {
+	now := time.Now()
+	status, err := service.Get(ctx, pin.RequestID)
+	var reason string
+	switch {
+	case errors.Is(err, ErrPinNotFound):
+		reason = "pin request no longer exists at the service"
+	case err != nil:
+		// The service being unreachable says nothing about the content, try again next time
+		return errors.Join(err, m.DB.Model(&pin).Updates(map[string]interface{}{"checked_at": now, "last_error": err.Error()}).Error)
+	case status.Status == StatusFailed:
+		reason = "service reports the pin failed"
+		if details := status.Info["error"]; details != "" {
+			reason += ": " + details
+		}
+	case status.Status == StatusPinned:
+	case now.Sub(status.Created) > m.QueueTimeout:
+		reason = fmt.Sprintf("pin still %s after %s", status.Status, m.QueueTimeout)
+	}
+
+	if reason == "" {
+		updates := map[string]interface{}{"status": string(status.Status), "failures": 0, "last_error": "", "checked_at": now}
+		if status.Status == StatusPinned && pin.PinnedAt == nil {
+			updates["pinned_at"] = now
+		}
+		if status.Status == StatusPinned && pin.Failures > 0 {
+			log.Printf("Pin of %s at %s recovered after %d failures", pin.CID, pin.Service, pin.Failures)
+		}
+		return m.DB.Model(&pin).Updates(updates).Error
+	}
+	return m.repin(ctx, service, pin, reason)
+}
+
+// repin replaces a lost pin request with a new one and alerts on the first loss in a row.
+func (m *Monitor) repin(ctx context.Context, service Service, pin models.Pin, reason string) error {
+	log.Printf("Pin of %s at %s lost: %s", pin.CID, pin.Service, reason)
+	if err := service.Remove(ctx, pin.RequestID); err != nil && !errors.Is(err, ErrPinNotFound) {
+		log.Printf("Failed to remove lost pin request %s at %s: %v", pin.RequestID, pin.Service, err)
+	}
+
+	updates := map[string]interface{}{
+		"status":     string(StatusFailed),
+		"failures":   pin.Failures + 1,
+		"last_error": reason,
+		"checked_at": time.Now(),
+		"pinned_at":  nil,
+	}
+	status, addErr := service.Add(ctx, Pin{CID: pin.CID, Name: pin.CID, Origins: m.Origins})
+	if addErr == nil {
+		updates["request_id"] = status.RequestID
+		updates["status"] = string(status.Status)
+	} else {
+		updates["last_error"] = reason + "; re-pin failed: " + addErr.Error()
+	}
+	if err := m.DB.Model(&pin).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if pin.Failures == 0 && m.Alert != nil {
+		var others []models.Pin
+		if err := m.DB.Select("status").Where("cid = ? AND id <> ?", pin.CID, pin.ID).Find(&others).Error; err != nil {
+			return err
+		}
+		alert := Alert{CID: pin.CID, Service: pin.Service, OwnerWallet: pin.OwnerWallet, Reason: updates["last_error"].(string), Services: len(others) + 1}
+		for _, other := range others {
+			if other.Status == string(StatusPinned) {
+				alert.Pinned++
+			}
+		}
+		m.Alert(ctx, alert)
+	}
+	return addErr
+}
@@ -0,0 +1,27 @@
+package pinning
+
+import (
+	"api/internal/ipfs"
+	"context"
+	"github.com/ipfs/go-cid"
+)
+
+// Pinner keeps content available on IPFS. Implementations may pin on the node the API writes
+// to or ask a remote pinning service to fetch and keep the content.
+type Pinner interface {
+	Name() string
+	Pin(ctx context.Context, id cid.Cid, name string) error
+}
+
+// NodePinner pins on the IPFS node uploads are written to.
+type NodePinner struct {
+	IPFS *ipfs.Client
+}
+
+func (p *NodePinner) Name() string {
+	return "node"
+}
+
+func (p *NodePinner) Pin(ctx context.Context, id cid.Cid, _ string) error {
+	return p.IPFS.Pin(ctx, id)
+}
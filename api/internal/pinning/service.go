@@ -0,0 +1,143 @@
+package pinning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ipfs/go-cid"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Status is where a pin request is at, as reported by a pinning service.
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusPinning Status = "pinning"
+	StatusPinned  Status = "pinned"
+	StatusFailed  Status = "failed"
+)
+
+var ErrPinNotFound = errors.New("pin request not found")
+
+// Pin is what a pinning service is asked to keep.
+type Pin struct {
+	CID     string            `json:"cid"`
+	Name    string            `json:"name,omitempty"`
+	Origins []string          `json:"origins,omitempty"` // Multiaddrs of nodes known to have the content
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+// PinStatus is a pin request as tracked by a pinning service.
+type PinStatus struct {
+	RequestID string            `json:"requestid"`
+	Status    Status            `json:"status"`
+	Created   time.Time         `json:"created"`
+	Pin       Pin               `json:"pin"`
+	Delegates []string          `json:"delegates"`
+	Info      map[string]string `json:"info,omitempty"`
+}
+
+// Service is a remote pinning service as described by the IPFS Pinning Service API. Pin requests
+// are asynchronous: Add returns a queued request whose status is then polled with Get.
+type Service interface {
+	Name() string
+	Add(ctx context.Context, pin Pin) (PinStatus, error)
+	Get(ctx context.Context, requestID string) (PinStatus, error)
+	Remove(ctx context.Context, requestID string) error
+}
+
+// ServiceClient talks to a pinning service over the Pinning Service API
+// (https://ipfs.github.io/pinning-services-api-spec/).
+type ServiceClient struct {
+	ServiceName string
+	Endpoint    string
+	Token       string
+	HTTP        *http.Client
+}
+
+func NewServiceClient(name, endpoint, token string) *ServiceClient {
+	return &ServiceClient{
+		ServiceName: name,
+		Endpoint:    strings.TrimRight(endpoint, "/"),
+		Token:       token,
+		HTTP:        &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *ServiceClient) Name() string {
+	return s.ServiceName
+}
+
+// Pin asks the service to pin id and returns once the request is accepted, not when the content
+// has been fetched.
+func (s *ServiceClient) Pin(ctx context.Context, id cid.Cid, name string) error {
+	_, err := s.Add(ctx, Pin{CID: id.String(), Name: name})
+	return err
+}
+
+func (s *ServiceClient) Add(ctx context.Context, pin Pin) (PinStatus, error) {
+	var status PinStatus
+	err := s.do(ctx, http.MethodPost, "/pins", pin, &status)
+	return status, err
+}
+
+func (s *ServiceClient) Get(ctx context.Context, requestID string) (PinStatus, error) {
+	var status PinStatus
+	err := s.do(ctx, http.MethodGet, "/pins/"+url.PathEscape(requestID), nil, &status)
+	return status, err
+}
+
+func (s *ServiceClient) Remove(ctx context.Context, requestID string) error {
+	return s.do(ctx, http.MethodDelete, "/pins/"+url.PathEscape(requestID), nil, nil)
+}
+
+func (s *ServiceClient) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, s.Endpoint+path, reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+s.Token)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := s.HTTP.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return ErrPinNotFound
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		var failure struct {
+			Error struct {
+				Reason  string `json:"reason"`
+				Details string `json:"details"`
+			} `json:"error"`
+		}
+		json.NewDecoder(io.LimitReader(response.Body, 4096)).Decode(&failure)
+		return fmt.Errorf("pinning service %s: %s %s: %s %s", s.ServiceName, method, path,
+			response.Status, strings.TrimSpace(failure.Error.Reason+" "+failure.Error.Details))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
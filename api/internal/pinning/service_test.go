@@ -0,0 +1,125 @@
+package pinning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testCID = "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"
+
+// newTestService serves a LocalService over HTTP and returns a client pointed at it.
+func newTestService(t *testing.T, token string) (*LocalService, *ServiceClient) {
+	local := NewLocalService("local", nil)
+	local.Token = token
+	server := httptest.NewServer(local)
+	t.Cleanup(server.Close)
+	return local, NewServiceClient("local", server.URL+"/", token)
+}
+
+func TestServiceClient(t *testing.T) {
+	ctx := context.Background()
+	local, client := newTestService(t, "secret")
+
+	tests := []struct {
+		name   string
+		pin    Pin
+		status Status
+	}{
+		{"valid CID is pinned", Pin{CID: testCID, Name: "transcript", Origins: []string{"/dns4/node.example/tcp/4001"}}, StatusPinned},
+		{"invalid CID fails", Pin{CID: "not-a-cid"}, StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, err := client.Add(ctx, tt.pin)
+			if err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+			if added.RequestID == "" || added.Status != tt.status || added.Pin.CID != tt.pin.CID || added.Pin.Name != tt.pin.Name {
+				t.Errorf("Add = %+v", added)
+			}
+
+			fetched, err := client.Get(ctx, added.RequestID)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if fetched.RequestID != added.RequestID || fetched.Status != tt.status {
+				t.Errorf("Get = %+v, want %+v", fetched, added)
+			}
+
+			if err := client.Remove(ctx, added.RequestID); err != nil {
+				t.Fatalf("Remove: %v", err)
+			}
+			if _, err := client.Get(ctx, added.RequestID); !errors.Is(err, ErrPinNotFound) {
+				t.Errorf("Get after Remove: err = %v", err)
+			}
+			if err := client.Remove(ctx, added.RequestID); !errors.Is(err, ErrPinNotFound) {
+				t.Errorf("second Remove: err = %v", err)
+			}
+		})
+	}
+
+	t.Run("dropped content is reported failed", func(t *testing.T) {
+		added, err := client.Add(ctx, Pin{CID: testCID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		local.Drop(testCID)
+		fetched, err := client.Get(ctx, added.RequestID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fetched.Status != StatusFailed {
+			t.Errorf("status = %s, want %s", fetched.Status, StatusFailed)
+		}
+	})
+}
+
+func TestServiceClientRejected(t *testing.T) {
+	_, client := newTestService(t, "secret")
+	client.Token = "wrong"
+	if _, err := client.Add(context.Background(), Pin{CID: testCID}); err == nil || errors.Is(err, ErrPinNotFound) {
+		t.Errorf("Add with a wrong token: err = %v", err)
+	}
+}
+
+func TestLocalServiceList(t *testing.T) {
+	ctx := context.Background()
+	local := NewLocalService("local", nil)
+	pinned, _ := local.Add(ctx, Pin{CID: testCID})
+	local.Add(ctx, Pin{CID: "not-a-cid"})
+
+	tests := []struct {
+		name  string
+		query string
+		count int
+	}{
+		{"pinned by default", "", 1},
+		{"by CID", "?cid=" + testCID, 1},
+		{"by another CID", "?cid=bafkreib", 0},
+		{"failed", "?status=failed", 1},
+		{"any status", "?status=queued,pinning,pinned,failed", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			local.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/pins"+tt.query, nil))
+			var list struct {
+				Count   int         `json:"count"`
+				Results []PinStatus `json:"results"`
+			}
+			if err := json.NewDecoder(recorder.Body).Decode(&list); err != nil {
+				t.Fatal(err)
+			}
+			if list.Count != tt.count || len(list.Results) != tt.count {
+				t.Errorf("listed %d pins, want %d", list.Count, tt.count)
+			}
+			if tt.query == "" && list.Count == 1 && list.Results[0].RequestID != pinned.RequestID {
+				t.Errorf("listed %s, want %s", list.Results[0].RequestID, pinned.RequestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package push
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+const recordSize = 4096
+
+// Encrypt encrypts a push message payload for a subscription using the aes128gcm content
+// encoding of RFC 8188 with the key derivation of RFC 8291. p256dh and auth are the base64url
+// encoded keys from the browser's PushSubscription.
+func Encrypt(payload []byte, p256dh, auth string) ([]byte, error) {
+	uaPublicBytes, err := decodeKey(p256dh)
+	if err != nil {
+		return nil, err
+	}
+	authSecret, err := decodeKey(auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, errors.New("invalid subscription auth secret")
+	}
+	// One record only: payload, delimiter, 16 byte tag and the header must fit
+	if len(payload) > recordSize-16-1-86 {
+		return nil, errors.New("push payload too large")
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encrypt(payload, uaPublicBytes, authSecret, asPrivate, salt)
+}
+
+// encrypt performs the encryption with the given application server key and salt.
+func encrypt(payload, uaPublicBytes, authSecret []byte, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, errors.New("invalid subscription p256dh key")
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public, 32)
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublicBytes...)
+	keyInfo = append(keyInfo, asPublicBytes...)
+	ikm := hkdf(authSecret, ecdhSecret, keyInfo, 32)
+
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 marks the last (and only) record
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(asPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublicBytes)))
+	header = append(header, asPublicBytes...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// hkdf is HKDF-SHA-256 (RFC 5869) for outputs of at most one hash length.
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{0x01})
+	return expand.Sum(nil)[:length]
+}
+
+// decodeKey accepts both padded and unpadded base64url, as browsers differ.
+func decodeKey(encoded string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+}
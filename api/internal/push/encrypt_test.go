@@ -0,0 +1,91 @@
+package push
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"encoding/base64"
+	"testing"
+)
+
+// Test vector of RFC 8291, Appendix A
+const (
+	rfc8291Plaintext  = "When I grow up, I want to be a watermelon"
+	rfc8291ASPrivate  = "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"
+	rfc8291UAPrivate  = "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"
+	rfc8291UAPublic   = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+	rfc8291AuthSecret = "BTBZMqHH6r4Tts7J_aSIgg"
+	rfc8291Salt       = "DGv6ra1nlYgDCS1FRnbzlw"
+	rfc8291Message    = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+)
+
+func mustDecode(t *testing.T, encoded string) []byte {
+	t.Helper()
+	decoded, err := decodeKey(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestEncryptRFC8291(t *testing.T) {
+	asPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, rfc8291ASPrivate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := encrypt([]byte(rfc8291Plaintext), mustDecode(t, rfc8291UAPublic), mustDecode(t, rfc8291AuthSecret),
+		asPrivate, mustDecode(t, rfc8291Salt))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if got := base64.RawURLEncoding.EncodeToString(message); got != rfc8291Message {
+		t.Errorf("encrypt =\n%s\nwant\n%s", got, rfc8291Message)
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	uaPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, rfc8291UAPrivate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(uaPrivate.PublicKey().Bytes(), mustDecode(t, rfc8291UAPublic)) {
+		t.Fatal("user agent key pair of the test vector does not match")
+	}
+
+	tests := []struct {
+		name    string
+		payload []byte
+		p256dh  string
+		auth    string
+		wantErr bool
+	}{
+		{"unpadded keys", []byte(rfc8291Plaintext), rfc8291UAPublic, rfc8291AuthSecret, false},
+		{"padded keys", []byte(rfc8291Plaintext), rfc8291UAPublic + "=", rfc8291AuthSecret + "==", false},
+		{"largest payload", bytes.Repeat([]byte("a"), recordSize-16-1-86), rfc8291UAPublic, rfc8291AuthSecret, false},
+		{"payload too large", bytes.Repeat([]byte("a"), recordSize-16-86), rfc8291UAPublic, rfc8291AuthSecret, true},
+		{"short auth secret", []byte("hi"), rfc8291UAPublic, "BTBZMqHH6r4Tts7J", true},
+		{"p256dh not a P-256 point", []byte("hi"), "B" + rfc8291UAPublic[2:] + "A", rfc8291AuthSecret, true},
+		{"p256dh not base64url", []byte("hi"), "not a key!", rfc8291AuthSecret, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := Encrypt(tt.payload, tt.p256dh, tt.auth)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Encrypt succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if len(first) > recordSize {
+				t.Errorf("message is %d bytes, larger than one record", len(first))
+			}
+			// Every message has a fresh salt and server key
+			second, _ := Encrypt(tt.payload, tt.p256dh, tt.auth)
+			if bytes.Equal(first[:16], second[:16]) || bytes.Equal(first[21:86], second[21:86]) {
+				t.Error("salt or server key reused")
+			}
+		})
+	}
+}
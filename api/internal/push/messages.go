@@ -0,0 +1,47 @@
+package push
+
+import (
+	"api/internal/models"
+	"fmt"
+)
+
+// RequestEventMessage returns the push message for a request lifecycle event and the wallets
+// that should receive it; ok is false for events that are not pushed.
+func RequestEventMessage(request models.Request, eventType models.RequestEventType) (wallets []string, message Message, ok bool) {
+	message = Message{Type: string(eventType), RequestID: request.ID}
+	switch eventType {
+	case models.EventRequestCreated:
+		message.Title = "New transcript request"
+		message.Body = fmt.Sprintf("%s requested access to your transcripts", shortWallet(request.RecipientWallet))
+		return []string{request.StudentWallet}, message, true
+	case models.EventRequestApproved:
+		message.Title = "Request approved"
+		message.Body = fmt.Sprintf("%s shared transcripts with you", shortWallet(request.StudentWallet))
+		return []string{request.RecipientWallet}, message, true
+	case models.EventRequestDenied:
+		message.Title = "Request denied"
+		message.Body = fmt.Sprintf("%s denied your transcript request", shortWallet(request.StudentWallet))
+		return []string{request.RecipientWallet}, message, true
+	case models.EventRequestExpired:
+		message.Title = "Request expired"
+		message.Body = "A transcript request expired without an answer"
+		return []string{request.StudentWallet, request.RecipientWallet}, message, true
+	}
+	return nil, message, false
+}
+
+// CredentialRevokedMessage returns the push message for a revoked credential.
+func CredentialRevokedMessage(tokenID, reason string) Message {
+	body := fmt.Sprintf("Credential #%s was revoked by its institution", tokenID)
+	if reason != "" {
+		body += ": " + reason
+	}
+	return Message{Type: "credential.revoked", Title: "Credential revoked", Body: body, TokenID: tokenID}
+}
+
+func shortWallet(wallet string) string {
+	if len(wallet) <= 10 {
+		return wallet
+	}
+	return wallet[:6] + "…" + wallet[len(wallet)-4:]
+}
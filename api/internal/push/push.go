@@ -0,0 +1,185 @@
+package push
+
+import (
+	"api/internal/kms"
+	"api/internal/models"
+	"api/internal/webhooks"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	messageTTL  = 24 * time.Hour // push services drop undelivered messages after this
+	maxFailures = 5              // consecutive failures before a subscription is dropped
+)
+
+// Endpoints are supplied by browsers, so pushes go through the client that refuses internal
+// addresses and redirects, like webhook deliveries
+var client = webhooks.NewClient(10 * time.Second)
+
+var ErrEndpointTaken = errors.New("push endpoint is registered to another wallet")
+
+// Message is the JSON payload the service worker receives.
+type Message struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	RequestID string `json:"request_id,omitempty"`
+	TokenID   string `json:"token_id,omitempty"`
+}
+
+// LoadVAPIDKey returns the configured VAPID key, or the one stored in the database, generating
+// and storing it on first use so that every replica signs with the same key. The stored private
+// key is wrapped by the KMS.
+func LoadVAPIDKey(ctx context.Context, db *gorm.DB, k kms.KMS, configured, subject string) (*VAPIDKey, error) {
+	if configured != "" {
+		return ParseVAPIDKey(configured, subject)
+	}
+
+	generated, err := GenerateVAPIDKey(subject)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := k.Wrap(ctx, generated.private.D.FillBytes(make([]byte, 32)))
+	if err != nil {
+		return nil, err
+	}
+	// Replicas starting together each generate a key, the first one stored wins
+	if err := db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.VAPIDKeyRecord{
+		ID:         1,
+		KMSKeyID:   wrapped.KeyID,
+		WrappedKey: wrapped.Ciphertext,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	var record models.VAPIDKeyRecord
+	if err := db.WithContext(ctx).First(&record, "id = ?", 1).Error; err != nil {
+		return nil, err
+	}
+	d, err := k.Unwrap(ctx, kms.WrappedKey{KeyID: record.KMSKeyID, Ciphertext: record.WrappedKey})
+	if err != nil {
+		return nil, err
+	}
+	return ParseVAPIDKey(base64.RawURLEncoding.EncodeToString(d), subject)
+}
+
+// Subscribe registers the browser subscription for the wallet. Re-registering an endpoint of the
+// wallet updates its keys; an endpoint registered to another wallet is ErrEndpointTaken, as
+// anyone who learned it could otherwise redirect that wallet's notifications.
+func Subscribe(db *gorm.DB, wallet, endpoint, p256dh, auth, userAgent string) (models.PushSubscription, error) {
+	subscription := models.PushSubscription{
+		Wallet:    strings.ToLower(wallet),
+		Endpoint:  endpoint,
+		P256dh:    p256dh,
+		Auth:      auth,
+		UserAgent: userAgent,
+	}
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "endpoint"}},
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "push_subscriptions.wallet", Value: subscription.Wallet}}},
+		DoUpdates: clause.AssignmentColumns([]string{"p256dh", "auth", "user_agent", "failures"}),
+	}).Create(&subscription)
+	if result.Error != nil {
+		return subscription, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return subscription, ErrEndpointTaken
+	}
+	err := db.First(&subscription, "endpoint = ?", endpoint).Error
+	return subscription, err
+}
+
+func ListSubscriptions(db *gorm.DB, wallet string) ([]models.PushSubscription, error) {
+	var subscriptions []models.PushSubscription
+	err := db.Where("wallet = ?", strings.ToLower(wallet)).Order("created_at ASC").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func Unsubscribe(db *gorm.DB, wallet, subscriptionID string) (bool, error) {
+	result := db.Where("id = ? AND wallet = ?", subscriptionID, strings.ToLower(wallet)).Delete(&models.PushSubscription{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Notify sends the message to every subscription of the wallets. Expired subscriptions and ones
+// failing repeatedly are removed; other delivery errors are returned, so that the caller can
+// retry.
+func Notify(db *gorm.DB, key *VAPIDKey, wallets []string, message Message) error {
+	if key == nil || len(wallets) == 0 {
+		return nil
+	}
+	lower := make([]string, 0, len(wallets))
+	for _, wallet := range wallets {
+		lower = append(lower, strings.ToLower(wallet))
+	}
+
+	var subscriptions []models.PushSubscription
+	if err := db.Where("wallet IN ?", lower).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, subscription := range subscriptions {
+		err := send(context.Background(), key, subscription, payload)
+		switch {
+		case err == nil:
+			db.Model(&subscription).Updates(map[string]interface{}{"last_push_at": time.Now(), "failures": 0})
+		case errors.Is(err, errGone) || subscription.Failures+1 >= maxFailures:
+			db.Delete(&subscription)
+		default:
+			db.Model(&subscription).Update("failures", gorm.Expr("failures + 1"))
+			errs = append(errs, fmt.Errorf("sending push message to subscription %s: %w", subscription.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var errGone = errors.New("push subscription expired")
+
+func send(ctx context.Context, key *VAPIDKey, subscription models.PushSubscription, payload []byte) error {
+	body, err := Encrypt(payload, subscription.P256dh, subscription.Auth)
+	if err != nil {
+		return err
+	}
+	authorization, err := key.Authorization(subscription.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprint(int(messageTTL.Seconds())))
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errGone
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("push service responded with %s", resp.Status)
+	}
+	return nil
+}
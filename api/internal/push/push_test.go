@@ -0,0 +1,32 @@
+package push
+
+import (
+	"api/internal/models"
+	"api/internal/webhooks"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendRefusesInternalAddresses(t *testing.T) {
+	delivered := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered = true
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+
+	key, err := GenerateVAPIDKey("mailto:ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscription := models.PushSubscription{Endpoint: server.URL, P256dh: rfc8291UAPublic, Auth: rfc8291AuthSecret}
+	if err := send(context.Background(), key, subscription, []byte(`{}`)); !errors.Is(err, webhooks.ErrForbiddenAddress) {
+		t.Errorf("err = %v, want ErrForbiddenAddress", err)
+	}
+	if delivered {
+		t.Error("push message reached a loopback endpoint")
+	}
+}
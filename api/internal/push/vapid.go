@@ -0,0 +1,90 @@
+package push
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+)
+
+// VAPIDKey identifies this server to push services (RFC 8292).
+type VAPIDKey struct {
+	private *ecdsa.PrivateKey
+	Subject string // mailto: or https: contact of the operator
+}
+
+// GenerateVAPIDKey creates a new P-256 application server key.
+func GenerateVAPIDKey(subject string) (*VAPIDKey, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &VAPIDKey{private: private, Subject: subject}, nil
+}
+
+// ParseVAPIDKey loads a key from its base64url encoded 32 byte private scalar, the format used
+// by common Web Push libraries.
+func ParseVAPIDKey(encoded, subject string) (*VAPIDKey, error) {
+	d, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(d) != 32 {
+		return nil, errors.New("VAPID private key must be 32 bytes, base64url encoded")
+	}
+	curve := elliptic.P256()
+	private := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(d)}
+	private.PublicKey.Curve = curve
+	private.PublicKey.X, private.PublicKey.Y = curve.ScalarBaseMult(d)
+	return &VAPIDKey{private: private, Subject: subject}, nil
+}
+
+// PrivateKey returns the base64url encoded private scalar, the inverse of ParseVAPIDKey.
+func (k *VAPIDKey) PrivateKey() string {
+	return base64.RawURLEncoding.EncodeToString(k.private.D.FillBytes(make([]byte, 32)))
+}
+
+// PublicKey returns the uncompressed public key, base64url encoded. Browsers pass it as
+// applicationServerKey to PushManager.subscribe.
+func (k *VAPIDKey) PublicKey() string {
+	public, err := k.private.PublicKey.ECDH()
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(public.Bytes())
+}
+
+// Authorization returns the Authorization header for a request to the push endpoint.
+func (k *VAPIDKey) Authorization(endpoint string, now time.Time) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": k.Subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	jwt := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return fmt.Sprintf("vapid t=%s, k=%s", jwt, k.PublicKey()), nil
+}
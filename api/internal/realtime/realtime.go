@@ -0,0 +1,181 @@
+package realtime
+
+import (
+	"api/internal/initializers"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event types pushed to connected clients besides the request history event types
+const (
+	EventAccessGranted     = "access.granted"
+	EventCredentialRevoked = "credential.revoked"
+)
+
+const (
+	streamMaxLen = 1000           // events kept per wallet for resuming
+	streamTTL    = 24 * time.Hour // idle wallet streams are dropped after this
+)
+
+var ErrInvalidEventID = errors.New("invalid event id")
+
+// Event is a change pushed to a wallet. ID is the Redis stream entry ID and is what clients send
+// back as Last-Event-ID to resume.
+type Event struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+func streamKey(wallet string) string {
+	return fmt.Sprintf("realtime:stream:%s", strings.ToLower(wallet))
+}
+
+func channelName(wallet string) string {
+	return fmt.Sprintf("realtime:channel:%s", strings.ToLower(wallet))
+}
+
+// Publish appends the event to each wallet's stream and fans it out over pub/sub to every API
+// replica with a connection for that wallet. It returns the first error after trying every wallet.
+func Publish(wallets []string, eventType string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	seen := make(map[string]struct{}, len(wallets))
+	var firstErr error
+	for _, wallet := range wallets {
+		wallet = strings.ToLower(wallet)
+		if _, ok := seen[wallet]; ok || wallet == "" {
+			continue
+		}
+		seen[wallet] = struct{}{}
+
+		id, err := initializers.RedisClient.XAdd(ctx, &redis.XAddArgs{
+			Stream: streamKey(wallet),
+			MaxLen: streamMaxLen,
+			Approx: true,
+			Values: map[string]interface{}{"type": eventType, "data": string(raw)},
+		}).Result()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		initializers.RedisClient.Expire(ctx, streamKey(wallet), streamTTL)
+
+		message, _ := json.Marshal(Event{ID: id, Type: eventType, Data: raw})
+		if err := initializers.RedisClient.Publish(ctx, channelName(wallet), message).Err(); err != nil {
+			log.Printf("Failed to publish realtime event: %v", err)
+		}
+	}
+	return firstErr
+}
+
+// Subscribe streams the wallet's events until ctx is cancelled. When lastEventID is set, events
+// stored after it are replayed first. The returned channel is closed when the subscription ends.
+func Subscribe(ctx context.Context, wallet, lastEventID string) (<-chan Event, error) {
+	// Subscribe before replaying so nothing published in between is missed; duplicates are
+	// dropped below by comparing stream IDs
+	pubsub := initializers.RedisClient.Subscribe(ctx, channelName(wallet))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	var backlog []redis.XMessage
+	if lastEventID != "" {
+		if _, _, ok := parseID(lastEventID); !ok {
+			pubsub.Close()
+			return nil, ErrInvalidEventID
+		}
+		var err error
+		backlog, err = initializers.RedisClient.XRange(ctx, streamKey(wallet), "("+lastEventID, "+").Result()
+		if err != nil {
+			pubsub.Close()
+			return nil, err
+		}
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		last := lastEventID
+		send := func(event Event) bool {
+			if last != "" && !after(event.ID, last) {
+				return true
+			}
+			select {
+			case events <- event:
+				last = event.ID
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, message := range backlog {
+			eventType, _ := message.Values["type"].(string)
+			data, _ := message.Values["data"].(string)
+			if !send(Event{ID: message.ID, Type: eventType, Data: json.RawMessage(data)}) {
+				return
+			}
+		}
+
+		live := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-live:
+				if !ok {
+					return
+				}
+				var event Event
+				if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+					log.Printf("Failed to decode realtime event: %v", err)
+					continue
+				}
+				if !send(event) {
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// after reports whether stream ID a comes after b.
+func after(a, b string) bool {
+	aMs, aSeq, _ := parseID(a)
+	bMs, bSeq, _ := parseID(b)
+	return aMs > bMs || (aMs == bMs && aSeq > bSeq)
+}
+
+func parseID(id string) (uint64, uint64, bool) {
+	ms, seq, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	msValue, err := strconv.ParseUint(ms, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	seqValue, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return msValue, seqValue, true
+}
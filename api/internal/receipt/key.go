@@ -0,0 +1,200 @@
+package receipt
+
+import (
+	"api/internal/kms"
+	"api/internal/models"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math/big"
+)
+
+// Key is a P-256 key receipts are signed with (ES256). ID is its RFC 7638 JWK thumbprint, put in
+// the kid header of every receipt it signs.
+type Key struct {
+	private *ecdsa.PrivateKey
+	ID      string
+}
+
+// JWK is the public half of a key as published in the key set.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// GenerateKey creates a new signing key.
+func GenerateKey() (*Key, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newKey(private), nil
+}
+
+// ParseKey loads a key from its base64url encoded 32 byte private scalar, which must lie in
+// [1, n-1] for the P-256 order n.
+func ParseKey(encoded string) (*Key, error) {
+	d, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(d) != 32 {
+		return nil, errors.New("receipt signing key must be 32 bytes, base64url encoded")
+	}
+	curve := elliptic.P256()
+	scalar := new(big.Int).SetBytes(d)
+	if scalar.Sign() == 0 || scalar.Cmp(curve.Params().N) >= 0 {
+		return nil, errors.New("receipt signing key is not a valid P-256 private key")
+	}
+	private := &ecdsa.PrivateKey{D: scalar}
+	private.PublicKey.Curve = curve
+	private.PublicKey.X, private.PublicKey.Y = curve.ScalarBaseMult(d)
+	return newKey(private), nil
+}
+
+func newKey(private *ecdsa.PrivateKey) *Key {
+	key := &Key{private: private}
+	// Members in lexicographic order, as the thumbprint requires
+	thumbprint, _ := json.Marshal(struct {
+		Curve   string `json:"crv"`
+		KeyType string `json:"kty"`
+		X       string `json:"x"`
+		Y       string `json:"y"`
+	}{"P-256", "EC", key.coordinate(private.X), key.coordinate(private.Y)})
+	digest := sha256.Sum256(thumbprint)
+	key.ID = base64.RawURLEncoding.EncodeToString(digest[:])
+	return key
+}
+
+func (k *Key) coordinate(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.FillBytes(make([]byte, 32)))
+}
+
+// PrivateKey returns the base64url encoded private scalar, the inverse of ParseKey.
+func (k *Key) PrivateKey() string {
+	return base64.RawURLEncoding.EncodeToString(k.private.D.FillBytes(make([]byte, 32)))
+}
+
+// JWK returns the public key as a JSON Web Key.
+func (k *Key) JWK() JWK {
+	return JWK{
+		KeyType:   "EC",
+		Curve:     "P-256",
+		X:         k.coordinate(k.private.X),
+		Y:         k.coordinate(k.private.Y),
+		KeyID:     k.ID,
+		Use:       "sig",
+		Algorithm: "ES256",
+	}
+}
+
+// keysLock is the Postgres advisory lock held while the signing key is chosen.
+const keysLock = 0x72637074
+
+// Keys holds the key receipts are signed with. Every key that ever signed one stays published
+// so old receipts can still be verified after a rotation.
+type Keys struct {
+	Signer *Key
+	db     *gorm.DB
+}
+
+// LoadKeys returns the receipt keys shared by all API replicas. A configured key signs and is
+// recorded so it stays published once replaced; without one the newest recorded key signs,
+// generated on first start. Private keys are stored wrapped by the KMS.
+func LoadKeys(ctx context.Context, db *gorm.DB, k kms.KMS, configured string) (*Keys, error) {
+	var signer *Key
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Replicas starting on an empty table would each generate a key, the lock makes them agree
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", keysLock).Error; err != nil {
+			return err
+		}
+		var err error
+		if configured != "" {
+			if signer, err = ParseKey(configured); err != nil {
+				return err
+			}
+			return storeKey(ctx, tx, k, signer)
+		}
+
+		var latest models.ReceiptKey
+		err = tx.Order("created_at DESC").Take(&latest).Error
+		if err == nil {
+			signer, err = unwrapKey(ctx, k, latest)
+			return err
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if signer, err = GenerateKey(); err != nil {
+			return err
+		}
+		return storeKey(ctx, tx, k, signer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Keys{Signer: signer, db: db}, nil
+}
+
+// Set returns the published keys as a JWK Set. It is read from the database on every call, so
+// keys recorded by replicas started later are included.
+func (k *Keys) Set(ctx context.Context) (map[string][]JWK, error) {
+	var published []string
+	if err := k.db.WithContext(ctx).Model(&models.ReceiptKey{}).Order("created_at").
+		Pluck("public_key", &published).Error; err != nil {
+		return nil, err
+	}
+	set := make([]JWK, 0, len(published))
+	for _, encoded := range published {
+		var jwk JWK
+		if err := json.Unmarshal([]byte(encoded), &jwk); err != nil {
+			return nil, err
+		}
+		set = append(set, jwk)
+	}
+	return map[string][]JWK{"keys": set}, nil
+}
+
+// storeKey records the key, wrapped by the KMS, unless it already is.
+func storeKey(ctx context.Context, tx *gorm.DB, k kms.KMS, key *Key) error {
+	wrapped, err := k.Wrap(ctx, key.private.D.FillBytes(make([]byte, 32)))
+	if err != nil {
+		return err
+	}
+	public, err := json.Marshal(key.JWK())
+	if err != nil {
+		return err
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ReceiptKey{
+		KeyID:      key.ID,
+		PublicKey:  string(public),
+		KMSKeyID:   wrapped.KeyID,
+		WrappedKey: wrapped.Ciphertext,
+	}).Error
+}
+
+// unwrapKey loads a recorded key, checking it is the key the record was stored under.
+func unwrapKey(ctx context.Context, k kms.KMS, record models.ReceiptKey) (*Key, error) {
+	d, err := k.Unwrap(ctx, kms.WrappedKey{KeyID: record.KMSKeyID, Ciphertext: record.WrappedKey})
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseKey(base64.RawURLEncoding.EncodeToString(d))
+	if err != nil {
+		return nil, err
+	}
+	if key.ID != record.KeyID {
+		return nil, fmt.Errorf("receipt key %s unwrapped to a different key", record.KeyID)
+	}
+	return key, nil
+}
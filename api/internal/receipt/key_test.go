@@ -0,0 +1,222 @@
+package receipt
+
+import (
+	"api/internal/kms"
+	"api/internal/models"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// encodeScalar returns the scalar in the form ParseKey reads.
+func encodeScalar(d *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(d.FillBytes(make([]byte, 32)))
+}
+
+func TestParseKey(t *testing.T) {
+	generated, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := elliptic.P256().Params().N
+
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr bool
+	}{
+		{"generated key", generated.PrivateKey(), false},
+		{"smallest scalar", encodeScalar(big.NewInt(1)), false},
+		{"largest scalar", encodeScalar(new(big.Int).Sub(order, big.NewInt(1))), false},
+		{"zero scalar", encodeScalar(big.NewInt(0)), true},
+		{"curve order", encodeScalar(order), true},
+		{"above the curve order", encodeScalar(new(big.Int).Add(order, big.NewInt(1))), true},
+		{"short scalar", base64.RawURLEncoding.EncodeToString([]byte{1, 2, 3}), true},
+		{"padded base64", generated.PrivateKey() + "=", true},
+		{"not base64url", "not a key!", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKey(tt.encoded)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ParseKey succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKey: %v", err)
+			}
+			if key.PrivateKey() != tt.encoded {
+				t.Errorf("PrivateKey = %s, want %s", key.PrivateKey(), tt.encoded)
+			}
+			if !key.private.PublicKey.IsOnCurve(key.private.X, key.private.Y) {
+				t.Error("public key is not on the curve")
+			}
+		})
+	}
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// The scalar 1 has the base point as public key
+	key, err := ParseKey(encodeScalar(big.NewInt(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk := key.JWK()
+	if jwk.X != "axfR8uEsQkf4vOblY6RA8ncDfYEt6zOg9KE5RdiYwpY" || jwk.Y != "T-NC4v4af5uO5-tKfA-eFivOM1drMV7Oy7ZAaDe_UfU" {
+		t.Fatalf("JWK = %+v, want the P-256 base point", jwk)
+	}
+
+	// RFC 7638: the required members in lexicographic order, without whitespace
+	canonical := `{"crv":"P-256","kty":"EC","x":"` + jwk.X + `","y":"` + jwk.Y + `"}`
+	digest := sha256.Sum256([]byte(canonical))
+	if want := base64.RawURLEncoding.EncodeToString(digest[:]); key.ID != want || jwk.KeyID != want {
+		t.Errorf("key ID = %s, JWK kid = %s, want %s", key.ID, jwk.KeyID, want)
+	}
+	if jwk.KeyType != "EC" || jwk.Curve != "P-256" || jwk.Use != "sig" || jwk.Algorithm != "ES256" {
+		t.Errorf("JWK = %+v", jwk)
+	}
+}
+
+// verifyJWS checks a compact JWS the way a verifier holding only the published key set would,
+// and returns its header and payload.
+func verifyJWS(t *testing.T, token string, set map[string][]JWK) (map[string]string, []byte, bool) {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("JWS has %d parts", len(parts))
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var header map[string]string
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		t.Fatal(err)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		t.Fatalf("signature is not 64 bytes: %v", err)
+	}
+
+	for _, jwk := range set["keys"] {
+		if jwk.KeyID != header["kid"] {
+			continue
+		}
+		x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+		y, _ := base64.RawURLEncoding.DecodeString(jwk.Y)
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		valid := ecdsa.Verify(public, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:]))
+		return header, payload, valid
+	}
+	return header, payload, false
+}
+
+func TestSignVerifiesAgainstPublishedKey(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The key set as served and parsed by a verifier
+	served, err := json.Marshal(map[string][]JWK{"keys": {other.JWK(), key.JWK()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set map[string][]JWK
+	if err := json.Unmarshal(served, &set); err != nil {
+		t.Fatal(err)
+	}
+
+	claims := Claims{Issuer: "nft-cms-api", Subject: "7", ID: "a7d3c5f2-0000-4000-8000-000000000001", TokenID: "7", Verified: true}
+	token, err := key.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	forged, err := json.Marshal(Claims{Issuer: "nft-cms-api", Subject: "8", TokenID: "8", Verified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedByOther, err := other.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherParts := strings.Split(signedByOther, ".")
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"signed receipt", token, true},
+		{"another key's signature", parts[0] + "." + parts[1] + "." + otherParts[2], false},
+		{"altered claims", parts[0] + "." + base64.RawURLEncoding.EncodeToString(forged) + "." + parts[2], false},
+		{"kid of another key", otherParts[0] + "." + parts[1] + "." + parts[2], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, payload, valid := verifyJWS(t, tt.token, set)
+			if valid != tt.valid {
+				t.Fatalf("signature valid = %v, want %v", valid, tt.valid)
+			}
+			if !tt.valid {
+				return
+			}
+			if header["typ"] != Type || header["alg"] != "ES256" || header["kid"] != key.ID {
+				t.Errorf("header = %v", header)
+			}
+			var decoded Claims
+			if err := json.Unmarshal(payload, &decoded); err != nil || decoded != claims {
+				t.Errorf("claims = %+v, %v, want %+v", decoded, err, claims)
+			}
+		})
+	}
+}
+
+func TestUnwrapKey(t *testing.T) {
+	ctx := context.Background()
+	local, err := kms.NewLocalKMS(filepath.Join(t.TempDir(), "master.key"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := local.Wrap(ctx, key.private.D.FillBytes(make([]byte, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := models.ReceiptKey{KeyID: key.ID, KMSKeyID: wrapped.KeyID, WrappedKey: wrapped.Ciphertext}
+
+	unwrapped, err := unwrapKey(ctx, local, record)
+	if err != nil {
+		t.Fatalf("unwrapKey: %v", err)
+	}
+	if unwrapped.ID != key.ID || unwrapped.PrivateKey() != key.PrivateKey() {
+		t.Error("unwrapped a different key")
+	}
+
+	swapped := record
+	swapped.KeyID = "another-key"
+	if _, err := unwrapKey(ctx, local, swapped); err == nil {
+		t.Error("unwrapKey accepted a record whose key ID does not match the key")
+	}
+}
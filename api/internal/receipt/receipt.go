@@ -0,0 +1,80 @@
+package receipt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Type is the typ header of receipts, telling them apart from other JWTs signed by the API.
+const Type = "verification-receipt+jwt"
+
+// HashResult is the outcome of hashing the credential media.
+type HashResult struct {
+	MediaHash string `json:"media_hash,omitempty"` // keccak256 of the media, empty when it could not be hashed
+	Matches   bool   `json:"matches"`              // The issuer's signature on chain is over MediaHash
+}
+
+// Claims is the payload of a receipt: what the API found when it verified a credential shared
+// with the recipient through an approved request.
+type Claims struct {
+	Issuer           string     `json:"iss"`
+	Subject          string     `json:"sub"` // Token ID
+	IssuedAt         int64      `json:"iat"`
+	ID               string     `json:"jti"`
+	RequestID        string     `json:"request_id"`
+	Recipient        string     `json:"recipient"` // Wallet the receipt was issued to
+	TokenID          string     `json:"token_id"`
+	Owner            string     `json:"owner"`
+	CredentialIssuer string     `json:"credential_issuer"` // Wallet that signed the media hash
+	IssuerAuthorized bool       `json:"issuer_authorized"`
+	Status           string     `json:"status"`
+	Hash             HashResult `json:"hash"`
+	Verified         bool       `json:"verified"`
+	ChainID          string     `json:"chain_id,omitempty"`
+	Contract         string     `json:"contract"`
+	BlockNumber      uint64     `json:"block_number"`
+	VerifiedAt       time.Time  `json:"verified_at"`
+}
+
+// Outcome returns the hex SHA-256 of what the claims assert about the credential, leaving out
+// when and against which block it was verified. Receipts with the same outcome say the same.
+func (c Claims) Outcome() string {
+	c.IssuedAt, c.ID, c.BlockNumber, c.VerifiedAt = 0, "", 0, time.Time{}
+	encoded, _ := json.Marshal(c)
+	digest := sha256.Sum256(encoded)
+	return hex.EncodeToString(digest[:])
+}
+
+// Sign returns the claims as a compact JWS signed with ES256.
+func (k *Key) Sign(claims Claims) (string, error) {
+	return k.SignJWS(Type, claims)
+}
+
+// SignJWS returns the payload as a compact JWS signed with ES256, with typ telling verifiers what
+// the payload is.
+func (k *Key) SignJWS(typ string, payload interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"typ": typ, "alg": "ES256", "kid": k.ID})
+	if err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(encoded)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
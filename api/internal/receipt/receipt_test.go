@@ -0,0 +1,50 @@
+package receipt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClaimsOutcome(t *testing.T) {
+	base := Claims{
+		Issuer:           "nft-cms-api",
+		Subject:          "7",
+		IssuedAt:         1719705600,
+		ID:               "a7d3c5f2-0000-4000-8000-000000000001",
+		RequestID:        "6f1c0d9e-2b1a-4c55-9a7e-3f0b8f1f2a10",
+		Recipient:        "0x00000000000000000000000000000000000000b0",
+		TokenID:          "7",
+		Owner:            "0x00000000000000000000000000000000000000a0",
+		CredentialIssuer: "0x00000000000000000000000000000000000000c0",
+		IssuerAuthorized: true,
+		Status:           "Active",
+		Hash:             HashResult{MediaHash: "0x01", Matches: true},
+		Verified:         true,
+		Contract:         "0x5FbDB2315678afecb367f032d93F642f64180aa3",
+		BlockNumber:      100,
+		VerifiedAt:       time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Claims)
+		same   bool
+	}{
+		{"issued again", func(c *Claims) { c.IssuedAt++; c.ID = "a7d3c5f2-0000-4000-8000-000000000002" }, true},
+		{"verified at a later block", func(c *Claims) { c.BlockNumber = 200; c.VerifiedAt = c.VerifiedAt.Add(time.Hour) }, true},
+		{"credential revoked", func(c *Claims) { c.Status = "Revoked"; c.Verified = false }, false},
+		{"issuer lost its role", func(c *Claims) { c.IssuerAuthorized = false }, false},
+		{"media no longer matches", func(c *Claims) { c.Hash.Matches = false }, false},
+		{"credential transferred", func(c *Claims) { c.Owner = "0x00000000000000000000000000000000000000a1" }, false},
+		{"another recipient", func(c *Claims) { c.Recipient = "0x00000000000000000000000000000000000000b1" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := base
+			tt.change(&changed)
+			if same := changed.Outcome() == base.Outcome(); same != tt.same {
+				t.Errorf("same outcome = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
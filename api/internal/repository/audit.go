@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"api/internal/customErrors"
+	"api/internal/models"
+	"strconv"
+)
+
+type AccessLogInput struct {
+	Action   models.AuditAction `form:"action"` // Only entries with this action
+	Limit    int                `form:"limit"`  // Default: 20, max: 100
+	Cursor   string             `form:"cursor"` // next_cursor of the previous page
+	BeforeID uint64             `form:"-"`      // Parsed from Cursor
+}
+
+func (a *AccessLogInput) Validate() interface{} {
+	switch a.Action {
+	case "", models.AuditAccessCheck, models.AuditAccessGrant, models.AuditAccessDeny, models.AuditCredentialRevoke, models.AuditLogin,
+		models.AuditKeyRelease:
+	default:
+		return customErrors.ErrInvalidEventType
+	}
+
+	if a.Limit <= 0 {
+		a.Limit = DefaultListLimit
+	} else if a.Limit > MaxListLimit {
+		a.Limit = MaxListLimit
+	}
+
+	if a.Cursor != "" {
+		id, err := strconv.ParseUint(a.Cursor, 10, 64)
+		if err != nil || id == 0 {
+			return customErrors.ErrInvalidCursor
+		}
+		a.BeforeID = id
+	}
+	return nil
+}
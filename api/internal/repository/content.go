@@ -0,0 +1,29 @@
+package repository
+
+import "api/internal/customErrors"
+
+type KeyReleaseMode string
+
+const (
+	ReleaseECIES KeyReleaseMode = "ecies" // Data key encrypted to the public key recovered from the signature
+	ReleasePlain KeyReleaseMode = "plain" // Data key returned as is, protected only by TLS
+)
+
+type KeyReleaseInput struct {
+	Mode      KeyReleaseMode `json:"mode"`      // Default: ecies
+	Signature string         `json:"signature"` // personal_sign signature over the key release payload, required for ecies
+}
+
+func (k *KeyReleaseInput) Validate() interface{} {
+	switch k.Mode {
+	case "":
+		k.Mode = ReleaseECIES
+	case ReleaseECIES, ReleasePlain:
+	default:
+		return customErrors.ErrInvalidData
+	}
+	if k.Mode == ReleaseECIES && k.Signature == "" {
+		return customErrors.ErrInsufficientData
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package repository
+
+type CredentialProofInput struct {
+	Signature string `json:"signature" binding:"required"` // EIP-712 signature of the issuer, 0x prefixed
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"api/internal/customErrors"
+	"api/internal/models"
+)
+
+type EmailInput struct {
+	Email string `json:"email" binding:"required,email,max=320"` // Notification address, verified by link
+}
+
+type NotificationPreferencesInput struct {
+	Preferences map[string]bool `json:"preferences" binding:"required"` // Example: {"request.expiring": false}
+}
+
+func (n *NotificationPreferencesInput) Validate() interface{} {
+	for notificationType := range n.Preferences {
+		valid := false
+		for _, t := range models.NotificationTypes {
+			if t == notificationType {
+				valid = true
+			}
+		}
+		if !valid {
+			return customErrors.ErrInvalidEventType
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"api/internal/customErrors"
+	"api/internal/webhooks"
+	"encoding/base64"
+	"strings"
+)
+
+// PushSubscriptionInput matches the JSON of a browser PushSubscription.
+type PushSubscriptionInput struct {
+	Endpoint string `json:"endpoint" binding:"required"`
+	Keys     struct {
+		P256dh string `json:"p256dh" binding:"required"` // Browser public key, base64url
+		Auth   string `json:"auth" binding:"required"`   // Browser auth secret, base64url
+	} `json:"keys" binding:"required"`
+}
+
+func (p *PushSubscriptionInput) Validate() interface{} {
+	// The API posts to the endpoint, it must not point at internal services
+	if err := webhooks.ValidateURL(p.Endpoint); err != nil {
+		return customErrors.ErrInvalidSubscription
+	}
+	p256dh, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(p.Keys.P256dh, "="))
+	if err != nil || len(p256dh) != 65 {
+		return customErrors.ErrInvalidSubscription
+	}
+	auth, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(p.Keys.Auth, "="))
+	if err != nil || len(auth) != 16 {
+		return customErrors.ErrInvalidSubscription
+	}
+	return nil
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"api/internal/customErrors"
+	"api/internal/models"
 	"github.com/holiman/uint256"
+	"strings"
+	"time"
 )
 
 type CreateRequestInput struct {
@@ -33,20 +36,117 @@ func (r *RespondRequestInput) Validate() interface{} {
 	return nil
 }
 
-type WalletTypeEnum string
+type RoleEnum string
 
 const (
-	StudentWallet   WalletTypeEnum = "student_wallet"
-	RecipientWallet WalletTypeEnum = "recipient_wallet"
+	StudentRole   RoleEnum = "student"
+	RecipientRole RoleEnum = "recipient"
 )
 
-type GetWalletType struct {
-	WalletType WalletTypeEnum `json:"wallet_type" binding:"required"` // "student_wallet" or "recipient_wallet"
+// Column returns the requests column holding the caller's wallet for the role.
+func (r RoleEnum) Column() string {
+	if r == StudentRole {
+		return "student_wallet"
+	}
+	return "recipient_wallet"
+}
+
+// CounterpartyColumn returns the requests column holding the other party's wallet.
+func (r RoleEnum) CounterpartyColumn() string {
+	if r == StudentRole {
+		return "recipient_wallet"
+	}
+	return "student_wallet"
+}
+
+type SortEnum string
+
+const (
+	SortCreatedAsc  SortEnum = "created_at"
+	SortCreatedDesc SortEnum = "-created_at"
+	SortExpiryAsc   SortEnum = "expiry_timestamp"
+	SortExpiryDesc  SortEnum = "-expiry_timestamp"
+)
+
+// Column returns the requests column the sort order is keyed on.
+func (s SortEnum) Column() string {
+	if s == SortExpiryAsc || s == SortExpiryDesc {
+		return "expiry_timestamp"
+	}
+	return "created_at"
+}
+
+// Descending reports whether the sort order is newest/latest first.
+func (s SortEnum) Descending() bool {
+	return s == SortCreatedDesc || s == SortExpiryDesc
+}
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+type ListRequestsInput struct {
+	Role          RoleEnum               `form:"role"`           // "student" or "recipient"
+	Status        []models.RequestStatus `form:"status"`         // Repeatable or comma separated
+	Counterparty  string                 `form:"counterparty"`   // Wallet of the other party
+	CreatedAfter  time.Time              `form:"created_after"`  // RFC 3339
+	CreatedBefore time.Time              `form:"created_before"` // RFC 3339
+	ExpiresAfter  time.Time              `form:"expires_after"`  // RFC 3339
+	ExpiresBefore time.Time              `form:"expires_before"` // RFC 3339
+	Sort          SortEnum               `form:"sort"`           // Default: -created_at
+	Limit         int                    `form:"limit"`          // Default: 20, max: 100
+	Cursor        string                 `form:"cursor"`         // Opaque cursor from a previous page
 }
 
-func (g *GetWalletType) Validate() interface{} {
-	if !(g.WalletType == StudentWallet || g.WalletType == RecipientWallet) {
-		return customErrors.ErrInvalidWalletType
+func (l *ListRequestsInput) Validate() interface{} {
+	if !(l.Role == StudentRole || l.Role == RecipientRole) {
+		return customErrors.ErrInvalidRole
+	}
+
+	var statuses []models.RequestStatus
+	for _, status := range l.Status {
+		for _, s := range strings.Split(string(status), ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			switch models.RequestStatus(s) {
+			case models.Pending, models.Approved, models.Denied, models.Expired:
+				statuses = append(statuses, models.RequestStatus(s))
+			default:
+				return customErrors.ErrInvalidStatusFilter
+			}
+		}
+	}
+	l.Status = statuses
+
+	switch l.Sort {
+	case "":
+		l.Sort = SortCreatedDesc
+	case SortCreatedAsc, SortCreatedDesc, SortExpiryAsc, SortExpiryDesc:
+	default:
+		return customErrors.ErrInvalidSortOrder
+	}
+
+	if l.Limit <= 0 {
+		l.Limit = DefaultListLimit
+	} else if l.Limit > MaxListLimit {
+		l.Limit = MaxListLimit
+	}
+
+	if (!l.CreatedAfter.IsZero() && !l.CreatedBefore.IsZero() && l.CreatedAfter.After(l.CreatedBefore)) ||
+		(!l.ExpiresAfter.IsZero() && !l.ExpiresBefore.IsZero() && l.ExpiresAfter.After(l.ExpiresBefore)) {
+		return customErrors.ErrInvalidDateRange
 	}
 	return nil
 }
+
+type SendMessageInput struct {
+	Body      string `json:"body" binding:"required,max=4000"` // Message text
+	Signature string `json:"signature" binding:"required"`     // personal_sign signature over the message payload
+}
+
+type PresentationProofInput struct {
+	Signature string `json:"signature" binding:"required"` // EIP-712 signature of the student, 0x prefixed
+}
@@ -0,0 +1,5 @@
+package repository
+
+type UploadInput struct {
+	Encrypt bool `form:"encrypt"` // Envelope encrypt the file before it is stored, default: false
+}
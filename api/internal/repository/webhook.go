@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"api/internal/customErrors"
+	"api/internal/webhooks"
+)
+
+type WebhookEndpointInput struct {
+	URL        string   `json:"url" binding:"required"` // URL receiving the events
+	EventTypes []string `json:"event_types"`            // Subscribed event types, empty for all
+}
+
+func (w *WebhookEndpointInput) Validate() interface{} {
+	if err := webhooks.ValidateURL(w.URL); err != nil {
+		return customErrors.ErrInvalidWebhookURL
+	}
+	for _, eventType := range w.EventTypes {
+		if !webhooks.IsEventType(eventType) {
+			return customErrors.ErrInvalidEventType
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package statuslist
+
+import (
+	"api/internal/models"
+	"api/internal/receipt"
+	"api/pkg/constants"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// Type is the typ header of published status lists, a VC-JOSE secured credential.
+const Type = "vc+jwt"
+
+// Purpose is the only status published: whether the credential was revoked on chain.
+const Purpose = "revocation"
+
+var ErrNotPublished = errors.New("status list not published")
+
+// Lists assigns credentials their index in a status list and publishes the lists, signed with the
+// API's key, at stable URLs under BaseURL. The issuer of a list is BaseURL; verifiers find the
+// signing key by the kid header of the list in the JWK Set served at KeysURL.
+type Lists struct {
+	DB      *gorm.DB
+	Key     *receipt.Key
+	BaseURL string // Public URL of the API
+}
+
+// URL returns where the status list is served.
+func (l *Lists) URL(listID uint) string {
+	return fmt.Sprintf("%s/v1/public/status-lists/%d", l.BaseURL, listID)
+}
+
+// KeysURL returns where the JWK Set holding the keys lists are signed with is served.
+func (l *Lists) KeysURL() string {
+	return l.BaseURL + "/v1/receipts/keys"
+}
+
+// Assign returns the status list entry of the credential, assigning the next free index when it
+// has none yet. revoked is the credential's current status on chain, in case its revocation was
+// indexed before it had an entry.
+func (l *Lists) Assign(ctx context.Context, tokenID string, revoked bool) (models.StatusListEntry, error) {
+	var entry models.StatusListEntry
+	err := l.DB.WithContext(ctx).Take(&entry, "token_id = ?", tokenID).Error
+	if err == nil {
+		return entry, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return entry, err
+	}
+
+	var list models.StatusList
+	err = l.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("id DESC").Take(&list).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && list.NextIndex >= constants.StatusListSize) {
+			list = models.StatusList{}
+			err = tx.Create(&list).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		entry = models.StatusListEntry{TokenID: tokenID, ListID: list.ID, StatusIndex: list.NextIndex, Revoked: revoked}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Assigned concurrently, keep that index
+			return tx.Take(&entry, "token_id = ?", tokenID).Error
+		}
+		list.NextIndex++
+		return tx.Model(&list).Update("next_index", list.NextIndex).Error
+	})
+	if err != nil {
+		return entry, err
+	}
+
+	// A new list must be served before credentials point at it, a revoked entry must show up
+	if list.Document == "" || entry.Revoked {
+		if err := l.Publish(ctx, entry.ListID); err != nil {
+			return entry, err
+		}
+	}
+	return entry, nil
+}
+
+// Publish signs the status list credential of the list from its current entries.
+func (l *Lists) Publish(ctx context.Context, listID uint) error {
+	return l.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serializes publishers, so an older bitstring never replaces a newer one
+		var list models.StatusList
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&list, "id = ?", listID).Error; err != nil {
+			return err
+		}
+		var revoked []int
+		if err := tx.Model(&models.StatusListEntry{}).Where("list_id = ? AND revoked", listID).
+			Pluck("status_index", &revoked).Error; err != nil {
+			return err
+		}
+		encoded, err := Encode(revoked)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC().Truncate(time.Second)
+		url := l.URL(listID)
+		document, err := l.Key.SignJWS(Type, map[string]interface{}{
+			"@context":  []string{"https://www.w3.org/ns/credentials/v2"},
+			"id":        url,
+			"type":      []string{"VerifiableCredential", "BitstringStatusListCredential"},
+			"issuer":    l.BaseURL,
+			"validFrom": now.Format(time.RFC3339),
+			"credentialSubject": map[string]interface{}{
+				"id":            url + "#list",
+				"type":          "BitstringStatusList",
+				"statusPurpose": Purpose,
+				"encodedList":   encoded,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Model(&list).Updates(map[string]interface{}{"document": document, "published_at": now}).Error
+	})
+}
+
+// PublishFor republishes the list holding the credential, if it has an entry.
+func (l *Lists) PublishFor(ctx context.Context, tokenID string) error {
+	var entry models.StatusListEntry
+	err := l.DB.WithContext(ctx).Take(&entry, "token_id = ?", tokenID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return l.Publish(ctx, entry.ListID)
+}
+
+// Get returns the published list.
+func (l *Lists) Get(ctx context.Context, listID uint) (models.StatusList, error) {
+	var list models.StatusList
+	err := l.DB.WithContext(ctx).Take(&list, "id = ?", listID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && list.Document == "") {
+		return list, ErrNotPublished
+	}
+	return list, err
+}
+
+// MarkRevoked flags the credential's entry as revoked, if it has one. It runs in the transaction
+// that indexes the revocation; the list is republished after.
+func MarkRevoked(tx *gorm.DB, tokenID string) error {
+	return tx.Model(&models.StatusListEntry{}).Where("token_id = ?", tokenID).Update("revoked", true).Error
+}
+
+// Encode returns the encodedList of a status list with the given bits set: the GZIP compressed
+// bitstring, base64url encoded without padding behind the multibase prefix u. Index 0 is the
+// left-most bit of the first byte.
+func Encode(indexes []int) (string, error) {
+	bitstring := make([]byte, constants.StatusListSize/8)
+	for _, index := range indexes {
+		if index < 0 || index >= constants.StatusListSize {
+			return "", fmt.Errorf("status list index %d out of range", index)
+		}
+		bitstring[index/8] |= 0x80 >> (index % 8)
+	}
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(bitstring); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return "u" + base64.RawURLEncoding.EncodeToString(compressed.Bytes()), nil
+}
@@ -0,0 +1,75 @@
+package statuslist
+
+import (
+	"api/pkg/constants"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+)
+
+// decode reverses Encode into the raw bitstring.
+func decode(t *testing.T, encoded string) []byte {
+	t.Helper()
+	data, found := strings.CutPrefix(encoded, "u")
+	if !found {
+		t.Fatalf("encoded list %.10s... lacks the multibase prefix u", encoded)
+	}
+	compressed, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatalf("not base64url without padding: %v", err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("not GZIP: %v", err)
+	}
+	bitstring, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bitstring
+}
+
+func TestEncode(t *testing.T) {
+	last := constants.StatusListSize - 1
+	tests := []struct {
+		name    string
+		indexes []int
+		set     map[int]byte // Byte offset to its expected value, every other byte is zero
+	}{
+		{"empty list", nil, nil},
+		{"first index is the left-most bit", []int{0}, map[int]byte{0: 0x80}},
+		{"eighth index is the right-most bit", []int{7}, map[int]byte{0: 0x01}},
+		{"ninth index starts the next byte", []int{8}, map[int]byte{1: 0x80}},
+		{"indexes in the same byte", []int{1, 6, 3}, map[int]byte{0: 0x52}},
+		{"duplicate index", []int{9, 9}, map[int]byte{1: 0x40}},
+		{"last index", []int{last}, map[int]byte{last / 8: 0x01}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := Encode(tt.indexes)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			bitstring := decode(t, encoded)
+			if len(bitstring) != constants.StatusListSize/8 {
+				t.Fatalf("bitstring is %d bytes, want %d", len(bitstring), constants.StatusListSize/8)
+			}
+			for offset, value := range bitstring {
+				if value != tt.set[offset] {
+					t.Errorf("byte %d = %#02x, want %#02x", offset, value, tt.set[offset])
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeOutOfRange(t *testing.T) {
+	for _, index := range []int{-1, constants.StatusListSize, constants.StatusListSize + 8} {
+		if _, err := Encode([]int{0, index}); err == nil {
+			t.Errorf("Encode accepted index %d", index)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package tsa
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// PKIStatus rejection and its failure reasons
+const (
+	statusRejection = 2
+	failBadAlg      = 0
+	failBadRequest  = 2
+)
+
+// LocalAuthority is an in-process time-stamp authority for tests and local development. Its
+// tokens are well formed RFC 3161 tokens signed by a self-signed certificate generated on
+// start, so they prove nothing to anyone else. It also serves the RFC 3161 HTTP transport so
+// Client can be pointed at it.
+type LocalAuthority struct {
+	AuthorityName string
+
+	key         *ecdsa.PrivateKey
+	certificate *x509.Certificate
+
+	mu     sync.Mutex
+	serial int64
+}
+
+func NewLocalAuthority(name string) (*LocalAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	// RFC 3161 requires the timestamping key usage to be the only one and critical, which
+	// x509.CreateCertificate cannot express through ExtKeyUsage
+	usage, err := asn1.Marshal([]asn1.ObjectIdentifier{oidExtKeyUsageTiming})
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name + " (local time-stamp authority)"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtraExtensions:       []pkix.Extension{{Id: oidExtKeyUsage, Critical: true, Value: usage}},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalAuthority{AuthorityName: name, key: key, certificate: certificate}, nil
+}
+
+func (l *LocalAuthority) Name() string {
+	return l.AuthorityName
+}
+
+// Certificate returns the certificate tokens are signed with.
+func (l *LocalAuthority) Certificate() *x509.Certificate {
+	return l.certificate
+}
+
+func (l *LocalAuthority) Timestamp(ctx context.Context, digest [32]byte) (Token, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return Token{}, err
+	}
+	query, err := asn1.Marshal(timeStampReq{
+		Version:        1,
+		MessageImprint: messageImprint{HashAlgorithm: algorithmIdentifier{Algorithm: oidSHA256}, HashedMessage: digest[:]},
+		Nonce:          nonce,
+		CertReq:        true,
+	})
+	if err != nil {
+		return Token{}, err
+	}
+	response, err := l.respond(query, time.Now())
+	if err != nil {
+		return Token{}, err
+	}
+	return parseResponse(response, digest, nonce)
+}
+
+// ServeHTTP answers application/timestamp-query POSTs with application/timestamp-reply.
+func (l *LocalAuthority) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response, err := l.respond(query, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	w.Write(response)
+}
+
+// respond builds the TimeStampResp to a DER encoded TimeStampReq. Requests it cannot serve get a
+// rejection status rather than an error, as a real authority would answer.
+func (l *LocalAuthority) respond(query []byte, now time.Time) ([]byte, error) {
+	var request timeStampReq
+	if rest, err := asn1.Unmarshal(query, &request); err != nil || len(rest) > 0 || request.Version != 1 {
+		return rejection(failBadRequest, "malformed TimeStampReq")
+	}
+	hash, ok := digestAlgorithms[request.MessageImprint.HashAlgorithm.Algorithm.String()]
+	if !ok || len(request.MessageImprint.HashedMessage) != hash.Size() {
+		return rejection(failBadAlg, "unsupported hash algorithm")
+	}
+
+	l.mu.Lock()
+	l.serial++
+	serial := l.serial
+	l.mu.Unlock()
+
+	content, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         oidLocalPolicy,
+		MessageImprint: request.MessageImprint,
+		SerialNumber:   big.NewInt(serial),
+		GenTime:        now.UTC().Truncate(time.Second),
+		Accuracy:       accuracy{Seconds: 1},
+		Nonce:          request.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+	token, err := l.sign(content)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(timeStampResp{
+		Status:         pkiStatusInfo{Status: statusGranted},
+		TimeStampToken: asn1.RawValue{FullBytes: token},
+	})
+}
+
+// sign wraps the TSTInfo in a CMS SignedData signed by the authority's certificate.
+func (l *LocalAuthority) sign(content []byte) ([]byte, error) {
+	contentDigest := sha256.Sum256(content)
+	certDigest := sha256.Sum256(l.certificate.Raw)
+	var attributes []attribute
+	for _, attr := range []struct {
+		oid   asn1.ObjectIdentifier
+		value interface{}
+	}{
+		{oidContentType, oidTSTInfo},
+		{oidMessageDigest, contentDigest[:]},
+		{oidSigningCertV2, signingCertificateV2{Certs: []essCertIDv2{{CertHash: certDigest[:]}}}},
+	} {
+		value, err := asn1.Marshal(attr.value)
+		if err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, attribute{
+			Type:   attr.oid,
+			Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+		})
+	}
+	signedAttrs, err := asn1.MarshalWithParams(attributes, "set")
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(signedAttrs)
+	signature, err := ecdsa.SignASN1(rand.Reader, l.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// In the SignerInfo the attributes are tagged implicit [0] instead of SET OF
+	implicit := append([]byte{0xa0}, signedAttrs[1:]...)
+	signed, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []algorithmIdentifier{{Algorithm: oidSHA256}},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidTSTInfo, EContent: content},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: l.certificate.Raw},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: l.certificate.RawIssuer}, SerialNumber: l.certificate.SerialNumber},
+			DigestAlgorithm:    algorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        asn1.RawValue{FullBytes: implicit},
+			SignatureAlgorithm: algorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			Signature:          signature,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// A RawValue is written as is, so the explicit [0] around the content is spelled out
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signed},
+	})
+}
+
+func rejection(failure int, text string) ([]byte, error) {
+	info := asn1.BitString{Bytes: []byte{0}, BitLength: failure + 1}
+	info.Bytes[0] = 0x80 >> failure
+	return asn1.Marshal(timeStampResp{Status: pkiStatusInfo{
+		Status:       statusRejection,
+		StatusString: []asn1.RawValue{{Tag: asn1.TagUTF8String, Bytes: []byte(text)}},
+		FailInfo:     info,
+	}})
+}
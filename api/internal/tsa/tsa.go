@@ -0,0 +1,246 @@
+package tsa
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRejected = errors.New("time-stamp authority rejected the request")
+	ErrInvalid  = errors.New("invalid time-stamp token")
+)
+
+// maxResponseSize bounds a TimeStampResp; tokens are a few KiB with the TSA certificate.
+const maxResponseSize = 1 << 20
+
+// Token is an RFC 3161 TimeStampToken over a SHA-256 digest. DER is the CMS SignedData as
+// returned by the authority; the other fields are read from it.
+type Token struct {
+	DER          []byte
+	GenTime      time.Time
+	SerialNumber string
+	Policy       string
+}
+
+// Authority timestamps digests.
+type Authority interface {
+	// Name identifies the authority in stored timestamps.
+	Name() string
+	// Timestamp returns a token binding the SHA-256 digest to the authority's current time.
+	Timestamp(ctx context.Context, digest [32]byte) (Token, error)
+}
+
+// Client asks an RFC 3161 authority for timestamps over HTTP.
+type Client struct {
+	AuthorityName string
+	URL           string
+	HTTP          *http.Client
+}
+
+func NewClient(name, url string) *Client {
+	return &Client{AuthorityName: name, URL: url, HTTP: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (c *Client) Name() string {
+	return c.AuthorityName
+}
+
+// Timestamp sends a TimeStampReq with a random nonce and checks the token the authority returns
+// covers the digest and nonce and is signed by the certificate it carries.
+func (c *Client) Timestamp(ctx context.Context, digest [32]byte) (Token, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return Token{}, err
+	}
+	query, err := asn1.Marshal(timeStampReq{
+		Version:        1,
+		MessageImprint: messageImprint{HashAlgorithm: algorithmIdentifier{Algorithm: oidSHA256}, HashedMessage: digest[:]},
+		Nonce:          nonce,
+		CertReq:        true,
+	})
+	if err != nil {
+		return Token{}, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(query))
+	if err != nil {
+		return Token{}, err
+	}
+	request.Header.Set("Content-Type", "application/timestamp-query")
+	response, err := c.HTTP.Do(request)
+	if err != nil {
+		return Token{}, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxResponseSize))
+	if err != nil {
+		return Token{}, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return Token{}, fmt.Errorf("time-stamp authority %s: %s", c.URL, response.Status)
+	}
+	return parseResponse(body, digest, nonce)
+}
+
+func parseResponse(der []byte, digest [32]byte, nonce *big.Int) (Token, error) {
+	var resp timeStampResp
+	if rest, err := asn1.Unmarshal(der, &resp); err != nil || len(rest) > 0 {
+		return Token{}, fmt.Errorf("%w: malformed TimeStampResp", ErrInvalid)
+	}
+	if resp.Status.Status != statusGranted && resp.Status.Status != statusGrantedWithMods {
+		var text []string
+		for _, value := range resp.Status.StatusString {
+			text = append(text, string(value.Bytes))
+		}
+		return Token{}, fmt.Errorf("%w: status %d %s", ErrRejected, resp.Status.Status, strings.Join(text, "; "))
+	}
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return Token{}, fmt.Errorf("%w: response has no token", ErrInvalid)
+	}
+
+	token, info, err := parseToken(resp.TimeStampToken.FullBytes)
+	if err != nil {
+		return Token{}, err
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) || !bytes.Equal(info.MessageImprint.HashedMessage, digest[:]) {
+		return Token{}, fmt.Errorf("%w: token is for another digest", ErrInvalid)
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return Token{}, fmt.Errorf("%w: nonce does not match the request", ErrInvalid)
+	}
+	return token, nil
+}
+
+// Parse decodes a TimeStampToken and checks its signature against the signing certificate it
+// carries. Whether that certificate belongs to a trusted authority is left to whoever relies on
+// the token.
+func Parse(der []byte) (Token, error) {
+	token, _, err := parseToken(der)
+	return token, err
+}
+
+func parseToken(der []byte) (Token, tstInfo, error) {
+	var info tstInfo
+	var content contentInfo
+	if rest, err := asn1.Unmarshal(der, &content); err != nil || len(rest) > 0 || !content.ContentType.Equal(oidSignedData) {
+		return Token{}, info, fmt.Errorf("%w: not a CMS SignedData", ErrInvalid)
+	}
+	var signed signedData
+	if _, err := asn1.Unmarshal(content.Content.Bytes, &signed); err != nil {
+		return Token{}, info, fmt.Errorf("%w: malformed SignedData: %v", ErrInvalid, err)
+	}
+	if !signed.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return Token{}, info, fmt.Errorf("%w: content is not a TSTInfo", ErrInvalid)
+	}
+	if _, err := asn1.Unmarshal(signed.EncapContentInfo.EContent, &info); err != nil {
+		return Token{}, info, fmt.Errorf("%w: malformed TSTInfo: %v", ErrInvalid, err)
+	}
+	if len(signed.SignerInfos) != 1 {
+		return Token{}, info, fmt.Errorf("%w: expected one signer, got %d", ErrInvalid, len(signed.SignerInfos))
+	}
+	if err := verifySigner(signed, signed.SignerInfos[0]); err != nil {
+		return Token{}, info, err
+	}
+
+	return Token{
+		DER:          der,
+		GenTime:      info.GenTime,
+		SerialNumber: info.SerialNumber.String(),
+		Policy:       info.Policy.String(),
+	}, info, nil
+}
+
+// verifySigner checks the signer's signed attributes cover the TSTInfo and are signed by the
+// matching certificate, which must be meant for timestamping.
+func verifySigner(signed signedData, signer signerInfo) error {
+	certificates, err := x509.ParseCertificates(signed.Certificates.Bytes)
+	if err != nil {
+		return fmt.Errorf("%w: malformed certificates: %v", ErrInvalid, err)
+	}
+	var certificate *x509.Certificate
+	for _, candidate := range certificates {
+		if bytes.Equal(candidate.RawIssuer, signer.SID.Issuer.FullBytes) && candidate.SerialNumber.Cmp(signer.SID.SerialNumber) == 0 {
+			certificate = candidate
+		}
+	}
+	if certificate == nil {
+		return fmt.Errorf("%w: token does not carry the signing certificate", ErrInvalid)
+	}
+	timestamping := false
+	for _, usage := range certificate.ExtKeyUsage {
+		timestamping = timestamping || usage == x509.ExtKeyUsageTimeStamping
+	}
+	if !timestamping {
+		return fmt.Errorf("%w: signing certificate is not for timestamping", ErrInvalid)
+	}
+
+	hash, ok := digestAlgorithms[signer.DigestAlgorithm.Algorithm.String()]
+	if !ok {
+		return fmt.Errorf("%w: unsupported digest algorithm %s", ErrInvalid, signer.DigestAlgorithm.Algorithm)
+	}
+	if len(signer.SignedAttrs.Bytes) == 0 {
+		return fmt.Errorf("%w: signer has no signed attributes", ErrInvalid)
+	}
+	var attributes []attribute
+	if _, err := asn1.UnmarshalWithParams(signer.SignedAttrs.FullBytes, &attributes, "set,tag:0"); err != nil {
+		return fmt.Errorf("%w: malformed signed attributes: %v", ErrInvalid, err)
+	}
+	contentDigest := hash.New()
+	contentDigest.Write(signed.EncapContentInfo.EContent)
+	if !bytes.Equal(attributeOctets(attributes, oidMessageDigest), contentDigest.Sum(nil)) {
+		return fmt.Errorf("%w: message digest does not match the TSTInfo", ErrInvalid)
+	}
+
+	// The signature covers the attributes with their SET OF tag rather than the implicit [0]
+	signedBytes := append([]byte{0x31}, signer.SignedAttrs.FullBytes[1:]...)
+	algorithm, err := signatureAlgorithm(hash, certificate.PublicKeyAlgorithm)
+	if err != nil {
+		return err
+	}
+	if err := certificate.CheckSignature(algorithm, signedBytes, signer.Signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return nil
+}
+
+// attributeOctets returns the first value of the attribute as an OCTET STRING.
+func attributeOctets(attributes []attribute, oid asn1.ObjectIdentifier) []byte {
+	for _, attr := range attributes {
+		if !attr.Type.Equal(oid) {
+			continue
+		}
+		var value []byte
+		if _, err := asn1.Unmarshal(attr.Values.Bytes, &value); err == nil {
+			return value
+		}
+	}
+	return nil
+}
+
+func signatureAlgorithm(hash crypto.Hash, key x509.PublicKeyAlgorithm) (x509.SignatureAlgorithm, error) {
+	algorithms := map[x509.PublicKeyAlgorithm]map[crypto.Hash]x509.SignatureAlgorithm{
+		x509.RSA:   {crypto.SHA256: x509.SHA256WithRSA, crypto.SHA384: x509.SHA384WithRSA, crypto.SHA512: x509.SHA512WithRSA},
+		x509.ECDSA: {crypto.SHA256: x509.ECDSAWithSHA256, crypto.SHA384: x509.ECDSAWithSHA384, crypto.SHA512: x509.ECDSAWithSHA512},
+	}
+	if algorithm, ok := algorithms[key][hash]; ok {
+		return algorithm, nil
+	}
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("%w: unsupported %s key with %s", ErrInvalid, key, hash)
+}
+
+// Digest is the SHA-256 digest timestamps are requested over.
+func Digest(data []byte) [32]byte {
+	return sha256.Sum256(data)
+}
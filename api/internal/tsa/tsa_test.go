@@ -0,0 +1,137 @@
+package tsa
+
+import (
+	"bytes"
+	"context"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimestamp(t *testing.T) {
+	ctx := context.Background()
+	local, err := NewLocalAuthority("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(local)
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name      string
+		authority Authority
+		serial    string
+	}{
+		{"in process", local, "1"},
+		{"over HTTP", NewClient("test", server.URL), "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now().Add(-time.Second)
+			token, err := tt.authority.Timestamp(ctx, Digest([]byte(tt.name)))
+			if err != nil {
+				t.Fatalf("Timestamp: %v", err)
+			}
+			if token.GenTime.Before(before) || token.GenTime.After(time.Now()) {
+				t.Errorf("GenTime = %v, want about now", token.GenTime)
+			}
+			if token.SerialNumber != tt.serial || token.Policy != oidLocalPolicy.String() {
+				t.Errorf("serial %s policy %s, want serial %s", token.SerialNumber, token.Policy, tt.serial)
+			}
+
+			parsed, err := Parse(token.DER)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !parsed.GenTime.Equal(token.GenTime) || parsed.SerialNumber != token.SerialNumber || parsed.Policy != token.Policy {
+				t.Errorf("Parse = %+v, want %+v", parsed, token)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	local, err := NewLocalAuthority("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := Digest([]byte("transcript"))
+	token, err := local.Timestamp(context.Background(), digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flip := func(at int) []byte {
+		tampered := append([]byte(nil), token.DER...)
+		tampered[at] ^= 1
+		return tampered
+	}
+
+	tests := []struct {
+		name string
+		der  []byte
+	}{
+		{"empty", nil},
+		{"garbage", []byte("not a token")},
+		{"truncated", token.DER[:len(token.DER)/2]},
+		{"trailing data", append(append([]byte(nil), token.DER...), 0)},
+		{"altered digest", flip(bytes.Index(token.DER, digest[:]))},
+		{"altered signature", flip(len(token.DER) - 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.der); !errors.Is(err, ErrInvalid) {
+				t.Errorf("err = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	local, err := NewLocalAuthority("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := Digest([]byte("transcript"))
+	nonce := big.NewInt(42)
+	query, err := asn1.Marshal(timeStampReq{
+		Version:        1,
+		MessageImprint: messageImprint{HashAlgorithm: algorithmIdentifier{Algorithm: oidSHA256}, HashedMessage: digest[:]},
+		Nonce:          nonce,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	granted, err := local.respond(query, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejected, err := local.respond([]byte("not a query"), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		response []byte
+		digest   [32]byte
+		nonce    *big.Int
+		wantErr  error
+	}{
+		{"granted", granted, digest, nonce, nil},
+		{"another digest", granted, Digest([]byte("other")), nonce, ErrInvalid},
+		{"another nonce", granted, digest, big.NewInt(43), ErrInvalid},
+		{"rejected", rejected, digest, nonce, ErrRejected},
+		{"malformed", []byte{0x30, 0x01}, digest, nonce, ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseResponse(tt.response, tt.digest, tt.nonce)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package vc
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"math/big"
+	"time"
+)
+
+var ErrUnsignedCredential = errors.New("credential has no proof")
+
+// PresentationProof is the holder's EthereumEip712Signature2021 proof over a presentation. The
+// challenge and domain bind it to one request and one recipient, so it cannot be replayed to
+// anyone else.
+type PresentationProof struct {
+	Type               string         `json:"type"`
+	Created            time.Time      `json:"created"`
+	ProofPurpose       string         `json:"proofPurpose"`
+	VerificationMethod string         `json:"verificationMethod"`
+	Challenge          string         `json:"challenge"` // ID of the request the presentation answers
+	Domain             string         `json:"domain"`    // did:pkh of the recipient
+	ProofValue         string         `json:"proofValue"`
+	Eip712             *Eip712Details `json:"eip712"`
+}
+
+// Presentation is a W3C Verifiable Presentation of signed credentials. The credentials are kept
+// as the exact JSON their issuers signed.
+type Presentation struct {
+	Context              []string           `json:"@context"`
+	ID                   string             `json:"id"`
+	Type                 []string           `json:"type"`
+	Holder               string             `json:"holder"` // did:pkh of the student wallet
+	VerifiableCredential []json.RawMessage  `json:"verifiableCredential"`
+	Proof                *PresentationProof `json:"proof,omitempty"`
+}
+
+// PresentationRequest is what a presentation is built for: the request being answered, its
+// parties and the signed credentials the student chose to share.
+type PresentationRequest struct {
+	ChainID     *big.Int
+	RequestID   string
+	Holder      common.Address
+	Audience    common.Address
+	Credentials []string // Signed Verifiable Credentials as JSON
+}
+
+// BuildPresentation bundles the signed credentials into an unsigned presentation and returns it
+// with the typed data the holder signs for it.
+func BuildPresentation(request PresentationRequest) (Presentation, apitypes.TypedData, error) {
+	presentation := Presentation{
+		Context: contexts,
+		ID:      "urn:nft-cms:request:" + request.RequestID,
+		Type:    []string{"VerifiablePresentation"},
+		Holder:  DID(request.ChainID, request.Holder),
+	}
+	references := make([]interface{}, 0, len(request.Credentials))
+	for _, document := range request.Credentials {
+		var credential Credential
+		if err := json.Unmarshal([]byte(document), &credential); err != nil {
+			return Presentation{}, apitypes.TypedData{}, err
+		}
+		if credential.Proof == nil {
+			return Presentation{}, apitypes.TypedData{}, ErrUnsignedCredential
+		}
+		presentation.VerifiableCredential = append(presentation.VerifiableCredential, json.RawMessage(document))
+		references = append(references, map[string]interface{}{
+			"id":         credential.ID,
+			"issuer":     credential.Issuer,
+			"proofValue": credential.Proof.ProofValue,
+		})
+	}
+
+	// Credentials are signed by reference: the issuer's proof value pins the exact document, so
+	// the holder does not have to sign every credential field again
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"VerifiablePresentation": {
+				{Name: "@context", Type: "string[]"},
+				{Name: "id", Type: "string"},
+				{Name: "type", Type: "string[]"},
+				{Name: "holder", Type: "string"},
+				{Name: "verifiableCredential", Type: "CredentialReference[]"},
+				{Name: "challenge", Type: "string"},
+				{Name: "domain", Type: "string"},
+			},
+			"CredentialReference": {
+				{Name: "id", Type: "string"},
+				{Name: "issuer", Type: "string"},
+				{Name: "proofValue", Type: "string"},
+			},
+		},
+		PrimaryType: "VerifiablePresentation",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "NFT-CMS Verifiable Presentation",
+			Version: "1",
+			ChainId: (*math.HexOrDecimal256)(request.ChainID),
+		},
+		Message: apitypes.TypedDataMessage{
+			"@context":             toInterfaces(presentation.Context),
+			"id":                   presentation.ID,
+			"type":                 toInterfaces(presentation.Type),
+			"holder":               presentation.Holder,
+			"verifiableCredential": references,
+			"challenge":            request.RequestID,
+			"domain":               DID(request.ChainID, request.Audience),
+		},
+	}
+	return presentation, typedData, nil
+}
+
+// AttachPresentationProof checks the signature is the holder's EIP-712 signature over the typed
+// data of the presentation and returns the presentation with its proof.
+func AttachPresentationProof(request PresentationRequest, signature string, created time.Time) (Presentation, error) {
+	presentation, typedData, err := BuildPresentation(request)
+	if err != nil {
+		return Presentation{}, err
+	}
+	if err := checkSigner(typedData, signature, request.Holder); err != nil {
+		return Presentation{}, err
+	}
+
+	presentation.Proof = &PresentationProof{
+		Type:               ProofType,
+		Created:            created.UTC().Truncate(time.Second),
+		ProofPurpose:       "authentication",
+		VerificationMethod: presentation.Holder + "#blockchainAccountId",
+		Challenge:          request.RequestID,
+		Domain:             DID(request.ChainID, request.Audience),
+		ProofValue:         signature,
+		Eip712: &Eip712Details{
+			Domain:      typedData.Domain,
+			Types:       typedData.Types,
+			PrimaryType: typedData.PrimaryType,
+		},
+	}
+	return presentation, nil
+}
+
+func toInterfaces(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, value := range values {
+		out[i] = value
+	}
+	return out
+}
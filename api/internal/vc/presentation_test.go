@@ -0,0 +1,95 @@
+package vc
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestPresentation(t *testing.T) {
+	issuerKey, issuer := generateKey(t)
+	holderKey, holder := generateKey(t)
+	otherKey, _ := generateKey(t)
+	_, audience := generateKey(t)
+	_, otherAudience := generateKey(t)
+
+	credential, err := Build(testSource(issuer, holder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	typedData, err := TypedData(credential, testChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := Attach(credential, testChainID, issuer, signTypedData(t, typedData, issuerKey), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedDocument, _ := json.Marshal(signed)
+	unsignedDocument, _ := json.Marshal(credential)
+
+	request := PresentationRequest{
+		ChainID:     testChainID,
+		RequestID:   "6f1c0d9e-2b1a-4c55-9a7e-3f0b8f1f2a10",
+		Holder:      holder,
+		Audience:    audience,
+		Credentials: []string{string(signedDocument)},
+	}
+	_, presentationData, err := BuildPresentation(request)
+	if err != nil {
+		t.Fatalf("BuildPresentation: %v", err)
+	}
+	signature := signTypedData(t, presentationData, holderKey)
+
+	otherRequest, otherAudienceRequest, otherChain := request, request, request
+	otherRequest.RequestID = "another-request"
+	otherAudienceRequest.Audience = otherAudience
+	otherChain.ChainID = big.NewInt(1)
+
+	tests := []struct {
+		name      string
+		request   PresentationRequest
+		signature string
+		wantErr   error
+	}{
+		{"holder signature", request, signature, nil},
+		{"another wallet", request, signTypedData(t, presentationData, otherKey), ErrWrongSigner},
+		{"replayed for another request", otherRequest, signature, ErrWrongSigner},
+		{"replayed to another recipient", otherAudienceRequest, signature, ErrWrongSigner},
+		{"replayed on another chain", otherChain, signature, ErrWrongSigner},
+		{"unsigned credential", withCredentials(request, string(unsignedDocument)), signature, ErrUnsignedCredential},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			presentation, err := AttachPresentationProof(tt.request, tt.signature, time.Now())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			proof := presentation.Proof
+			if proof == nil || proof.ProofValue != signature || proof.Challenge != request.RequestID ||
+				proof.Domain != DID(testChainID, audience) || presentation.Holder != DID(testChainID, holder) {
+				t.Fatalf("presentation = %+v, proof = %+v", presentation, proof)
+			}
+			// The issuer's document is embedded byte for byte, so its proof still verifies
+			if len(presentation.VerifiableCredential) != 1 || string(presentation.VerifiableCredential[0]) != string(signedDocument) {
+				t.Errorf("embedded credentials = %s", presentation.VerifiableCredential)
+			}
+		})
+	}
+
+	var syntaxErr *json.SyntaxError
+	if _, _, err := BuildPresentation(withCredentials(request, "{]")); !errors.As(err, &syntaxErr) {
+		t.Errorf("malformed credential: err = %v", err)
+	}
+}
+
+func withCredentials(request PresentationRequest, credentials ...string) PresentationRequest {
+	request.Credentials = credentials
+	return request
+}
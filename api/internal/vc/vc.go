@@ -0,0 +1,239 @@
+package vc
+
+import (
+	"api/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// ProofType is the Data Integrity proof suite credentials are signed with: an EIP-712 signature
+// by the issuer's wallet, so institutions sign with the wallet that issued the NFT.
+const ProofType = "EthereumEip712Signature2021"
+
+var (
+	ErrMetadataInvalid = errors.New("credential metadata is missing or invalid")
+	ErrWrongSigner     = errors.New("signature is not by the expected wallet")
+)
+
+var contexts = []string{
+	"https://www.w3.org/ns/credentials/v2",
+	"https://w3id.org/security/suites/eip712sig-2021/v1",
+}
+
+// Subject describes the student and what the credential attests.
+type Subject struct {
+	ID          string `json:"id"` // did:pkh of the wallet holding the NFT
+	TokenID     string `json:"tokenId"`
+	Contract    string `json:"contract"`
+	Name        string `json:"name"`
+	Institution string `json:"institution"`
+	Degree      string `json:"degree"`
+	MediaURI    string `json:"mediaUri"`
+	MediaHash   string `json:"mediaHash"`
+}
+
+// Status is a BitstringStatusListEntry: the bit of the credential in a published revocation list.
+type Status struct {
+	ID                   string `json:"id"`
+	Type                 string `json:"type"`
+	StatusPurpose        string `json:"statusPurpose"`
+	StatusListIndex      string `json:"statusListIndex"`
+	StatusListCredential string `json:"statusListCredential"`
+}
+
+// Proof is an EthereumEip712Signature2021 proof. Eip712 carries the domain and types the
+// credential was hashed with, so verifiers need nothing else to recompute the signed hash.
+type Proof struct {
+	Type               string         `json:"type"`
+	Created            time.Time      `json:"created"`
+	ProofPurpose       string         `json:"proofPurpose"`
+	VerificationMethod string         `json:"verificationMethod"`
+	ProofValue         string         `json:"proofValue"`
+	Eip712             *Eip712Details `json:"eip712"`
+}
+
+type Eip712Details struct {
+	Domain      apitypes.TypedDataDomain `json:"domain"`
+	Types       apitypes.Types           `json:"types"`
+	PrimaryType string                   `json:"primaryType"`
+}
+
+// Credential is a W3C Verifiable Credential (Data Model 2.0) of an NFT credential.
+type Credential struct {
+	Context           []string `json:"@context"`
+	ID                string   `json:"id"`
+	Type              []string `json:"type"`
+	Issuer            string   `json:"issuer"` // did:pkh of the institution wallet that signed the credential
+	ValidFrom         string   `json:"validFrom"`
+	Name              string   `json:"name"`
+	CredentialSubject Subject  `json:"credentialSubject"`
+	CredentialStatus  Status   `json:"credentialStatus"`
+	Proof             *Proof   `json:"proof,omitempty"`
+}
+
+// Source is what a credential is built from: the NFT as read from the chain and its indexed
+// metadata.
+type Source struct {
+	ChainID     *big.Int
+	Contract    common.Address
+	TokenID     *big.Int
+	Issuer      common.Address // Credential.signer on chain
+	Owner       common.Address
+	Metadata    models.TranscriptMetadata
+	StatusList  string // URL of the status list holding the credential
+	StatusIndex int    // Index of the credential in the status list
+}
+
+// DID returns the did:pkh identifier of an account on the chain.
+func DID(chainID *big.Int, address common.Address) string {
+	return fmt.Sprintf("did:pkh:eip155:%s:%s", chainID, address.Hex())
+}
+
+// Build maps the NFT credential to an unsigned Verifiable Credential. The result depends only
+// on the source, so the issuer can be shown the document, sign it, and have it rebuilt to check
+// the signature.
+func Build(source Source) (Credential, error) {
+	metadata := source.Metadata
+	if metadata.Status != models.MetadataValid || metadata.IssueDate == nil {
+		return Credential{}, ErrMetadataInvalid
+	}
+	return Credential{
+		Context:   contexts,
+		ID:        fmt.Sprintf("urn:nft-cms:eip155:%s:%s:%s", source.ChainID, source.Contract.Hex(), source.TokenID),
+		Type:      []string{"VerifiableCredential", "AcademicCredential"},
+		Issuer:    DID(source.ChainID, source.Issuer),
+		ValidFrom: metadata.IssueDate.UTC().Format(time.RFC3339),
+		Name:      metadata.Name,
+		CredentialSubject: Subject{
+			ID:          DID(source.ChainID, source.Owner),
+			TokenID:     source.TokenID.String(),
+			Contract:    source.Contract.Hex(),
+			Name:        metadata.Name,
+			Institution: metadata.Institution,
+			Degree:      metadata.Degree,
+			MediaURI:    metadata.MediaURI,
+			MediaHash:   metadata.MediaHash,
+		},
+		CredentialStatus: Status{
+			ID:                   fmt.Sprintf("%s#%d", source.StatusList, source.StatusIndex),
+			Type:                 "BitstringStatusListEntry",
+			StatusPurpose:        "revocation",
+			StatusListIndex:      strconv.Itoa(source.StatusIndex),
+			StatusListCredential: source.StatusList,
+		},
+	}, nil
+}
+
+// TypedData returns the EIP-712 typed data the issuer signs for the credential, following the
+// EthereumEip712Signature2021 suite: the credential without its proof is the message.
+func TypedData(credential Credential, chainID *big.Int) (apitypes.TypedData, error) {
+	credential.Proof = nil
+	raw, err := json.Marshal(credential)
+	if err != nil {
+		return apitypes.TypedData{}, err
+	}
+	var message apitypes.TypedDataMessage
+	if err := json.Unmarshal(raw, &message); err != nil {
+		return apitypes.TypedData{}, err
+	}
+
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"VerifiableCredential": {
+				{Name: "@context", Type: "string[]"},
+				{Name: "id", Type: "string"},
+				{Name: "type", Type: "string[]"},
+				{Name: "issuer", Type: "string"},
+				{Name: "validFrom", Type: "string"},
+				{Name: "name", Type: "string"},
+				{Name: "credentialSubject", Type: "CredentialSubject"},
+				{Name: "credentialStatus", Type: "CredentialStatus"},
+			},
+			"CredentialSubject": {
+				{Name: "id", Type: "string"},
+				{Name: "tokenId", Type: "string"},
+				{Name: "contract", Type: "string"},
+				{Name: "name", Type: "string"},
+				{Name: "institution", Type: "string"},
+				{Name: "degree", Type: "string"},
+				{Name: "mediaUri", Type: "string"},
+				{Name: "mediaHash", Type: "string"},
+			},
+			"CredentialStatus": {
+				{Name: "id", Type: "string"},
+				{Name: "type", Type: "string"},
+				{Name: "statusPurpose", Type: "string"},
+				{Name: "statusListIndex", Type: "string"},
+				{Name: "statusListCredential", Type: "string"},
+			},
+		},
+		PrimaryType: "VerifiableCredential",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "NFT-CMS Verifiable Credential",
+			Version: "1",
+			ChainId: (*math.HexOrDecimal256)(chainID),
+		},
+		Message: message,
+	}, nil
+}
+
+// Attach checks the signature is the issuer's EIP-712 signature over the credential and returns
+// the credential with its proof.
+func Attach(credential Credential, chainID *big.Int, issuer common.Address, signature string, created time.Time) (Credential, error) {
+	typedData, err := TypedData(credential, chainID)
+	if err != nil {
+		return Credential{}, err
+	}
+	if err := checkSigner(typedData, signature, issuer); err != nil {
+		return Credential{}, err
+	}
+
+	credential.Proof = &Proof{
+		Type:               ProofType,
+		Created:            created.UTC().Truncate(time.Second),
+		ProofPurpose:       "assertionMethod",
+		VerificationMethod: DID(chainID, issuer) + "#blockchainAccountId",
+		ProofValue:         signature,
+		Eip712: &Eip712Details{
+			Domain:      typedData.Domain,
+			Types:       typedData.Types,
+			PrimaryType: typedData.PrimaryType,
+		},
+	}
+	return credential, nil
+}
+
+// checkSigner returns ErrWrongSigner unless signature is signer's eth_signTypedData_v4 signature
+// over the typed data.
+func checkSigner(typedData apitypes.TypedData, signature string, signer common.Address) error {
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return err
+	}
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return ErrWrongSigner
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27 // Wallets return v as 27 or 28
+	}
+	public, err := crypto.SigToPub(hash, sig)
+	if err != nil || crypto.PubkeyToAddress(*public) != signer {
+		return ErrWrongSigner
+	}
+	return nil
+}
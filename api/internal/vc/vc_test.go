@@ -0,0 +1,177 @@
+package vc
+
+import (
+	"api/internal/models"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"math/big"
+	"testing"
+	"time"
+)
+
+var testChainID = big.NewInt(31337)
+
+// signTypedData signs like eth_signTypedData_v4, with v as 27 or 28.
+func signTypedData(t *testing.T, typedData apitypes.TypedData, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := crypto.Sign(hash, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(signature)
+}
+
+func generateKey(t *testing.T) (*ecdsa.PrivateKey, common.Address) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func testSource(issuer, owner common.Address) Source {
+	issued := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	return Source{
+		ChainID:  testChainID,
+		Contract: common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"),
+		TokenID:  big.NewInt(7),
+		Issuer:   issuer,
+		Owner:    owner,
+		Metadata: models.TranscriptMetadata{
+			Status:      models.MetadataValid,
+			Name:        "Transcript of Records",
+			Institution: "Example University",
+			Degree:      "BSc Computer Science",
+			IssueDate:   &issued,
+			MediaURI:    "ipfs://bafybeihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku/transcript.pdf",
+			MediaHash:   "0x" + common.Bytes2Hex(crypto.Keccak256([]byte("transcript"))),
+		},
+		StatusList:  "https://api.example/v1/public/status-lists/1",
+		StatusIndex: 42,
+	}
+}
+
+func TestBuild(t *testing.T) {
+	_, issuer := generateKey(t)
+	_, owner := generateKey(t)
+	valid := testSource(issuer, owner)
+
+	credential, err := Build(valid)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if credential.Issuer != DID(testChainID, issuer) || credential.CredentialSubject.ID != DID(testChainID, owner) {
+		t.Errorf("issuer %s subject %s", credential.Issuer, credential.CredentialSubject.ID)
+	}
+	if credential.ValidFrom != "2024-06-30T00:00:00Z" || credential.CredentialStatus.StatusListIndex != "42" ||
+		credential.CredentialStatus.ID != valid.StatusList+"#42" {
+		t.Errorf("credential = %+v", credential)
+	}
+	again, _ := Build(valid)
+	first, _ := json.Marshal(credential)
+	second, _ := json.Marshal(again)
+	if string(first) != string(second) {
+		t.Error("building the same source twice gives different credentials")
+	}
+
+	invalid := []struct {
+		name   string
+		change func(*Source)
+	}{
+		{"invalid metadata", func(s *Source) { s.Metadata.Status = models.MetadataInvalid }},
+		{"unreachable metadata", func(s *Source) { s.Metadata.Status = models.MetadataUnreachable }},
+		{"no issue date", func(s *Source) { s.Metadata.IssueDate = nil }},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			source := valid
+			tt.change(&source)
+			if _, err := Build(source); !errors.Is(err, ErrMetadataInvalid) {
+				t.Errorf("err = %v, want ErrMetadataInvalid", err)
+			}
+		})
+	}
+}
+
+func TestAttach(t *testing.T) {
+	issuerKey, issuer := generateKey(t)
+	otherKey, _ := generateKey(t)
+	_, owner := generateKey(t)
+	credential, err := Build(testSource(issuer, owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	typedData, err := TypedData(credential, testChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := signTypedData(t, typedData, issuerKey)
+
+	changed := credential
+	changed.CredentialSubject.Degree = "MSc Computer Science"
+	otherChain, _ := TypedData(credential, big.NewInt(1))
+	noV := hexutil.MustDecode(signature)
+	noV[crypto.RecoveryIDOffset] -= 27
+
+	tests := []struct {
+		name       string
+		credential Credential
+		signature  string
+		wantErr    error
+	}{
+		{"issuer signature", credential, signature, nil},
+		{"v as 0 or 1", credential, hexutil.Encode(noV), nil},
+		{"another wallet", credential, signTypedData(t, typedData, otherKey), ErrWrongSigner},
+		{"credential changed after signing", changed, signature, ErrWrongSigner},
+		{"signed for another chain", credential, signTypedData(t, otherChain, issuerKey), ErrWrongSigner},
+		{"not hex", credential, "signature", ErrWrongSigner},
+		{"truncated", credential, signature[:len(signature)-2], ErrWrongSigner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := time.Date(2024, 7, 1, 12, 0, 0, 500, time.UTC)
+			signed, err := Attach(tt.credential, testChainID, issuer, tt.signature, created)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if signed.Proof == nil || signed.Proof.ProofValue != tt.signature || signed.Proof.Type != ProofType ||
+				!signed.Proof.Created.Equal(created.Truncate(time.Second)) {
+				t.Fatalf("proof = %+v", signed.Proof)
+			}
+
+			// A verifier recomputes the hash from the document and the eip712 details in the proof
+			document, err := json.Marshal(signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var received Credential
+			if err := json.Unmarshal(document, &received); err != nil {
+				t.Fatal(err)
+			}
+			recomputed, err := TypedData(received, testChainID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recomputed.PrimaryType != received.Proof.Eip712.PrimaryType {
+				t.Errorf("primary type %s, proof says %s", recomputed.PrimaryType, received.Proof.Eip712.PrimaryType)
+			}
+			if err := checkSigner(recomputed, received.Proof.ProofValue, issuer); err != nil {
+				t.Errorf("signature does not verify on the received document: %v", err)
+			}
+		})
+	}
+}
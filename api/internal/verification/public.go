@@ -0,0 +1,89 @@
+package verification
+
+import (
+	"api/internal/chain"
+	"api/internal/ipfs"
+	"api/internal/models"
+	"api/internal/outbox"
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+	"math/big"
+	"time"
+)
+
+// PublicReport is what anyone holding a token ID may learn about a credential. It is limited to
+// facts already public on chain; the owner, the media location and anything stored off chain
+// about the student are left out.
+type PublicReport struct {
+	TokenID          string    `json:"token_id"`
+	Authentic        bool      `json:"authentic"` // Signed by a current institution, not revoked and, when checked, the file is the credential
+	Issuer           string    `json:"issuer"`
+	IssuerAuthorized bool      `json:"issuer_authorized"` // Issuer still holds INSTITUTION_ROLE
+	Status           string    `json:"status"`
+	Revoked          bool      `json:"revoked"`
+	RevocationReason string    `json:"revocation_reason,omitempty"`
+	RevocationTx     string    `json:"revocation_tx,omitempty"`
+	MediaHashMatch   bool      `json:"media_hash_match"`          // The stored media hashes to what the issuer signed
+	FileHashMatch    *bool     `json:"file_hash_match,omitempty"` // The given file hashes to what the issuer signed
+	BlockNumber      uint64    `json:"block_number"`
+	CheckedAt        time.Time `json:"checked_at"`
+}
+
+// Public verifies the credential for someone outside the platform. When fileHash is set, the
+// keccak256 of a file they were given, it also reports whether that file is the one the issuer
+// signed, checked by the contract at the report's block.
+func Public(ctx context.Context, db *gorm.DB, nftcms *chain.NFTCMS, client *ipfs.Client, tokenID *big.Int, fileHash *common.Hash) (PublicReport, error) {
+	report, err := Get(ctx, nftcms, client, tokenID)
+	if err != nil {
+		return PublicReport{}, err
+	}
+	passed := make(map[string]bool, len(report.Checks))
+	for _, check := range report.Checks {
+		passed[check.Name] = check.Passed
+	}
+
+	public := PublicReport{
+		TokenID:          report.TokenID,
+		Issuer:           report.Issuer,
+		IssuerAuthorized: passed[CheckIssuerRole],
+		Status:           report.Status,
+		Revoked:          !passed[CheckNotRevoked],
+		MediaHashMatch:   passed[CheckSignature],
+		BlockNumber:      report.BlockNumber,
+		CheckedAt:        report.VerifiedAt,
+	}
+	signed := public.MediaHashMatch
+	if fileHash != nil {
+		matches, err := nftcms.At(report.BlockNumber).VerifyCredential(ctx, tokenID, *fileHash)
+		if err != nil {
+			return PublicReport{}, err
+		}
+		public.FileHashMatch = &matches
+		signed = matches
+	}
+	public.Authentic = signed && public.IssuerAuthorized && !public.Revoked
+
+	if public.Revoked {
+		if public.RevocationReason, public.RevocationTx, err = revocation(db, tokenID); err != nil {
+			return PublicReport{}, err
+		}
+	}
+	return public, nil
+}
+
+// revocation returns the reason and transaction of the credential's revocation as recorded by
+// the revocation watcher, empty when it has not seen the revocation yet.
+func revocation(db *gorm.DB, tokenID *big.Int) (string, string, error) {
+	var entry models.AuditEntry
+	err := db.Where("action = ? AND resource = ?", models.AuditCredentialRevoke, outbox.CredentialAggregate(tokenID.String())).
+		Order("id DESC").Take(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return entry.Metadata["reason"], entry.Metadata["tx_hash"], nil
+}
@@ -0,0 +1,228 @@
+package verification
+
+import (
+	"api/internal/chain"
+	"api/internal/initializers"
+	"api/internal/ipfs"
+	"api/internal/ipfsuri"
+	"api/pkg/constants"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"log"
+	"math/big"
+	"time"
+)
+
+// Checks making up a report, in the order they are run
+const (
+	CheckMinted     = "minted"
+	CheckMediaHash  = "media_hash"
+	CheckSignature  = "signature"
+	CheckIssuerRole = "issuer_role"
+	CheckNotRevoked = "not_revoked"
+)
+
+// ErrNotMinted is returned for token IDs the contract has no credential for.
+var ErrNotMinted = errors.New("credential is not minted")
+
+// Check is the outcome of one step of a verification.
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report is the result of verifying a credential. Every chain read is made at BlockNumber, so
+// the checks agree with each other even when the credential changes while it is verified.
+type Report struct {
+	TokenID     string    `json:"token_id"`
+	Verified    bool      `json:"verified"` // Every check passed
+	Owner       string    `json:"owner"`
+	Issuer      string    `json:"issuer"` // Wallet that signed the media hash
+	Status      string    `json:"status"`
+	IPFSURI     string    `json:"ipfs_uri"`
+	MediaHash   string    `json:"media_hash,omitempty"` // keccak256 of the media, empty when it could not be hashed
+	Checks      []Check   `json:"checks"`
+	BlockNumber uint64    `json:"block_number"`
+	VerifiedAt  time.Time `json:"verified_at"`
+}
+
+func (r *Report) add(name string, passed bool, detail string) {
+	r.Checks = append(r.Checks, Check{Name: name, Passed: passed, Detail: detail})
+}
+
+// Verify downloads the credential's media, hashes it the way the client does before issuing
+// (keccak256 of the file bytes), has the contract check the issuer's signature over that hash
+// and checks the issuer still holds INSTITUTION_ROLE. Media that cannot be fetched right now is
+// an error rather than a failed check, since fetching it again may succeed.
+func Verify(ctx context.Context, nftcms *chain.NFTCMS, client *ipfs.Client, tokenID *big.Int) (Report, error) {
+	block, err := nftcms.BlockNumber(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+	at := nftcms.At(block)
+
+	credential, err := at.Credential(ctx, tokenID)
+	if err != nil {
+		return Report{}, err
+	}
+	if credential.TokenID == nil || credential.TokenID.Sign() == 0 {
+		return Report{}, ErrNotMinted
+	}
+	owner, err := at.OwnerOf(ctx, tokenID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		TokenID:     tokenID.String(),
+		Owner:       owner.Hex(),
+		Issuer:      credential.Signer.Hex(),
+		Status:      credential.Status.String(),
+		IPFSURI:     credential.IPFSURI,
+		BlockNumber: block,
+		VerifiedAt:  time.Now().UTC(),
+	}
+	report.add(CheckMinted, true, "")
+
+	hash, err := mediaHash(ctx, client, credential.IPFSURI)
+	switch {
+	case err == nil:
+		report.MediaHash = hash.Hex()
+		report.add(CheckMediaHash, true, "")
+		verified, err := at.VerifyCredential(ctx, tokenID, hash)
+		if err != nil {
+			return Report{}, err
+		}
+		detail := ""
+		if !verified {
+			detail = "signature was not made by the issuer over the media hash"
+		}
+		report.add(CheckSignature, verified, detail)
+	case isMalformed(err):
+		report.add(CheckMediaHash, false, err.Error())
+		report.add(CheckSignature, false, "media could not be hashed")
+	default:
+		return Report{}, fmt.Errorf("fetching %s: %w", credential.IPFSURI, err)
+	}
+
+	issuerRole, err := issuerRoleCheck(ctx, at, credential.Signer)
+	if err != nil {
+		return Report{}, err
+	}
+	report.Checks = append(report.Checks, issuerRole)
+
+	detail := ""
+	if credential.Status != chain.CredentialValid {
+		detail = "credential is " + credential.Status.String()
+	}
+	report.add(CheckNotRevoked, credential.Status == chain.CredentialValid, detail)
+
+	report.tally()
+	return report, nil
+}
+
+// tally sets Verified from the checks.
+func (r *Report) tally() {
+	r.Verified = true
+	for _, check := range r.Checks {
+		r.Verified = r.Verified && check.Passed
+	}
+}
+
+// issuerRoleCheck checks the issuer holds INSTITUTION_ROLE.
+func issuerRoleCheck(ctx context.Context, nftcms *chain.NFTCMS, issuer common.Address) (Check, error) {
+	isInstitution, err := nftcms.HasRole(ctx, chain.InstitutionRole, issuer)
+	if err != nil {
+		return Check{}, err
+	}
+	check := Check{Name: CheckIssuerRole, Passed: isInstitution}
+	if !isInstitution {
+		check.Detail = "issuer no longer holds INSTITUTION_ROLE"
+	}
+	return check, nil
+}
+
+// Get returns the cached report of the credential, verifying it when there is none. Reports are
+// cached for VerificationCacheTTL and dropped early when the credential is revoked. The issuer's
+// role is not cached: it is read again for every cached report, so a report never outlives the
+// issuer's RoleRevoked, which would otherwise have to drop every report of its credentials.
+func Get(ctx context.Context, nftcms *chain.NFTCMS, client *ipfs.Client, tokenID *big.Int) (Report, error) {
+	var report Report
+	cached, err := initializers.RedisClient.Get(ctx, cacheKey(tokenID)).Result()
+	if err == nil && json.Unmarshal([]byte(cached), &report) == nil {
+		issuerRole, err := issuerRoleCheck(ctx, nftcms, common.HexToAddress(report.Issuer))
+		if err != nil {
+			return Report{}, err
+		}
+		for i, check := range report.Checks {
+			if check.Name == CheckIssuerRole {
+				report.Checks[i] = issuerRole
+			}
+		}
+		report.tally()
+		return report, nil
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("Failed to read the cached verification of token %s: %v", tokenID, err)
+	}
+
+	report, err = Verify(ctx, nftcms, client, tokenID)
+	if err != nil {
+		return report, err
+	}
+	raw, err := json.Marshal(report)
+	if err != nil {
+		return report, err
+	}
+	if err := initializers.RedisClient.Set(ctx, cacheKey(tokenID), raw, constants.VerificationCacheTTL*time.Minute).Err(); err != nil {
+		log.Printf("Failed to cache the verification of token %s: %v", tokenID, err)
+	}
+	return report, nil
+}
+
+// Invalidate drops the cached report of the credential.
+func Invalidate(ctx context.Context, tokenID *big.Int) error {
+	return initializers.RedisClient.Del(ctx, cacheKey(tokenID)).Err()
+}
+
+func cacheKey(tokenID *big.Int) string {
+	return fmt.Sprintf("verification:report:%s", tokenID)
+}
+
+// mediaHash streams the file at the URI through keccak256.
+func mediaHash(ctx context.Context, client *ipfs.Client, rawURI string) (common.Hash, error) {
+	uri, err := ipfsuri.Parse(rawURI)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, constants.VerificationFetchTimeout*time.Second)
+	defer cancel()
+
+	id, err := client.Resolve(ctx, uri.CID, uri.Path)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	file, err := client.Open(ctx, id)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hasher := crypto.NewKeccakState()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(hasher.Sum(nil)), nil
+}
+
+// isMalformed reports whether the media could not be hashed because of what the credential
+// points at, in which case trying again cannot help.
+func isMalformed(err error) bool {
+	return errors.Is(err, ipfsuri.ErrInvalid) || errors.Is(err, ipfs.ErrNotAFile) ||
+		errors.Is(err, ipfs.ErrNotADirectory) || errors.Is(err, ipfs.ErrNoSuchLink)
+}
@@ -0,0 +1,52 @@
+package webhooks
+
+import (
+	"api/internal/models"
+	"gorm.io/gorm"
+)
+
+// CreateEndpoint registers a new endpoint for the wallet with a freshly generated secret.
+func CreateEndpoint(db *gorm.DB, ownerWallet, url string, eventTypes []string) (models.WebhookEndpoint, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return models.WebhookEndpoint{}, err
+	}
+	endpoint := models.WebhookEndpoint{
+		OwnerWallet: ownerWallet,
+		URL:         url,
+		Secret:      secret,
+		EventTypes:  eventTypes,
+		Active:      true,
+	}
+	err = db.Create(&endpoint).Error
+	return endpoint, err
+}
+
+func ListEndpoints(db *gorm.DB, ownerWallet string) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := db.Where("owner_wallet = ?", ownerWallet).Order("created_at ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func GetEndpoint(db *gorm.DB, endpointID, ownerWallet string) (models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	err := db.First(&endpoint, "id = ? AND owner_wallet = ?", endpointID, ownerWallet).Error
+	return endpoint, err
+}
+
+// DeleteEndpoint removes the endpoint and, through the foreign key, its deliveries.
+func DeleteEndpoint(db *gorm.DB, endpointID, ownerWallet string) (bool, error) {
+	result := db.Where("id = ? AND owner_wallet = ?", endpointID, ownerWallet).Delete(&models.WebhookEndpoint{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListDeliveries returns the latest deliveries of an endpoint, optionally only those in one status.
+func ListDeliveries(db *gorm.DB, endpointID string, status models.WebhookDeliveryStatus, limit int) ([]models.WebhookDelivery, error) {
+	query := db.Where("endpoint_id = ?", endpointID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []models.WebhookDelivery
+	err := query.Order("created_at DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
@@ -0,0 +1,132 @@
+package webhooks
+
+import (
+	"api/internal/models"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+// Event types delivered to webhook endpoints
+const (
+	EventRequestCreated    = string(models.EventRequestCreated)
+	EventRequestApproved   = string(models.EventRequestApproved)
+	EventRequestDenied     = string(models.EventRequestDenied)
+	EventRequestExpired    = string(models.EventRequestExpired)
+	EventCredentialRevoked = "credential.revoked"
+)
+
+var EventTypes = []string{
+	EventRequestCreated,
+	EventRequestApproved,
+	EventRequestDenied,
+	EventRequestExpired,
+	EventCredentialRevoked,
+}
+
+// IsEventType reports whether endpoints can subscribe to the event type.
+func IsEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the JSON body POSTed to an endpoint.
+type Event struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// Publish queues the event for every active endpoint that one of the audience wallets registered
+// for it. Publishing the same event ID again queues nothing new, so callers may retry.
+func Publish(db *gorm.DB, eventID, eventType string, createdAt time.Time, audience []string, data map[string]interface{}) error {
+	wallets := make([]string, 0, len(audience))
+	for _, wallet := range audience {
+		if wallet != "" {
+			wallets = append(wallets, strings.ToLower(wallet))
+		}
+	}
+	if len(wallets) == 0 {
+		return nil
+	}
+
+	subscribed, err := json.Marshal([]string{eventType})
+	if err != nil {
+		return err
+	}
+	var endpoints []models.WebhookEndpoint
+	if err := db.Where("active AND LOWER(owner_wallet) IN ?", wallets).
+		Where("event_types IS NULL OR jsonb_array_length(event_types) = 0 OR event_types @> ?::jsonb", string(subscribed)).
+		Find(&endpoints).Error; err != nil {
+		return err
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	event := Event{ID: eventID, Type: eventType, CreatedAt: createdAt.UTC(), Data: data}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       event.ID,
+			EventType:     eventType,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// GenerateSecret returns a new random endpoint signing secret.
+func GenerateSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(bytes), nil
+}
+
+// Signature returns the Webhook-Signature header value for a payload sent at timestamp.
+// Receivers recompute HMAC-SHA256(secret, "<timestamp>.<payload>") and compare it to v1.
+func Signature(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.", timestamp)))
+	mac.Write(payload)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// Redeliver puts a delivery of one of the wallet's endpoints back in the queue with fresh retries.
+func Redeliver(db *gorm.DB, deliveryID, ownerWallet string) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := db.Joins("Endpoint").
+		Where("webhook_deliveries.id = ? AND \"Endpoint\".owner_wallet = ?", deliveryID, ownerWallet).
+		First(&delivery).Error; err != nil {
+		return delivery, err
+	}
+	err := db.Model(&delivery).Updates(map[string]interface{}{
+		"status":          models.DeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+		"last_error":      "",
+	}).Error
+	return delivery, err
+}
@@ -0,0 +1,136 @@
+package webhooks
+
+import (
+	"api/internal/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignature(t *testing.T) {
+	payload := []byte(`{"event":"credential.revoked"}`)
+	got := Signature("whsec_5f1d", 1700000000, payload)
+	want := "t=1700000000,v1=46292ca30881093315134fdb9b320208df9891d99e34c4f44c32c93a2b0ec4a2"
+	if got != want {
+		t.Errorf("Signature = %q, want %q", got, want)
+	}
+}
+
+// roundTripFunc lets a test stand in for the network.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestSendSignsPayload(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	worker := &Worker{Client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		received = req
+		body, _ = io.ReadAll(req.Body)
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: req}, nil
+	})}}
+	endpoint := models.WebhookEndpoint{URL: "https://hooks.example.com/nft-cms", Secret: "whsec_5f1d", Active: true}
+	delivery := models.WebhookDelivery{EventID: "evt_1", EventType: "credential.revoked", Payload: `{"event":"credential.revoked"}`}
+
+	before := time.Now().Unix()
+	if _, err := worker.send(context.Background(), endpoint, delivery); err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != delivery.Payload {
+		t.Fatalf("body = %q, want %q", body, delivery.Payload)
+	}
+	if got := received.Header.Get("Webhook-Id"); got != "evt_1" {
+		t.Errorf("Webhook-Id = %q", got)
+	}
+	if got := received.Header.Get("Webhook-Event"); got != "credential.revoked" {
+		t.Errorf("Webhook-Event = %q", got)
+	}
+
+	// A receiver splits the header into its timestamp and v1 MAC and recomputes the MAC
+	header := received.Header.Get("Webhook-Signature")
+	parts := strings.Split(header, ",")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "t=") || !strings.HasPrefix(parts[1], "v1=") {
+		t.Fatalf("Webhook-Signature = %q, want t=<unix>,v1=<hex>", header)
+	}
+	timestamp, err := strconv.ParseInt(strings.TrimPrefix(parts[0], "t="), 10, 64)
+	if err != nil || timestamp < before || timestamp > time.Now().Unix() {
+		t.Fatalf("timestamp %q is not the send time", parts[0])
+	}
+	mac := hmac.New(sha256.New, []byte(endpoint.Secret))
+	fmt.Fprintf(mac, "%d.%s", timestamp, body)
+	if got, want := strings.TrimPrefix(parts[1], "v1="), hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("v1 = %s, want %s", got, want)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"https://hooks.example.com/nft-cms", nil},
+		{"https://93.184.216.34/hook", nil},
+		{"http://hooks.example.com/nft-cms", ErrInsecureURL},
+		{"https://localhost/hook", ErrForbiddenAddress},
+		{"https://api.LOCALHOST/hook", ErrForbiddenAddress},
+		{"https://127.0.0.1/hook", ErrForbiddenAddress},
+		{"https://[::1]/hook", ErrForbiddenAddress},
+		{"https://10.1.2.3/hook", ErrForbiddenAddress},
+		{"https://172.16.0.1/hook", ErrForbiddenAddress},
+		{"https://192.168.1.1/hook", ErrForbiddenAddress},
+		{"https://100.64.0.1/hook", ErrForbiddenAddress},
+		{"https://169.254.169.254/latest/meta-data", ErrForbiddenAddress},
+		{"https://[fe80::1]/hook", ErrForbiddenAddress},
+		{"https://[fd00::1]/hook", ErrForbiddenAddress},
+		{"https://0.0.0.0/hook", ErrForbiddenAddress},
+	}
+	for _, tt := range tests {
+		if err := ValidateURL(tt.url); !errors.Is(err, tt.want) {
+			t.Errorf("ValidateURL(%q) = %v, want %v", tt.url, err, tt.want)
+		}
+	}
+	for _, url := range []string{"", "https://", "not a url"} {
+		if err := ValidateURL(url); err == nil {
+			t.Errorf("ValidateURL(%q) accepted an invalid URL", url)
+		}
+	}
+}
+
+func TestNewClientRefusesInternalAddresses(t *testing.T) {
+	delivered := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered = true
+	}))
+	t.Cleanup(server.Close)
+
+	// The dialer checks the resolved address before connecting, so none of these leave the host
+	client := NewClient(2 * time.Second)
+	for _, url := range []string{
+		server.URL,                // loopback
+		"http://10.0.0.1/",        // private
+		"http://192.168.0.1/",     // private
+		"http://169.254.169.254/", // link-local, cloud metadata
+		"http://[fe80::1]/",       // link-local IPv6
+	} {
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("GET %s: err = %v, want ErrForbiddenAddress", url, err)
+		}
+	}
+	if delivered {
+		t.Error("client connected to a loopback address")
+	}
+}
@@ -0,0 +1,158 @@
+package webhooks
+
+import (
+	"api/internal/models"
+	"api/pkg/constants"
+	"bytes"
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Worker delivers queued webhook events with retries and exponential backoff. Deliveries that
+// still fail after WebhookMaxAttempts are marked dead and stay in the dead-letter queue until
+// they are redelivered. Several workers may run against the same database.
+type Worker struct {
+	DB           *gorm.DB
+	Client       *http.Client
+	BatchSize    int
+	PollInterval time.Duration
+}
+
+func NewWorker(db *gorm.DB) *Worker {
+	return &Worker{
+		DB:           db,
+		Client:       NewClient(constants.WebhookRequestTimeout * time.Second),
+		BatchSize:    50,
+		PollInterval: 5 * time.Second,
+	}
+}
+
+// Run delivers due events until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+	for {
+		deliveries, err := w.claim()
+		if err != nil {
+			log.Printf("Failed to claim webhook deliveries: %v", err)
+		}
+		for _, delivery := range deliveries {
+			w.deliver(ctx, delivery)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim leases due deliveries by pushing their next attempt forward, so that other workers skip
+// them while they are in flight and they are retried if this process dies mid-delivery.
+func (w *Worker) claim() ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := w.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(w.BatchSize).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]string, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+		lease := time.Now().Add(2 * w.Client.Timeout)
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", lease).Error
+	})
+	return deliveries, err
+}
+
+func (w *Worker) deliver(ctx context.Context, delivery models.WebhookDelivery) {
+	var endpoint models.WebhookEndpoint
+	if err := w.DB.First(&endpoint, "id = ?", delivery.EndpointID).Error; err != nil {
+		log.Printf("Failed to load webhook endpoint %s: %v", delivery.EndpointID, err)
+		return
+	}
+
+	statusCode, err := w.send(ctx, endpoint, delivery)
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":         attempts,
+		"last_status_code": statusCode,
+		"last_error":       "",
+	}
+	switch {
+	case err == nil:
+		updates["status"] = models.DeliverySucceeded
+	case !endpoint.Active || attempts >= constants.WebhookMaxAttempts:
+		updates["status"] = models.DeliveryDead
+		updates["last_error"] = err.Error()
+	default:
+		updates["next_attempt_at"] = time.Now().Add(Backoff(attempts))
+		updates["last_error"] = err.Error()
+	}
+
+	if err := w.DB.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+		log.Printf("Failed to update webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+func (w *Worker) send(ctx context.Context, endpoint models.WebhookEndpoint, delivery models.WebhookDelivery) (int, error) {
+	if !endpoint.Active {
+		return 0, fmt.Errorf("endpoint is disabled")
+	}
+	// Endpoints registered before URLs were restricted
+	if err := ValidateURL(endpoint.URL); err != nil {
+		return 0, err
+	}
+
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "NFT-CMS-Webhooks/1.0")
+	req.Header.Set("Webhook-Id", delivery.EventID)
+	req.Header.Set("Webhook-Event", delivery.EventType)
+	req.Header.Set("Webhook-Signature", Signature(endpoint.Secret, time.Now().Unix(), payload))
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the delay before the next attempt after the given number of failed attempts:
+// exponential from WebhookBaseBackoff up to WebhookMaxBackoff, with up to 10% jitter.
+func Backoff(attempts int) time.Duration {
+	delay := time.Duration(constants.WebhookBaseBackoff) * time.Second
+	max := time.Duration(constants.WebhookMaxBackoff) * time.Second
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/10+1))
+}
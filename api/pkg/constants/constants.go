@@ -1,6 +1,49 @@
 package constants
 
+// SystemActor is the actor recorded for changes made by background jobs.
+const SystemActor = "system"
+
+const (
+	NonceTokenTimeout        = 5    // minutes
+	SessionTokenTimeout      = 60   // minutes
+	IdempotencyKeyTimeout    = 1440 // minutes, overridable with IDEMPOTENCY_TTL_MINUTES
+	IdempotencyLockTimeout   = 1    // minutes, renewed while the handler runs
+	IdempotencyKeyMaxLen     = 255
+	RequestExpiryInterval    = 1     // minutes between expiry sweeps
+	WebhookMaxAttempts       = 8     // attempts before a delivery is dead-lettered
+	WebhookBaseBackoff       = 30    // seconds before the first retry
+	WebhookMaxBackoff        = 21600 // seconds, upper bound of the retry delay
+	WebhookRequestTimeout    = 10    // seconds
+	ChainPollInterval        = 15    // seconds between chain log polls
+	ChainConfirmations       = 2     // blocks a log must be buried under before it is processed
+	EmailVerificationTimeout = 1440  // minutes a verification link stays valid
+	ExpiryReminderWindow     = 1440  // minutes before expiry the student is reminded
+	ExpiryReminderInterval   = 10    // minutes between reminder sweeps
+	OutboxMaxAttempts        = 20    // attempts before an outbox event is given up on
+	OutboxBaseBackoff        = 5     // seconds before the first retry
+	OutboxMaxBackoff         = 600   // seconds, upper bound of the retry delay
+	OutboxRetention          = 168   // hours published events are kept
+	AuditVerifyInterval      = 60    // minutes between audit chain checks
+	AuditAnchorInterval      = 60    // minutes between anchors, overridable with AUDIT_ANCHOR_INTERVAL_MINUTES
+	AuditAnchorMaxEntries    = 10000 // entries per Merkle tree
+	AuditAnchorTimeout       = 5     // minutes to wait for an anchor to be mined
+	UploadMaxSize            = 20    // MiB, overridable with UPLOAD_MAX_SIZE_MB
+	PinCheckInterval         = 60    // minutes between pin checks, overridable with PINNING_CHECK_INTERVAL_MINUTES
+	PinQueueTimeout          = 1440  // minutes a pin may stay queued before it is requested again
+	MetadataIndexInterval    = 5     // minutes between metadata indexing passes
+	MetadataFetchTimeout     = 30    // seconds to fetch one metadata document
+	MetadataBaseBackoff      = 5     // minutes before unreachable metadata is fetched again
+	MetadataMaxBackoff       = 1440  // minutes, upper bound of the refetch delay
+	VerificationCacheTTL     = 10    // minutes a credential verification report is cached
+	VerificationFetchTimeout = 60    // seconds to download and hash credential media
+	PublicVerifyRateLimit    = 30    // public verifications per IP per minute, overridable with PUBLIC_VERIFY_RATE_LIMIT
+)
+
+// Streaming
+const StreamTicketTimeout = 1 // minutes a stream ticket may wait to be redeemed
+
+// Bitstring Status List
 const (
-	NonceTokenTimeout   = 5  // minutes
-	SessionTokenTimeout = 60 // minutes
+	StatusListSize     = 131072 // entries per status list, the W3C minimum of 16 KiB
+	StatusListCacheTTL = 300    // seconds verifiers may cache a status list
 )
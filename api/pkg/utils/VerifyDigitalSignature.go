@@ -2,6 +2,7 @@ package utils
 
 import (
 	"api/internal/customErrors"
+	"api/internal/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,31 +18,30 @@ func VerifyDigitalSignature(c *gin.Context) error {
 		return customErrors.ErrInsufficientHeaders
 	}
 
-	storedNonce, err := RetrieveFromRedis(WalletAddress)
+	// The nonce is used up by the first signature checked against it, valid or not, so each nonce
+	// records at most one failed login in the wallet's access log
+	storedNonce, err := TakeFromRedis(WalletAddress)
 	if err != nil {
 		return customErrors.ErrRequestNotFound
 	}
 
 	signature, err := VerifySignature(storedNonce, Signature, WalletAddress)
 	if err != nil {
+		recordLogin(c, WalletAddress, models.AuditFailure, "malformed signature")
 		return customErrors.ErrInvalidSignature
 
 	}
 
 	if !signature {
+		recordLogin(c, WalletAddress, models.AuditFailure, "signature mismatch")
 		return customErrors.ErrInvalidSignature
 	}
 
-	// if signature is valid, delete the nonce
-	err = DeleteFromRedis(WalletAddress)
-	if err != nil {
-		return customErrors.ErrFailedToCreateNonce
-	}
-
 	newToken, err := CreateSession(WalletAddress)
 	if err != nil {
 		return customErrors.ErrFailedToCreateSession
 	}
 	c.Header("Session-Token", newToken)
+	recordLogin(c, WalletAddress, models.AuditSuccess, "")
 	return nil
 }
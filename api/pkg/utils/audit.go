@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"api/internal/audit"
+	"api/internal/initializers"
+	"api/internal/models"
+	"github.com/gin-gonic/gin"
+	"log"
+)
+
+// AuditClient returns the client metadata recorded with audit entries.
+func AuditClient(c *gin.Context) audit.Client {
+	return audit.Client{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// recordLogin audits a sign-in attempt. A failure to record is logged rather than failing the
+// sign-in, which already happened.
+func recordLogin(c *gin.Context, walletAddress string, outcome models.AuditOutcome, reason string) {
+	var metadata map[string]string
+	if reason != "" {
+		metadata = map[string]string{"reason": reason}
+	}
+	if err := audit.Record(initializers.DB, AuditClient(c), models.AuditLogin, walletAddress, walletAddress, "session", outcome, metadata); err != nil {
+		log.Printf("Failed to record login: %v", err)
+	}
+}
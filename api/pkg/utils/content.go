@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"api/pkg/constants"
+	"fmt"
+)
+
+// KeyReleasePayload is the text a wallet signs (personal_sign) to receive the data key of an
+// encrypted document wrapped to its public key.
+func KeyReleasePayload(cid string) string {
+	return fmt.Sprintf("NFT-CMS key release\nCID: %s", cid)
+}
+
+// UploadMaxBytes is the size of the largest file accepted for upload.
+func UploadMaxBytes() int64 {
+	return int64(GetEnvInt("UPLOAD_MAX_SIZE_MB", constants.UploadMaxSize)) << 20
+}
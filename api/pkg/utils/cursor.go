@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"api/internal/customErrors"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// RequestCursor marks the last row of a page in a keyset-paginated request listing.
+type RequestCursor struct {
+	Sort  string    `json:"s"`
+	Value time.Time `json:"v"`
+	ID    string    `json:"id"`
+}
+
+func EncodeCursor(cursor RequestCursor) string {
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(encoded string) (RequestCursor, error) {
+	var cursor RequestCursor
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, customErrors.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == "" {
+		return cursor, customErrors.ErrInvalidCursor
+	}
+	return cursor, nil
+}
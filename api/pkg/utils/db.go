@@ -1,17 +1,219 @@
 package utils
 
 import (
+	"api/internal/audit"
+	"api/internal/customErrors"
+	"api/internal/ipfsuri"
 	"api/internal/models"
+	"api/internal/repository"
+	"api/pkg/constants"
+	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func CreateRequestEntry(db *gorm.DB, request models.Request) (models.Request, error) {
-	if err := db.Create(&request).Error; err != nil {
-		return request, err
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&request).Error; err != nil {
+			return err
+		}
+		// The recipient made the request, it is not unread in their outbox
+		if err := MarkRequestViewed(tx, request.ID, request.RecipientWallet); err != nil {
+			return err
+		}
+		return RecordLifecycleEvent(tx, request, models.EventRequestCreated, request.RecipientWallet, nil)
+	})
+	return request, err
+}
+
+// RespondToRequest records the student's response in a single transaction. The request row is
+// only updated if it is still pending at the version that was read (or expectedVersion, when
+// non-zero), so concurrent or stale responses fail with ErrRequestVersionConflict.
+func RespondToRequest(db *gorm.DB, walletAddress string, input repository.RespondRequestInput, expectedVersion int, client audit.Client) (models.Request, error) {
+	var request models.Request
+	var event models.RequestEventType
+	var eventData map[string]interface{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&request, "id = ? AND student_wallet = ?", input.RequestID, walletAddress).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return customErrors.ErrRequestNotFound
+			}
+			return err
+		}
+
+		if expectedVersion != 0 && request.Version != expectedVersion {
+			return customErrors.ErrRequestVersionConflict
+		}
+		// The expiry sweep runs every few minutes, a request past its expiry may still be pending
+		now := time.Now()
+		if request.Status == models.Expired || (request.Status == models.Pending && !now.Before(request.ExpiryTimestamp)) {
+			return customErrors.ErrRequestExpired
+		}
+		if request.Status != models.Pending {
+			return customErrors.ErrRequestNotPending
+		}
+
+		updates := map[string]interface{}{
+			"updated_at": now,
+			"version":    gorm.Expr("version + 1"),
+		}
+
+		switch input.Response {
+		case repository.Accept:
+			// NOTE: Check in blockchain if all the transcripts in the list are owned by student_wallet
+			transcriptIDs := uniqueTranscriptIDs(input)
+			var owned int64
+			if err := tx.Model(&models.Transcript{}).
+				Where("transcript_id IN ? AND owner_wallet = ?", transcriptIDs, walletAddress).
+				Count(&owned).Error; err != nil {
+				return err
+			}
+			if int(owned) != len(transcriptIDs) {
+				return customErrors.ErrUnauthorizedTranscript
+			}
+
+			if err := tx.Where("request_id = ?", request.ID).Delete(&models.RequestTranscript{}).Error; err != nil {
+				return err
+			}
+			requestTranscripts := make([]models.RequestTranscript, 0, len(transcriptIDs))
+			for _, transcriptID := range transcriptIDs {
+				requestTranscripts = append(requestTranscripts, models.RequestTranscript{
+					RequestID:    request.ID,
+					TranscriptID: transcriptID,
+				})
+			}
+			if err := tx.Create(&requestTranscripts).Error; err != nil {
+				return err
+			}
+			updates["status"] = models.Approved
+			event = models.EventRequestApproved
+			eventData = map[string]interface{}{"transcript_ids": transcriptIDs}
+		case repository.Reject:
+			updates["status"] = models.Denied
+			if input.Reason != "" {
+				updates["reason"] = input.Reason
+			}
+			event = models.EventRequestDenied
+			eventData = map[string]interface{}{"reason": input.Reason}
+		default:
+			return customErrors.ErrInvalidData
+		}
+
+		result := tx.Model(&models.Request{}).
+			Where("id = ? AND version = ? AND status = ? AND expiry_timestamp > ?", request.ID, request.Version, models.Pending, now).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return customErrors.ErrRequestVersionConflict
+		}
+
+		if err := tx.First(&request, "id = ?", request.ID).Error; err != nil {
+			return err
+		}
+		// The student's own response must not show as unread in their inbox
+		if err := MarkRequestViewed(tx, request.ID, walletAddress); err != nil {
+			return err
+		}
+
+		if err := RecordLifecycleEvent(tx, request, event, walletAddress, eventData); err != nil {
+			return err
+		}
+		return auditResponse(tx, client, request, eventData)
+	})
+	return request, err
+}
+
+// auditResponse records the student's decision in the audit log, one grant per shared transcript.
+func auditResponse(tx *gorm.DB, client audit.Client, request models.Request, eventData map[string]interface{}) error {
+	metadata := map[string]string{"request_id": request.ID, "recipient_wallet": request.RecipientWallet}
+	if request.Status != models.Approved {
+		return audit.Record(tx, client, models.AuditAccessDeny, request.StudentWallet, request.StudentWallet,
+			request.ID, models.AuditDenied, metadata)
+	}
+	for _, transcriptID := range eventData["transcript_ids"].([]string) {
+		if err := audit.Record(tx, client, models.AuditAccessGrant, request.StudentWallet, request.StudentWallet,
+			transcriptID, models.AuditAllowed, metadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueTranscriptIDs returns the transcript IDs of a response without duplicates, in input order.
+func uniqueTranscriptIDs(input repository.RespondRequestInput) []string {
+	seen := make(map[string]struct{}, len(input.TranscriptList))
+	transcriptIDs := make([]string, 0, len(input.TranscriptList))
+	for _, transcriptID := range input.TranscriptList {
+		id := transcriptID.String()
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		transcriptIDs = append(transcriptIDs, id)
 	}
-	return request, nil
+	return transcriptIDs
+}
+
+// ExpireRequests marks pending requests past their expiry as expired, returning how many it changed.
+func ExpireRequests(db *gorm.DB, now time.Time, limit int) (int, error) {
+	var requests []models.Request
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND expiry_timestamp <= ?", models.Pending, now).
+			Order("expiry_timestamp ASC").
+			Limit(limit).
+			Find(&requests).Error; err != nil {
+			return err
+		}
+
+		for i := range requests {
+			if err := tx.Model(&requests[i]).Updates(map[string]interface{}{
+				"status":     models.Expired,
+				"updated_at": now,
+				"version":    gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.First(&requests[i], "id = ?", requests[i].ID).Error; err != nil {
+				return err
+			}
+			if err := RecordLifecycleEvent(tx, requests[i], models.EventRequestExpired, constants.SystemActor, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(requests), nil
+}
+
+// CredentialAudience returns the wallets to notify about a change to the credential: its owner
+// and every recipient it was shared with through an approved request.
+func CredentialAudience(db *gorm.DB, tokenID string) ([]string, error) {
+	var owners []string
+	if err := db.Model(&models.Transcript{}).
+		Where("transcript_id = ?", tokenID).
+		Pluck("owner_wallet", &owners).Error; err != nil {
+		return nil, err
+	}
+
+	var recipients []string
+	if err := db.Model(&models.RequestTranscript{}).
+		Distinct("requests.recipient_wallet").
+		Joins("JOIN requests ON requests.id = request_transcripts.request_id").
+		Where("request_transcripts.transcript_id = ? AND requests.status = ?", tokenID, models.Approved).
+		Pluck("requests.recipient_wallet", &recipients).Error; err != nil {
+		return nil, err
+	}
+
+	return append(owners, recipients...), nil
 }
 
 func GetApprovedTranscripts(db *gorm.DB, recipientWallet string) ([]string, error) {
@@ -26,13 +228,142 @@ func GetApprovedTranscripts(db *gorm.DB, recipientWallet string) ([]string, erro
 	return transcriptIDs, err
 }
 
-func GetRequestsForStudent(db *gorm.DB, studentWallet string) ([]models.Request, error) {
+// filterRequests applies the wallet, status, counterparty and date filters of a listing.
+func filterRequests(db *gorm.DB, walletAddress string, input repository.ListRequestsInput) *gorm.DB {
+	query := db.Model(&models.Request{}).Where(fmt.Sprintf("%s = ?", input.Role.Column()), walletAddress)
+	if len(input.Status) > 0 {
+		query = query.Where("status IN ?", input.Status)
+	}
+	if input.Counterparty != "" {
+		query = query.Where(fmt.Sprintf("%s = ?", input.Role.CounterpartyColumn()), input.Counterparty)
+	}
+	if !input.CreatedAfter.IsZero() {
+		query = query.Where("created_at >= ?", input.CreatedAfter)
+	}
+	if !input.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", input.CreatedBefore)
+	}
+	if !input.ExpiresAfter.IsZero() {
+		query = query.Where("expiry_timestamp >= ?", input.ExpiresAfter)
+	}
+	if !input.ExpiresBefore.IsZero() {
+		query = query.Where("expiry_timestamp < ?", input.ExpiresBefore)
+	}
+	return query
+}
+
+// ListRequests returns one page of requests for the wallet along with the total number
+// of matching requests and the cursor of the next page ("" on the last page).
+func ListRequests(db *gorm.DB, walletAddress string, input repository.ListRequestsInput) ([]models.Request, int64, string, error) {
+	var total int64
+	if err := filterRequests(db, walletAddress, input).Count(&total).Error; err != nil {
+		return nil, 0, "", err
+	}
+
+	column := input.Sort.Column()
+	direction, comparison := "ASC", ">"
+	if input.Sort.Descending() {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := filterRequests(db, walletAddress, input)
+	if input.Cursor != "" {
+		cursor, err := DecodeCursor(input.Cursor)
+		if err != nil || cursor.Sort != string(input.Sort) {
+			return nil, 0, "", customErrors.ErrInvalidCursor
+		}
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparison), cursor.Value, cursor.ID)
+	}
+
+	// Fetch one extra row to find out whether another page exists
 	var requests []models.Request
-	err := db.Where("student_wallet = ?", studentWallet).Find(&requests).Error
-	return requests, err
+	if err := query.
+		Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(input.Limit + 1).
+		Find(&requests).Error; err != nil {
+		return nil, 0, "", err
+	}
+
+	nextCursor := ""
+	if len(requests) > input.Limit {
+		requests = requests[:input.Limit]
+		last := requests[len(requests)-1]
+		value := last.CreatedAt
+		if column == "expiry_timestamp" {
+			value = last.ExpiryTimestamp
+		}
+		nextCursor = EncodeCursor(RequestCursor{Sort: string(input.Sort), Value: value, ID: last.ID})
+	}
+
+	return requests, total, nextCursor, nil
+}
+
+// CountRequestsByStatus returns the number of matching requests per status, ignoring the status filter.
+func CountRequestsByStatus(db *gorm.DB, walletAddress string, input repository.ListRequestsInput) (map[models.RequestStatus]int64, error) {
+	input.Status = nil
+	var rows []struct {
+		Status models.RequestStatus
+		Count  int64
+	}
+	if err := filterRequests(db, walletAddress, input).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := map[models.RequestStatus]int64{models.Pending: 0, models.Approved: 0, models.Denied: 0, models.Expired: 0}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// CountUnreadRequests returns the number of matching requests the wallet has not viewed since
+// they last changed, across all pages.
+func CountUnreadRequests(db *gorm.DB, walletAddress string, input repository.ListRequestsInput) (int64, error) {
+	var unread int64
+	err := filterRequests(db, walletAddress, input).
+		Where("NOT EXISTS (SELECT 1 FROM request_views WHERE request_views.request_id = requests.id "+
+			"AND request_views.wallet = ? AND request_views.last_viewed_at >= requests.updated_at)", walletAddress).
+		Count(&unread).Error
+	return unread, err
+}
+
+// MarkRequestViewed upserts the time the wallet last viewed the request.
+func MarkRequestViewed(db *gorm.DB, requestID, walletAddress string) error {
+	view := models.RequestView{RequestID: requestID, Wallet: walletAddress, LastViewedAt: time.Now()}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "request_id"}, {Name: "wallet"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_viewed_at"}),
+	}).Create(&view).Error
 }
 
+// GetRequestViews returns the wallet's last view time keyed by request ID.
+func GetRequestViews(db *gorm.DB, walletAddress string, requestIDs []string) (map[string]time.Time, error) {
+	views := make(map[string]time.Time)
+	if len(requestIDs) == 0 {
+		return views, nil
+	}
+	var rows []models.RequestView
+	if err := db.Where("wallet = ? AND request_id IN ?", walletAddress, requestIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		views[row.RequestID] = row.LastViewedAt
+	}
+	return views, nil
+}
+
+// CreateTranscriptEntry stores the transcript with its IPFS URIs in canonical form.
 func CreateTranscriptEntry(db *gorm.DB, transcript models.Transcript) (models.Transcript, error) {
+	var err error
+	if transcript.IPFSURIMetadata, err = ipfsuri.Normalize(transcript.IPFSURIMetadata); err != nil {
+		return transcript, err
+	}
+	if transcript.IPFSURIMediaHash, err = ipfsuri.Normalize(transcript.IPFSURIMediaHash); err != nil {
+		return transcript, err
+	}
 	if err := db.Create(&transcript).Error; err != nil {
 		return transcript, err
 	}
@@ -77,22 +408,26 @@ func GetApprovedTranscript(db *gorm.DB, walletAddress string) ([]string, error)
 	return transcriptIDs, nil
 }
 
-func CheckAccess(db *gorm.DB, walletAddress string, ipfsURI string) (bool, error) {
+// CheckAccess reports whether the wallet may open the transcript stored at the IPFS URI, along
+// with the transcript, which is empty when no transcript has the URI. Transcripts store their
+// URIs in canonical form, so the URI matches whatever form it was given in.
+func CheckAccess(db *gorm.DB, walletAddress string, uri ipfsuri.URI) (models.Transcript, bool, error) {
 	var transcript models.Transcript
 
 	// 1. Find transcript by IPFS URI
-	err := db.Where("ipfs_uri_metadata = ? OR ipfs_uri_mediahash = ?", ipfsURI, ipfsURI).
+	canonical := uri.String()
+	err := db.Where("ipfs_uri_metadata = ? OR ipfs_uri_mediahash = ?", canonical, canonical).
 		First(&transcript).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return false, nil // No such transcript exists
+			return transcript, false, nil // No such transcript exists
 		}
-		return false, err // Database error
+		return transcript, false, err // Database error
 	}
 
 	// 2. Check if the walletAddress is the owner
 	if transcript.OwnerWallet == walletAddress {
-		return true, nil
+		return transcript, true, nil
 	}
 
 	// 3. Check if the walletAddress has approved access as recipient
@@ -102,8 +437,8 @@ func CheckAccess(db *gorm.DB, walletAddress string, ipfsURI string) (bool, error
 		Where("request_transcripts.transcript_id = ? AND requests.recipient_wallet = ? AND requests.status = ?", transcript.TranscriptID, walletAddress, models.Approved).
 		Count(&count).Error
 	if err != nil {
-		return false, err
+		return transcript, false, err
 	}
 
-	return count > 0, nil
+	return transcript, count > 0, nil
 }
@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"api/internal/audit"
+	"api/internal/customErrors"
+	"api/internal/models"
+	"api/internal/repository"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+var requestColumns = []string{"id", "student_wallet", "recipient_wallet", "status", "reason", "created_at", "updated_at", "expiry_timestamp", "version"}
+
+func requestRow(request models.Request) []driver.Value {
+	return []driver.Value{request.ID, request.StudentWallet, request.RecipientWallet, string(request.Status), request.Reason,
+		request.CreatedAt, request.UpdatedAt, request.ExpiryTimestamp, int64(request.Version)}
+}
+
+// listQuery matches the page query of ListRequests, with or without a cursor.
+var listQuery = regexp.MustCompile(`^SELECT \* FROM "requests" WHERE student_wallet = \$1` +
+	`(?: AND \((\w+), id\) ([<>]) \(\$2, \$3\))? ORDER BY (\w+) (ASC|DESC), id (ASC|DESC) LIMIT \$\d+$`)
+
+// serveListing answers the statements of ListRequests from requests the way Postgres would,
+// comparing (column, id) as a row.
+func serveListing(t *testing.T, requests []models.Request) func(string, []driver.Value) (fakeResult, error) {
+	return func(query string, args []driver.Value) (fakeResult, error) {
+		if strings.HasPrefix(query, "SELECT count(*)") {
+			return fakeResult{columns: []string{"count"}, rows: [][]driver.Value{{int64(len(requests))}}}, nil
+		}
+		match := listQuery.FindStringSubmatch(query)
+		if match == nil {
+			t.Fatalf("unexpected statement %s", query)
+		}
+		keyColumn, comparison, column, direction := match[1], match[2], match[3], match[4]
+		if keyColumn != "" && keyColumn != column {
+			t.Fatalf("cursor compares %s but the page is ordered by %s", keyColumn, column)
+		}
+		if direction != match[5] {
+			t.Fatalf("id is ordered %s, %s is ordered %s", match[5], column, direction)
+		}
+		key := func(request models.Request) time.Time {
+			if column == "expiry_timestamp" {
+				return request.ExpiryTimestamp
+			}
+			return request.CreatedAt
+		}
+		// compare orders a before b as (column, id) row values
+		compare := func(a time.Time, aID string, b time.Time, bID string) int {
+			if c := a.Compare(b); c != 0 {
+				return c
+			}
+			return strings.Compare(aID, bID)
+		}
+
+		var page []models.Request
+		for _, request := range requests {
+			if keyColumn != "" {
+				c := compare(key(request), request.ID, args[1].(time.Time), args[2].(string))
+				if (comparison == ">" && c <= 0) || (comparison == "<" && c >= 0) {
+					continue
+				}
+			}
+			page = append(page, request)
+		}
+		sort.Slice(page, func(i, j int) bool {
+			c := compare(key(page[i]), page[i].ID, key(page[j]), page[j].ID)
+			return (direction == "ASC" && c < 0) || (direction == "DESC" && c > 0)
+		})
+		if limit := int(args[len(args)-1].(int64)); len(page) > limit {
+			page = page[:limit]
+		}
+
+		result := fakeResult{columns: requestColumns}
+		for _, request := range page {
+			result.rows = append(result.rows, requestRow(request))
+		}
+		return result, nil
+	}
+}
+
+func TestListRequestsCursorContinuity(t *testing.T) {
+	// Several requests share their creation and expiry times, pages must still neither repeat
+	// nor skip any of them
+	base := time.Date(2024, 6, 1, 12, 0, 0, 123456000, time.UTC)
+	var requests []models.Request
+	for i, offsets := range [][2]time.Duration{{0, 0}, {0, time.Hour}, {0, time.Hour}, {time.Minute, time.Hour}, {time.Minute, 0}, {0, 2 * time.Hour}, {time.Minute, time.Hour}} {
+		requests = append(requests, models.Request{
+			ID:              fmt.Sprintf("00000000-0000-0000-0000-%012d", (i*5)%7),
+			StudentWallet:   "0xstudent",
+			RecipientWallet: "0xrecipient",
+			Status:          models.Pending,
+			CreatedAt:       base.Add(offsets[0]),
+			UpdatedAt:       base.Add(offsets[0]),
+			ExpiryTimestamp: base.Add(24*time.Hour + offsets[1]),
+			Version:         1,
+		})
+	}
+
+	for _, order := range []repository.SortEnum{repository.SortCreatedAsc, repository.SortCreatedDesc, repository.SortExpiryAsc, repository.SortExpiryDesc} {
+		t.Run(string(order), func(t *testing.T) {
+			db, _ := openFakeDB(t, serveListing(t, requests))
+
+			want := append([]models.Request(nil), requests...)
+			sort.Slice(want, func(i, j int) bool {
+				a, b := want[i].CreatedAt, want[j].CreatedAt
+				if order.Column() == "expiry_timestamp" {
+					a, b = want[i].ExpiryTimestamp, want[j].ExpiryTimestamp
+				}
+				if !a.Equal(b) {
+					return a.Before(b) != order.Descending()
+				}
+				return (want[i].ID < want[j].ID) != order.Descending()
+			})
+
+			input := repository.ListRequestsInput{Role: repository.StudentRole, Sort: order, Limit: 2}
+			var got []string
+			for page := 0; ; page++ {
+				if page > len(requests) {
+					t.Fatal("pagination does not end")
+				}
+				rows, total, next, err := ListRequests(db, "0xstudent", input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if total != int64(len(requests)) {
+					t.Errorf("total = %d, want %d", total, len(requests))
+				}
+				for _, row := range rows {
+					got = append(got, row.ID)
+				}
+				if next == "" {
+					break
+				}
+				input.Cursor = next
+			}
+
+			var wantIDs []string
+			for _, request := range want {
+				wantIDs = append(wantIDs, request.ID)
+			}
+			if strings.Join(got, " ") != strings.Join(wantIDs, " ") {
+				t.Errorf("pages returned\n%v\nwant\n%v", got, wantIDs)
+			}
+		})
+	}
+}
+
+func TestListRequestsInvalidCursor(t *testing.T) {
+	db, _ := openFakeDB(t, serveListing(t, nil))
+	cursor := EncodeCursor(RequestCursor{Sort: string(repository.SortCreatedDesc), Value: time.Now(), ID: "00000000-0000-0000-0000-000000000001"})
+
+	for name, input := range map[string]repository.ListRequestsInput{
+		"not base64":       {Sort: repository.SortCreatedDesc, Cursor: "%%%"},
+		"no ID":            {Sort: repository.SortCreatedDesc, Cursor: EncodeCursor(RequestCursor{Sort: string(repository.SortCreatedDesc)})},
+		"other sort order": {Sort: repository.SortExpiryAsc, Cursor: cursor},
+	} {
+		input.Role, input.Limit = repository.StudentRole, 2
+		if _, _, _, err := ListRequests(db, "0xstudent", input); err != customErrors.ErrInvalidCursor {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
+
+func TestRespondToRequestConflicts(t *testing.T) {
+	now := time.Now().UTC()
+	pending := models.Request{
+		ID:              "00000000-0000-0000-0000-000000000001",
+		StudentWallet:   "0xstudent",
+		RecipientWallet: "0xrecipient",
+		Status:          models.Pending,
+		CreatedAt:       now.Add(-time.Hour),
+		UpdatedAt:       now.Add(-time.Hour),
+		ExpiryTimestamp: now.Add(time.Hour),
+		Version:         2,
+	}
+	expired := pending
+	expired.Status = models.Expired
+	expired.Version = 3
+	unswept := pending
+	unswept.ExpiryTimestamp = now.Add(-time.Minute)
+	approved := pending
+	approved.Status = models.Approved
+
+	tests := []struct {
+		name            string
+		stored          models.Request
+		expectedVersion int
+		updated         int64 // Rows the conditional update changes
+		want            *customErrors.ApiError
+		wantStatus      int
+	}{
+		{"stale If-Match", pending, 1, 1, customErrors.ErrRequestVersionConflict, http.StatusConflict},
+		{"changed after it was read", pending, 2, 0, customErrors.ErrRequestVersionConflict, http.StatusConflict},
+		{"changed after it was read, no If-Match", pending, 0, 0, customErrors.ErrRequestVersionConflict, http.StatusConflict},
+		{"expired", expired, 0, 1, customErrors.ErrRequestExpired, http.StatusConflict},
+		{"past expiry, not swept yet", unswept, 2, 1, customErrors.ErrRequestExpired, http.StatusConflict},
+		{"already answered", approved, 0, 1, customErrors.ErrRequestNotPending, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var update string
+			db, fake := openFakeDB(t, func(query string, args []driver.Value) (fakeResult, error) {
+				switch {
+				case strings.HasPrefix(query, `SELECT * FROM "requests" WHERE id = $1 AND student_wallet = $2`):
+					return fakeResult{columns: requestColumns, rows: [][]driver.Value{requestRow(tt.stored)}}, nil
+				case strings.HasPrefix(query, `UPDATE "requests"`):
+					update = query
+					return fakeResult{affected: tt.updated}, nil
+				}
+				return fakeResult{}, fmt.Errorf("unexpected statement %s", query)
+			})
+
+			input := repository.RespondRequestInput{RequestID: tt.stored.ID, Response: repository.Reject, Reason: "no"}
+			_, err := RespondToRequest(db, tt.stored.StudentWallet, input, tt.expectedVersion, audit.Client{})
+			var apiErr *customErrors.ApiError
+			if !errors.As(err, &apiErr) || apiErr != tt.want {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if apiErr.Status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", apiErr.Status, tt.wantStatus)
+			}
+			if fake.rollbacks != 1 {
+				t.Errorf("%d rollbacks, want the transaction rolled back", fake.rollbacks)
+			}
+			// Stale and expired responses are refused before anything is written, a response racing
+			// another one is refused by the update only applying to what was checked
+			if (update != "") != (tt.updated == 0) {
+				t.Errorf("update issued: %q", update)
+			}
+			for _, condition := range []string{"WHERE id = $", "version = $", "status = $", "expiry_timestamp > $"} {
+				if update != "" && !strings.Contains(update, condition) {
+					t.Errorf("update does not check %q: %s", condition, update)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+)
+
+// GetEnvInt reads an integer environment variable, falling back to def when unset or invalid.
+func GetEnvInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
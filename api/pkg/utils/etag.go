@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"api/internal/customErrors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RequestETag returns the entity tag of a request at the given version. The approval timestamp is
+// stored after the approval without a new version, so its ID, when there is one, is part of the
+// tag too.
+func RequestETag(version int, timestampID uint) string {
+	if timestampID == 0 {
+		return fmt.Sprintf(`"%d"`, version)
+	}
+	return fmt.Sprintf(`"%d.%d"`, version, timestampID)
+}
+
+// ParseIfMatch returns the version named in an If-Match header, or 0 when the
+// header is empty or "*" and any version matches.
+func ParseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	header = strings.TrimPrefix(header, "W/")
+	tag, _, _ := strings.Cut(strings.Trim(header, `"`), ".")
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= 0 {
+		return 0, customErrors.ErrInvalidETag
+	}
+	return version, nil
+}
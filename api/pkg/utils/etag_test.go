@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"api/internal/customErrors"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		version int
+		wantErr bool
+	}{
+		{"no header", "", 0, false},
+		{"any version", "*", 0, false},
+		{"version", RequestETag(3, 0), 3, false},
+		{"version with approval timestamp", RequestETag(3, 12), 3, false},
+		{"weak tag", "W/" + RequestETag(4, 0), 4, false},
+		{"unquoted", "5", 5, false},
+		{"zero version", `"0"`, 0, true},
+		{"not a number", `"abc"`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := ParseIfMatch(tt.header)
+			if tt.wantErr {
+				if err != customErrors.ErrInvalidETag {
+					t.Errorf("err = %v, want ErrInvalidETag", err)
+				}
+				return
+			}
+			if err != nil || version != tt.version {
+				t.Errorf("ParseIfMatch(%q) = %d, %v, want %d", tt.header, version, err, tt.version)
+			}
+		})
+	}
+
+	if RequestETag(3, 0) == RequestETag(3, 12) {
+		t.Error("storing the approval timestamp does not change the ETag")
+	}
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"api/internal/models"
+	"api/internal/outbox"
+	"gorm.io/gorm"
+)
+
+// RecordRequestEvent appends an event to the history of a request. Pass the transaction
+// the change is made in so the event is only stored if the change is.
+func RecordRequestEvent(db *gorm.DB, requestID string, eventType models.RequestEventType, actorWallet string, data map[string]interface{}) error {
+	event := models.RequestEvent{
+		RequestID:   requestID,
+		Type:        eventType,
+		ActorWallet: actorWallet,
+		Data:        data,
+	}
+	return db.Create(&event).Error
+}
+
+// ListRequestEvents returns the history of a request, oldest first.
+func ListRequestEvents(db *gorm.DB, requestID string) ([]models.RequestEvent, error) {
+	var events []models.RequestEvent
+	err := db.Where("request_id = ?", requestID).Order("id ASC").Find(&events).Error
+	return events, err
+}
+
+// RecordLifecycleEvent records the event in the history of the request and queues it in the
+// outbox for both parties. Pass the transaction of the change so neither is stored without it.
+func RecordLifecycleEvent(db *gorm.DB, request models.Request, eventType models.RequestEventType, actorWallet string, data map[string]interface{}) error {
+	if err := RecordRequestEvent(db, request.ID, eventType, actorWallet, data); err != nil {
+		return err
+	}
+	return outbox.Enqueue(db, request.ID, string(eventType), []string{request.StudentWallet, request.RecipientWallet}, lifecyclePayload(request, data))
+}
+
+func lifecyclePayload(request models.Request, data map[string]interface{}) map[string]interface{} {
+	payload := map[string]interface{}{
+		"request_id":       request.ID,
+		"status":           request.Status,
+		"student_wallet":   request.StudentWallet,
+		"recipient_wallet": request.RecipientWallet,
+		"expiry_timestamp": request.ExpiryTimestamp,
+		"version":          request.Version,
+	}
+	for key, value := range data {
+		payload[key] = value
+	}
+	return payload
+}
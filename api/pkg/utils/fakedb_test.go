@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeResult is what the fake database answers to one statement.
+type fakeResult struct {
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+}
+
+// fakeDB is a database/sql driver that hands every statement, as the postgres dialect renders
+// it, to the test. No Postgres is needed to run the queries a function makes.
+type fakeDB struct {
+	mu         sync.Mutex
+	handle     func(query string, args []driver.Value) (fakeResult, error)
+	statements []string
+	rollbacks  int
+}
+
+var fakeDatabases sync.Map // DSN -> *fakeDB
+
+func init() {
+	sql.Register("fakedb", fakeDriver{})
+}
+
+// openFakeDB returns a GORM handle whose statements are answered by handle.
+func openFakeDB(t *testing.T, handle func(query string, args []driver.Value) (fakeResult, error)) (*gorm.DB, *fakeDB) {
+	t.Helper()
+	fake := &fakeDB{handle: handle}
+	fakeDatabases.Store(t.Name(), fake)
+	t.Cleanup(func() { fakeDatabases.Delete(t.Name()) })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DriverName: "fakedb", DSN: t.Name()}), &gorm.Config{
+		Logger:                 logger.Discard,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, fake
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	fake, ok := fakeDatabases.Load(dsn)
+	if !ok {
+		return nil, errors.New("fakedb: no database " + dsn)
+	}
+	return &fakeConn{db: fake.(*fakeDB)}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{c.db}, nil }
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{c.db}, nil
+}
+
+func (c *fakeConn) run(query string, named []driver.NamedValue) (fakeResult, error) {
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.statements = append(c.db.statements, query)
+	return c.db.handle(query, args)
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result, err := c.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{result: result}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := c.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(result.affected), nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (fakeTx) Commit() error { return nil }
+
+func (tx fakeTx) Rollback() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.rollbacks++
+	return nil
+}
+
+type fakeRows struct {
+	result fakeResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
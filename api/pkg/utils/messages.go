@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"api/internal/customErrors"
+	"api/internal/models"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// MessageSigningPayload is the text a wallet signs (personal_sign) to send a message on a request.
+func MessageSigningPayload(requestID, body string) string {
+	return fmt.Sprintf("NFT-CMS request message\nRequest: %s\n\n%s", requestID, body)
+}
+
+// GetRequestForParticipant loads a request the wallet is either the student or the recipient of.
+func GetRequestForParticipant(db *gorm.DB, requestID, walletAddress string) (models.Request, error) {
+	var request models.Request
+	err := db.Where("id = ? AND (student_wallet = ? OR recipient_wallet = ?)", requestID, walletAddress, walletAddress).
+		First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return request, customErrors.ErrRequestNotFound
+	}
+	return request, err
+}
+
+// CreateMessage stores a message on the request thread together with its history event.
+func CreateMessage(db *gorm.DB, request models.Request, senderWallet, body, signature string) (models.RequestMessage, error) {
+	message := models.RequestMessage{
+		RequestID:    request.ID,
+		SenderWallet: senderWallet,
+		Body:         body,
+		Signature:    signature,
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		return RecordLifecycleEvent(tx, request, models.EventMessageSent, senderWallet, map[string]interface{}{
+			"message_id": message.ID,
+		})
+	})
+	return message, err
+}
+
+// ListMessages returns the thread of a request, oldest first.
+func ListMessages(db *gorm.DB, requestID string) ([]models.RequestMessage, error) {
+	var messages []models.RequestMessage
+	err := db.Where("request_id = ?", requestID).Order("created_at ASC, id ASC").Find(&messages).Error
+	return messages, err
+}
+
+// MarkMessagesRead sets the read receipt on every unread message the other party sent,
+// recording a single history event when anything changed.
+func MarkMessagesRead(db *gorm.DB, request models.Request, readerWallet string) (int64, error) {
+	var marked int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RequestMessage{}).
+			Where("request_id = ? AND sender_wallet <> ? AND read_at IS NULL", request.ID, readerWallet).
+			Update("read_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		marked = result.RowsAffected
+		if marked == 0 {
+			return nil
+		}
+		return RecordLifecycleEvent(tx, request, models.EventMessageRead, readerWallet, map[string]interface{}{
+			"count": marked,
+		})
+	})
+	return marked, err
+}
@@ -27,3 +27,21 @@ func DeleteFromRedis(key string) error {
 	cmd := initializers.RedisClient.Del(ctx, key)
 	return cmd.Err()
 }
+
+// TakeFromRedis returns the value of key and deletes it, so it is returned once.
+func TakeFromRedis(key string) (string, error) {
+	var ctx = context.Background()
+	return initializers.RedisClient.GetDel(ctx, key).Result()
+}
+
+// ExtendInRedis resets the expiry of key, if it still exists.
+func ExtendInRedis(key string, minutes int) error {
+	var ctx = context.Background()
+	return initializers.RedisClient.Expire(ctx, key, time.Duration(minutes)*time.Minute).Err()
+}
+
+// StoreInRedisIfAbsent sets key only if it does not exist yet and reports whether it was set.
+func StoreInRedisIfAbsent(key, value string, minutes int) (bool, error) {
+	var ctx = context.Background()
+	return initializers.RedisClient.SetNX(ctx, key, value, time.Duration(minutes)*time.Minute).Result()
+}
@@ -41,3 +41,28 @@ func ValidateSession(address, sessionToken string) (bool, error) {
 	}
 	return storedToken == sessionToken, nil
 }
+
+// CreateStreamTicket returns a single-use ticket opening one event stream for the wallet. Tickets
+// go in the query string of EventSource and WebSocket URLs, so they expire within a minute and
+// never reveal the session token.
+func CreateStreamTicket(address string) (string, error) {
+	ticket, err := GenerateSessionToken()
+	if err != nil {
+		return "", err
+	}
+	err = StoreInRedis(fmt.Sprintf("stream-ticket:%s", ticket), address, constants.StreamTicketTimeout)
+	if err != nil {
+		return "", err
+	}
+	return ticket, nil
+}
+
+// RedeemStreamTicket returns the wallet the ticket was issued to and invalidates it, or an empty
+// address when the ticket is unknown, expired or already redeemed.
+func RedeemStreamTicket(ticket string) (string, error) {
+	address, err := TakeFromRedis(fmt.Sprintf("stream-ticket:%s", ticket))
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return address, err
+}
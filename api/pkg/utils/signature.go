@@ -2,6 +2,7 @@ package utils
 
 import (
 	"api/internal/customErrors"
+	"crypto/ecdsa"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -9,15 +10,31 @@ import (
 )
 
 func VerifySignature(message, signature, walletAddress string) (bool, error) {
+	pubKey, err := RecoverPublicKey(message, signature)
+	if err != nil {
+		return false, err
+	}
+
+	// Derive address from public key
+	recoveredAddr := crypto.PubkeyToAddress(*pubKey)
+
+	// Compare recovered address with provided address
+	providedAddr := common.HexToAddress(walletAddress)
+
+	return recoveredAddr == providedAddr, nil
+}
+
+// RecoverPublicKey returns the public key that produced a personal_sign signature over message.
+func RecoverPublicKey(message, signature string) (*ecdsa.PublicKey, error) {
 	// Convert hex signature to bytes
 	sig, err := hexutil.Decode(signature)
 	if err != nil {
-		return false, fmt.Errorf("%w: %v", customErrors.ErrInvalidSignatureFormat, err)
+		return nil, fmt.Errorf("%w: %v", customErrors.ErrInvalidSignatureFormat, err)
 	}
 
 	// Check signature length
 	if len(sig) != 65 {
-		return false, customErrors.ErrInvalidSignatureLength
+		return nil, customErrors.ErrInvalidSignatureLength
 	}
 
 	// Handle 'Ethereum Signed Message' prefix
@@ -26,21 +43,14 @@ func VerifySignature(message, signature, walletAddress string) (bool, error) {
 
 	// Convert signature to ECDSA format
 	if sig[64] != 27 && sig[64] != 28 {
-		return false, customErrors.ErrInvalidRecoveryID
+		return nil, customErrors.ErrInvalidRecoveryID
 	}
 	sig[64] -= 27
 
 	// Recover public key from signature
 	pubKey, err := crypto.SigToPub(hash.Bytes(), sig)
 	if err != nil {
-		return false, customErrors.ErrPublicKeyRecovery
+		return nil, customErrors.ErrPublicKeyRecovery
 	}
-
-	// Derive address from public key
-	recoveredAddr := crypto.PubkeyToAddress(*pubKey)
-
-	// Compare recovered address with provided address
-	providedAddr := common.HexToAddress(walletAddress)
-
-	return recoveredAddr == providedAddr, nil
+	return pubKey, nil
 }
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"api/internal/models"
+	"api/internal/tsa"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// approvalDocument is what is timestamped for an approval: the decision as recorded in the
+// request history. It is stored as the exact JSON that was hashed.
+type approvalDocument struct {
+	Type            string    `json:"type"`
+	RequestID       string    `json:"request_id"`
+	EventID         uint      `json:"event_id"`
+	StudentWallet   string    `json:"student_wallet"`
+	RecipientWallet string    `json:"recipient_wallet"`
+	TranscriptIDs   []string  `json:"transcript_ids"`
+	ApprovedAt      time.Time `json:"approved_at"`
+}
+
+// TimestampApproval has the authority timestamp the approval of the request. Approvals are
+// timestamped once; calling it again for the same approval does nothing.
+func TimestampApproval(ctx context.Context, db *gorm.DB, authority tsa.Authority, requestID string) error {
+	var event models.RequestEvent
+	err := db.WithContext(ctx).Where("request_id = ? AND type = ?", requestID, models.EventRequestApproved).Order("id DESC").Take(&event).Error
+	if err != nil {
+		return err
+	}
+	subjectID := fmt.Sprint(event.ID)
+	var existing int64
+	if err := db.WithContext(ctx).Model(&models.Timestamp{}).
+		Where("request_id = ? AND subject = ? AND subject_id = ?", requestID, models.TimestampApproval, subjectID).
+		Count(&existing).Error; err != nil || existing > 0 {
+		return err
+	}
+
+	var request models.Request
+	if err := db.WithContext(ctx).Take(&request, "id = ?", requestID).Error; err != nil {
+		return err
+	}
+	var transcriptIDs []string
+	if err := db.WithContext(ctx).Model(&models.RequestTranscript{}).Where("request_id = ?", requestID).
+		Order("transcript_id").Pluck("transcript_id", &transcriptIDs).Error; err != nil {
+		return err
+	}
+	document, err := json.Marshal(approvalDocument{
+		Type:            string(models.EventRequestApproved),
+		RequestID:       request.ID,
+		EventID:         event.ID,
+		StudentWallet:   request.StudentWallet,
+		RecipientWallet: request.RecipientWallet,
+		TranscriptIDs:   transcriptIDs,
+		ApprovedAt:      event.CreatedAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = storeTimestamp(ctx, db, authority, requestID, models.TimestampApproval, subjectID, document)
+	return err
+}
+
+// TimestampReceipt has the authority timestamp a stored receipt. Each receipt is timestamped
+// once; when its timestamp was stored concurrently that one is returned.
+func TimestampReceipt(ctx context.Context, db *gorm.DB, authority tsa.Authority, receipt models.VerificationReceipt) (models.Timestamp, error) {
+	return storeTimestamp(ctx, db, authority, receipt.RequestID, models.TimestampReceipt, receipt.ID, []byte(receipt.Document))
+}
+
+func storeTimestamp(ctx context.Context, db *gorm.DB, authority tsa.Authority, requestID string, subject models.TimestampSubject, subjectID string, document []byte) (models.Timestamp, error) {
+	digest := tsa.Digest(document)
+	token, err := authority.Timestamp(ctx, digest)
+	if err != nil {
+		return models.Timestamp{}, err
+	}
+	record := models.Timestamp{
+		RequestID:    requestID,
+		Subject:      subject,
+		SubjectID:    subjectID,
+		Document:     string(document),
+		Digest:       hex.EncodeToString(digest[:]),
+		Authority:    authority.Name(),
+		SerialNumber: token.SerialNumber,
+		GenTime:      token.GenTime,
+		Token:        token.DER,
+	}
+	result := db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return record, result.Error
+	}
+	err = db.WithContext(ctx).Where("request_id = ? AND subject = ? AND subject_id = ?", requestID, subject, subjectID).
+		Take(&record).Error
+	return record, err
+}
+
+// ApprovalTimestamp returns the timestamp of the request's latest approval, nil when there is
+// none yet.
+func ApprovalTimestamp(db *gorm.DB, requestID string) (*models.Timestamp, error) {
+	var record models.Timestamp
+	err := db.Where("request_id = ? AND subject = ?", requestID, models.TimestampApproval).Order("id DESC").Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ReceiptTimestamp returns the timestamp of a stored receipt, nil when it has none yet.
+func ReceiptTimestamp(db *gorm.DB, receipt models.VerificationReceipt) (*models.Timestamp, error) {
+	var record models.Timestamp
+	err := db.Where("request_id = ? AND subject = ? AND subject_id = ?", receipt.RequestID, models.TimestampReceipt, receipt.ID).
+		Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// LatestReceipt returns the receipt last issued for a transcript shared through the request, nil
+// when none was issued yet.
+func LatestReceipt(db *gorm.DB, requestID, transcriptID string) (*models.VerificationReceipt, error) {
+	var record models.VerificationReceipt
+	err := db.Where("request_id = ? AND transcript_id = ?", requestID, transcriptID).
+		Order("created_at DESC").Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+import "@openzeppelin/contracts/access/Ownable.sol";
+import "@openzeppelin/contracts/utils/cryptography/MerkleProof.sol";
+
+// Records Merkle roots of the off-chain server's audit log so anyone can check that an entry
+// existed, unchanged, when its batch was anchored.
+contract AuditAnchor is Ownable {
+
+    struct Anchor {
+        uint256 firstEntry;
+        uint256 lastEntry;
+        uint256 timestamp;
+    }
+
+    mapping(bytes32 => Anchor) public anchors;
+
+    event Anchored(bytes32 indexed root, uint256 firstEntry, uint256 lastEntry);
+
+    constructor() Ownable(msg.sender) {}
+
+    function anchor(bytes32 root, uint256 firstEntry, uint256 lastEntry)
+        external
+        onlyOwner {
+        require(anchors[root].timestamp == 0, "Root already anchored");
+        require(firstEntry <= lastEntry, "Invalid entry range");
+        anchors[root] = Anchor(firstEntry, lastEntry, block.timestamp);
+        emit Anchored(root, firstEntry, lastEntry);
+    }
+
+    // leaf is keccak256 of the entry hash; pairs are hashed in sorted order
+    function verifyEntry(bytes32 root, bytes32 leaf, bytes32[] calldata proof)
+        external
+        view
+        returns (bool) {
+        return anchors[root].timestamp != 0 && MerkleProof.verifyCalldata(proof, root, leaf);
+    }
+}
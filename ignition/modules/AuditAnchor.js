@@ -0,0 +1,9 @@
+// This setup uses Hardhat Ignition to manage smart contract deployments.
+// Learn more about it at https://hardhat.org/ignition
+
+import { buildModule } from "@nomicfoundation/hardhat-ignition/modules";
+
+export default buildModule("AuditAnchor", (m) => {
+  const AuditAnchor = m.contract("AuditAnchor");
+  return { AuditAnchor };
+});